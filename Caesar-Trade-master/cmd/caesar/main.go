@@ -7,7 +7,9 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/caesar-terminal/caesar/internal/adapter"
 	"github.com/caesar-terminal/caesar/internal/config"
+	"github.com/caesar-terminal/caesar/internal/gateway"
 )
 
 func main() {
@@ -22,6 +24,34 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	// No exchange adapters are wired in yet, so nothing feeds the
+	// Broadcaster; the gateway stays behind gateway.enabled until they are.
+	if !cfg.Gateway.Enabled {
+		fmt.Println("Market-data gateway disabled: CAESAR_GATEWAY_ENABLED not set")
+	} else {
+		bc := adapter.NewBroadcaster()
+		ub := adapter.NewUnifiedBook(bc, 0)
+		go bc.Run(ctx)
+		go ub.Run(ctx)
+
+		auth := make(gateway.TokenAuth, len(cfg.Gateway.Tokens))
+		for name, token := range cfg.Gateway.Tokens {
+			auth[token] = name
+		}
+		gwCfg := gateway.DefaultConfig(auth)
+		gwCfg.AllowedOrigins = cfg.Gateway.AllowedOrigins
+
+		gw := gateway.New(gwCfg, bc, ub)
+		go gw.Run(ctx)
+		go func() {
+			fmt.Printf("Market-data gateway listening on %s\n", cfg.Gateway.Addr)
+			if err := gw.ListenAndServe(ctx, cfg.Gateway.Addr); err != nil {
+				fmt.Fprintf(os.Stderr, "gateway: %v\n", err)
+				cancel()
+			}
+		}()
+	}
+
 	<-ctx.Done()
 	fmt.Println("Caesar shutting down")
 }
@@ -0,0 +1,286 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BackpressurePolicy decides what a fan-out does when a subscriber's buffer
+// is full.
+type BackpressurePolicy int
+
+const (
+	// DropNewest discards the incoming message. This is the default and
+	// matches the original fan-out behaviour.
+	DropNewest BackpressurePolicy = iota
+	// DropOldest evicts the oldest buffered message to make room, so the
+	// subscriber always holds the most recent Buffer messages.
+	DropOldest
+	// BlockWithTimeout waits up to SubscribeOptions.Timeout for room before
+	// dropping. The producer stalls for that subscriber only while waiting.
+	BlockWithTimeout
+	// DisconnectSlow closes the subscriber's channel on the first overflow
+	// and removes it from the fan-out.
+	DisconnectSlow
+)
+
+func (p BackpressurePolicy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop-newest"
+	case DropOldest:
+		return "drop-oldest"
+	case BlockWithTimeout:
+		return "block-with-timeout"
+	case DisconnectSlow:
+		return "disconnect-slow"
+	default:
+		return "unknown"
+	}
+}
+
+// SubscribeOptions configures a subscriber's buffer and backpressure policy.
+// The zero value gives the component's default buffer size and DropNewest.
+type SubscribeOptions struct {
+	Buffer  int
+	Policy  BackpressurePolicy
+	Timeout time.Duration // BlockWithTimeout only
+}
+
+// Reasons a subscriber's channel was closed, reported by Subscription.Err.
+// A subscription tied to a context reports the context's error instead.
+var (
+	ErrUnsubscribed   = errors.New("adapter: unsubscribed")
+	ErrFeedClosed     = errors.New("adapter: feed closed")
+	ErrSlowSubscriber = errors.New("adapter: evicted as slow subscriber")
+	ErrMarketRemoved  = errors.New("adapter: market removed")
+	ErrSourceClosed   = errors.New("adapter: source closed")
+)
+
+// SubscriberStats reports delivery counters for a single subscriber.
+type SubscriberStats struct {
+	Delivered uint64
+	Dropped   uint64
+	Evicted   bool   // true once DisconnectSlow has closed the channel
+	Conflated uint64 // updates superseded before being read (conflating subscribers only)
+}
+
+// Subscription is a handle to a buffered subscriber channel. It applies the
+// subscriber's backpressure policy on every delivery and keeps per-subscriber
+// drop counters.
+type Subscription[T any] struct {
+	ch   chan T
+	opts SubscribeOptions
+	name string // identifies the subscriber in logs
+
+	// mu serialises delivery against close so a DisconnectSlow eviction
+	// never races a concurrent send from another producer goroutine.
+	mu     sync.Mutex
+	closed bool
+	err    error // why the channel was closed
+
+	// detach removes the subscription from its owner's fan-out and is set
+	// before the subscription is published. stop releases its context
+	// watch and is guarded by mu.
+	detach func()
+	stop   func() bool
+	once   sync.Once
+
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	evicted   atomic.Bool
+}
+
+func newSubscription[T any](name string, opts SubscribeOptions, defaultBuffer int) *Subscription[T] {
+	if opts.Buffer <= 0 {
+		opts.Buffer = defaultBuffer
+	}
+	return &Subscription[T]{
+		ch:   make(chan T, opts.Buffer),
+		opts: opts,
+		name: name,
+	}
+}
+
+// C returns the subscriber's channel.
+func (s *Subscription[T]) C() <-chan T { return s.ch }
+
+// Stats returns a snapshot of the subscriber's delivery counters.
+func (s *Subscription[T]) Stats() SubscriberStats {
+	return SubscriberStats{
+		Delivered: s.delivered.Load(),
+		Dropped:   s.dropped.Load(),
+		Evicted:   s.evicted.Load(),
+	}
+}
+
+// Err returns nil while the subscription is open, and the reason once its
+// channel has been closed: ErrUnsubscribed, ErrFeedClosed,
+// ErrSlowSubscriber, ErrMarketRemoved, ErrSourceClosed or the error of the
+// context it was tied to.
+func (s *Subscription[T]) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Unsubscribe removes the subscriber from its fan-out and closes its
+// channel. Buffered messages can still be drained. It is safe to call more
+// than once.
+func (s *Subscription[T]) Unsubscribe() {
+	s.end(ErrUnsubscribed)
+}
+
+// watch ends the subscription with ctx's error once ctx is done.
+func (s *Subscription[T]) watch(ctx context.Context) {
+	if ctx.Done() == nil {
+		return
+	}
+	stop := context.AfterFunc(ctx, func() { s.end(ctx.Err()) })
+	s.mu.Lock()
+	s.stop = stop
+	s.mu.Unlock()
+}
+
+// end closes the channel with err and detaches the subscription once.
+func (s *Subscription[T]) end(err error) {
+	s.closeWith(err)
+	s.once.Do(func() {
+		s.mu.Lock()
+		stop := s.stop
+		s.mu.Unlock()
+		if stop != nil {
+			stop()
+		}
+		if s.detach != nil {
+			s.detach()
+		}
+	})
+}
+
+// deliver hands v to the subscriber according to its policy. It returns
+// false once the subscriber is closed or has been evicted, signalling the
+// caller to remove it from its fan-out list.
+func (s *Subscription[T]) deliver(v T) bool {
+	return s.send(v, true)
+}
+
+// prime is like deliver but never waits for room: under BlockWithTimeout
+// a full buffer drops v at once. Owners prime new subscribers with cached
+// values while holding the lock their producers need, so waiting there
+// would stall delivery to every other subscriber.
+func (s *Subscription[T]) prime(v T) bool {
+	return s.send(v, false)
+}
+
+// send implements deliver and prime; wait allows BlockWithTimeout to
+// wait for room.
+func (s *Subscription[T]) send(v T, wait bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	select {
+	case s.ch <- v:
+		s.delivered.Add(1)
+		return true
+	default:
+	}
+
+	switch s.opts.Policy {
+	case DropOldest:
+		for {
+			select {
+			case <-s.ch:
+				s.recordDrop()
+			default:
+			}
+			select {
+			case s.ch <- v:
+				s.delivered.Add(1)
+				return true
+			default:
+				// The reader raced us; evict again.
+			}
+		}
+
+	case BlockWithTimeout:
+		if !wait {
+			s.recordDrop()
+			return true
+		}
+		timer := time.NewTimer(s.opts.Timeout)
+		defer timer.Stop()
+		select {
+		case s.ch <- v:
+			s.delivered.Add(1)
+		case <-timer.C:
+			s.recordDrop()
+		}
+		return true
+
+	case DisconnectSlow:
+		s.recordDrop()
+		s.evicted.Store(true)
+		s.closed = true
+		s.err = ErrSlowSubscriber
+		close(s.ch)
+		log.Printf("%s: evicting slow subscriber after %d deliveries", s.name, s.delivered.Load())
+		return false
+
+	default: // DropNewest
+		s.recordDrop()
+		return true
+	}
+}
+
+// recordDrop counts a dropped message, logging the first and every 1000th
+// so a persistently slow consumer is visible without flooding the log.
+func (s *Subscription[T]) recordDrop() {
+	n := s.dropped.Add(1)
+	if n == 1 || n%1000 == 0 {
+		log.Printf("%s: slow subscriber, %d message(s) dropped (%s)", s.name, n, s.opts.Policy)
+	}
+}
+
+// close closes the channel if it is not already closed, reporting
+// ErrFeedClosed.
+func (s *Subscription[T]) close() {
+	s.closeWith(ErrFeedClosed)
+}
+
+// closeWith closes the channel with err if it is not already closed.
+func (s *Subscription[T]) closeWith(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		s.err = err
+		close(s.ch)
+	}
+}
+
+// without returns subs minus every element of drop, preserving order.
+func without[T any](subs []*Subscription[T], drop []*Subscription[T]) []*Subscription[T] {
+	out := subs[:0:0]
+	for _, s := range subs {
+		keep := true
+		for _, d := range drop {
+			if s == d {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			out = append(out, s)
+		}
+	}
+	return out
+}
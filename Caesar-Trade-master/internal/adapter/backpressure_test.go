@@ -0,0 +1,91 @@
+package adapter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscription_DropNewest(t *testing.T) {
+	sub := newSubscription[int]("test", SubscribeOptions{Buffer: 2}, 0)
+	for i := 1; i <= 4; i++ {
+		if !sub.deliver(i) {
+			t.Fatalf("deliver(%d) evicted under DropNewest", i)
+		}
+	}
+
+	if got := []int{<-sub.C(), <-sub.C()}; got[0] != 1 || got[1] != 2 {
+		t.Fatalf("want oldest messages [1 2] kept, got %v", got)
+	}
+	if st := sub.Stats(); st.Delivered != 2 || st.Dropped != 2 {
+		t.Fatalf("stats: want 2 delivered / 2 dropped, got %+v", st)
+	}
+}
+
+func TestSubscription_DropOldest(t *testing.T) {
+	sub := newSubscription[int]("test", SubscribeOptions{Buffer: 2, Policy: DropOldest}, 0)
+	for i := 1; i <= 4; i++ {
+		sub.deliver(i)
+	}
+
+	if got := []int{<-sub.C(), <-sub.C()}; got[0] != 3 || got[1] != 4 {
+		t.Fatalf("want newest messages [3 4] kept, got %v", got)
+	}
+	if st := sub.Stats(); st.Delivered != 4 || st.Dropped != 2 {
+		t.Fatalf("stats: want 4 delivered / 2 dropped, got %+v", st)
+	}
+}
+
+func TestSubscription_BlockWithTimeout(t *testing.T) {
+	sub := newSubscription[int]("test", SubscribeOptions{
+		Buffer:  1,
+		Policy:  BlockWithTimeout,
+		Timeout: 200 * time.Millisecond,
+	}, 0)
+	sub.deliver(1)
+
+	// A reader frees a slot while the producer is blocked.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		<-sub.C()
+	}()
+	sub.deliver(2)
+	if got := <-sub.C(); got != 2 {
+		t.Fatalf("want 2 delivered after blocking, got %d", got)
+	}
+
+	// No reader: the producer gives up after Timeout.
+	sub.deliver(3)
+	start := time.Now()
+	sub.deliver(4)
+	if waited := time.Since(start); waited < 150*time.Millisecond {
+		t.Fatalf("expected to block ~200ms, returned after %v", waited)
+	}
+	if st := sub.Stats(); st.Dropped != 1 {
+		t.Fatalf("stats: want 1 dropped, got %+v", st)
+	}
+}
+
+func TestSubscription_DisconnectSlow(t *testing.T) {
+	sub := newSubscription[int]("test", SubscribeOptions{Buffer: 1, Policy: DisconnectSlow}, 0)
+	if !sub.deliver(1) {
+		t.Fatal("first delivery should succeed")
+	}
+	if sub.deliver(2) {
+		t.Fatal("overflow should evict the subscriber")
+	}
+	if sub.deliver(3) {
+		t.Fatal("deliveries after eviction should report false")
+	}
+
+	if got := <-sub.C(); got != 1 {
+		t.Fatalf("buffered message lost: got %d", got)
+	}
+	if _, ok := <-sub.C(); ok {
+		t.Fatal("channel should be closed after eviction")
+	}
+	if st := sub.Stats(); !st.Evicted || st.Dropped != 1 {
+		t.Fatalf("stats: want evicted with 1 dropped, got %+v", st)
+	}
+
+	sub.close() // idempotent after eviction
+}
@@ -2,7 +2,8 @@ package adapter
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"sort"
 	"sync"
 )
 
@@ -12,118 +13,658 @@ type UpdatesProvider interface {
 	Updates() <-chan BookUpdate
 }
 
+// TradesProvider is implemented by adapters that also emit trade prints.
+// Register picks it up automatically.
+type TradesProvider interface {
+	Trades() <-chan Trade
+}
+
+// MarketRemover is told when an adapter stops carrying a market, so it can
+// release the market's state. Broadcaster and CircuitBreaker implement it.
+type MarketRemover interface {
+	RemoveMarket(exchange Exchange, marketID string)
+}
+
 // subKey identifies a filtered subscription by exchange and market.
 type subKey struct {
 	Exchange Exchange
 	MarketID string
 }
 
+// bookKey identifies one book: a Polymarket market has a book per outcome
+// token, a Kalshi market a single one.
+type bookKey struct {
+	subKey
+	AssetID string
+}
+
+func bookKeyOf(u BookUpdate) bookKey {
+	return bookKey{subKey{Exchange: u.Exchange, MarketID: u.MarketID}, u.AssetID}
+}
+
+// Registration is a source registered with a Broadcaster.
+type Registration struct {
+	b      *Broadcaster
+	books  <-chan BookUpdate
+	trades <-chan Trade // nil if the provider emits no trades
+
+	cancel context.CancelFunc // set once the source is running
+
+	// mu guards markets, the set of markets the source has carried.
+	mu      sync.Mutex
+	markets map[subKey]bool
+}
+
+// Unregister stops the Broadcaster reading from the source. Filtered
+// subscribers of markets no other source carries are closed with
+// ErrSourceClosed. It is safe to call more than once.
+func (r *Registration) Unregister() {
+	b := r.b
+	b.srcMu.Lock()
+	if !b.dropSource(r) {
+		b.srcMu.Unlock()
+		return
+	}
+	cancel := r.cancel
+	b.srcMu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	b.releaseMarkets(r)
+}
+
 // Broadcaster is a many-to-many hub that ingests BookUpdates from any number
 // of exchange adapters and distributes them to filtered subscribers and a
-// unified "all" stream.
+// unified "all" stream. Trades from adapters that emit them flow through a
+// parallel set of subscriptions. Sources and subscribers may come and go
+// while it runs.
 type Broadcaster struct {
-	sources []<-chan BookUpdate
+	// srcMu guards the registered sources and the Run state.
+	srcMu   sync.Mutex
+	sources []*Registration
+	runCtx  context.Context // nil until Run
+	wg      sync.WaitGroup
+
+	// Filtered subscribers keyed by (exchange, marketID), queueing and
+	// conflating, and the index of Filter subscribers.
+	mu        sync.RWMutex
+	subs      map[subKey][]*Subscription[BookUpdate]
+	conflated map[subKey][]*ConflatedSubscription
+	filters   filterIndex
+
+	// cacheMu guards cache, the latest update of every book by market and
+	// asset. It is written under mu so a filtered subscriber is primed with
+	// exactly the updates preceding its first delivery.
+	cacheMu sync.RWMutex
+	cache   map[subKey]map[string]BookUpdate
+
+	// allMu guards the unified subscriber lists.
+	allMu        sync.RWMutex
+	allSub       []*Subscription[BookUpdate]
+	conflatedAll []*ConflatedSubscription
 
-	// Filtered subscribers keyed by (exchange, marketID).
-	mu   sync.RWMutex
-	subs map[subKey][]chan BookUpdate
+	// tradeMu guards the trade subscribers, filtered and unified.
+	tradeMu     sync.RWMutex
+	tradeSubs   map[subKey][]*Subscription[Trade]
+	tradeAllSub []*Subscription[Trade]
 
-	// allMu guards the unified subscriber list.
-	allMu  sync.RWMutex
-	allSub []chan BookUpdate
+	latency *LatencyTracker // nil disables recording
 }
 
 // NewBroadcaster creates a Broadcaster ready for adapter registration.
 func NewBroadcaster() *Broadcaster {
 	return &Broadcaster{
-		subs: make(map[subKey][]chan BookUpdate),
+		subs:      make(map[subKey][]*Subscription[BookUpdate]),
+		conflated: make(map[subKey][]*ConflatedSubscription),
+		cache:     make(map[subKey]map[string]BookUpdate),
+		filters:   newFilterIndex(),
+		tradeSubs: make(map[subKey][]*Subscription[Trade]),
 	}
 }
 
-// Register adds an adapter's update channel as a source. Must be called
-// before Run.
-func (b *Broadcaster) Register(provider UpdatesProvider) {
-	b.sources = append(b.sources, provider.Updates())
+// Register adds an adapter's update channel as a source, and its trade
+// channel too if it implements TradesProvider. It may be called before or
+// during Run; a source registered during Run is read from at once.
+//
+// When the source's update channel closes, or it is unregistered, filtered
+// subscribers of the markets it carried are closed with ErrSourceClosed
+// unless another source has carried the same market.
+func (b *Broadcaster) Register(provider UpdatesProvider) *Registration {
+	r := &Registration{
+		b:       b,
+		books:   provider.Updates(),
+		markets: make(map[subKey]bool),
+	}
+	if tp, ok := provider.(TradesProvider); ok {
+		r.trades = tp.Trades()
+	}
+
+	b.srcMu.Lock()
+	defer b.srcMu.Unlock()
+	b.sources = append(b.sources, r)
+	if b.runCtx != nil && b.runCtx.Err() == nil {
+		b.start(r)
+	}
+	return r
+}
+
+// SetLatencyTracker records receive, parse and distribution latencies of
+// every update into lt. Must be called before Run.
+func (b *Broadcaster) SetLatencyTracker(lt *LatencyTracker) {
+	b.latency = lt
 }
 
 // Subscribe returns a buffered channel that receives BookUpdates for the
-// given exchange and market. The caller must drain the channel to avoid
-// dropped messages.
-func (b *Broadcaster) Subscribe(exchange Exchange, marketID string) <-chan BookUpdate {
-	ch := make(chan BookUpdate, 256)
+// given exchange and market until ctx is done, when the subscriber is
+// removed and the channel closed. If the market has been seen, the latest
+// update of each of its books is delivered first. The caller must drain
+// the channel to avoid dropped messages.
+func (b *Broadcaster) Subscribe(ctx context.Context, exchange Exchange, marketID string) <-chan BookUpdate {
+	return b.SubscribeWith(ctx, exchange, marketID, SubscribeOptions{}).C()
+}
+
+// SubscribeWith is like Subscribe but applies the given buffer size and
+// backpressure policy, and returns a handle exposing drop counters, the
+// close reason and Unsubscribe. Priming never waits for room, even under
+// BlockWithTimeout: cached books that do not fit are dropped.
+func (b *Broadcaster) SubscribeWith(ctx context.Context, exchange Exchange, marketID string, opts SubscribeOptions) *Subscription[BookUpdate] {
 	key := subKey{Exchange: exchange, MarketID: marketID}
+	sub := newSubscription[BookUpdate](
+		fmt.Sprintf("broadcaster %s/%s", exchange, marketID), opts, 256)
+	sub.detach = func() {
+		b.mu.Lock()
+		b.subs[key] = without(b.subs[key], []*Subscription[BookUpdate]{sub})
+		if len(b.subs[key]) == 0 {
+			delete(b.subs, key)
+		}
+		b.mu.Unlock()
+	}
 
 	b.mu.Lock()
-	b.subs[key] = append(b.subs[key], ch)
+	b.subs[key] = append(b.subs[key], sub)
+	for _, update := range b.Latest(exchange, marketID) {
+		sub.prime(update)
+	}
 	b.mu.Unlock()
 
-	return ch
+	sub.watch(ctx)
+	return sub
 }
 
 // SubscribeAll returns a buffered channel that receives every BookUpdate
-// regardless of exchange or market. Intended for logging, metrics, or
-// persistence (e.g. Redis in Ticket 2.6).
-func (b *Broadcaster) SubscribeAll() <-chan BookUpdate {
-	ch := make(chan BookUpdate, 512)
+// regardless of exchange or market until ctx is done. It is primed with the
+// latest update of every known book, one of which may arrive again if it
+// was being distributed at that moment; a subscriber whose buffer is
+// smaller than the book count loses part of the snapshot under its
+// backpressure policy. Intended for logging, metrics, or persistence (e.g.
+// Redis in Ticket 2.6).
+func (b *Broadcaster) SubscribeAll(ctx context.Context) <-chan BookUpdate {
+	return b.SubscribeAllWith(ctx, SubscribeOptions{}).C()
+}
+
+// SubscribeAllWith is like SubscribeAll but applies the given buffer size
+// and backpressure policy, and returns a handle exposing drop counters, the
+// close reason and Unsubscribe.
+func (b *Broadcaster) SubscribeAllWith(ctx context.Context, opts SubscribeOptions) *Subscription[BookUpdate] {
+	sub := newSubscription[BookUpdate]("broadcaster all", opts, 512)
+	sub.detach = func() {
+		b.allMu.Lock()
+		b.allSub = without(b.allSub, []*Subscription[BookUpdate]{sub})
+		b.allMu.Unlock()
+	}
+
+	b.allMu.Lock()
+	b.allSub = append(b.allSub, sub)
+	for _, update := range b.snapshot() {
+		sub.prime(update)
+	}
+	b.allMu.Unlock()
+
+	sub.watch(ctx)
+	return sub
+}
+
+// SubscribeFilter returns a buffered channel that receives the BookUpdates
+// matching f until ctx is done, starting with the cached update of every
+// matching book. Use it for exchange-wide, watchlist, series-prefix or
+// predicate subscriptions instead of filtering SubscribeAll client side.
+func (b *Broadcaster) SubscribeFilter(ctx context.Context, f Filter) <-chan BookUpdate {
+	return b.SubscribeFilterWith(ctx, f, SubscribeOptions{}).C()
+}
+
+// SubscribeFilterWith is like SubscribeFilter but applies the given buffer
+// size and backpressure policy, and returns a handle. Unlike exact-market
+// subscribers, it is not closed by RemoveMarket or a closing source.
+func (b *Broadcaster) SubscribeFilterWith(ctx context.Context, f Filter, opts SubscribeOptions) *Subscription[BookUpdate] {
+	sub := newSubscription[BookUpdate]("broadcaster filter", opts, 512)
+	fs := newFilterSub(f, sub)
+	sub.detach = func() {
+		b.mu.Lock()
+		b.filters.remove(fs)
+		b.mu.Unlock()
+	}
+
+	b.mu.Lock()
+	b.filters.add(fs)
+	for _, update := range b.snapshot() {
+		if fs.matches(update) {
+			sub.prime(update)
+		}
+	}
+	b.mu.Unlock()
+
+	sub.watch(ctx)
+	return sub
+}
+
+// SubscribeConflated returns a conflating subscriber for the given exchange
+// and market: it holds only the latest unread update of each of the
+// market's books, so a slow reader skips intermediate books instead of
+// lagging behind them. It starts with the cached updates, if any, and ends
+// when ctx is done.
+func (b *Broadcaster) SubscribeConflated(ctx context.Context, exchange Exchange, marketID string) *ConflatedSubscription {
+	key := subKey{Exchange: exchange, MarketID: marketID}
+	sub := newConflatedSubscription()
+	sub.detach = func() {
+		b.mu.Lock()
+		b.conflated[key] = withoutConflated(b.conflated[key], sub)
+		if len(b.conflated[key]) == 0 {
+			delete(b.conflated, key)
+		}
+		b.mu.Unlock()
+	}
+
+	b.mu.Lock()
+	b.conflated[key] = append(b.conflated[key], sub)
+	for _, update := range b.Latest(exchange, marketID) {
+		sub.deliver(update)
+	}
+	b.mu.Unlock()
+
+	sub.watch(ctx)
+	return sub
+}
+
+// SubscribeAllConflated returns a conflating subscriber across every
+// exchange and market, holding the latest unread update per book and
+// starting with the whole cache. Suited to UI and persistence consumers
+// that only care about current prices.
+func (b *Broadcaster) SubscribeAllConflated(ctx context.Context) *ConflatedSubscription {
+	sub := newConflatedSubscription()
+	sub.detach = func() {
+		b.allMu.Lock()
+		b.conflatedAll = withoutConflated(b.conflatedAll, sub)
+		b.allMu.Unlock()
+	}
 
 	b.allMu.Lock()
-	b.allSub = append(b.allSub, ch)
+	b.conflatedAll = append(b.conflatedAll, sub)
+	for _, update := range b.snapshot() {
+		sub.deliver(update)
+	}
 	b.allMu.Unlock()
 
-	return ch
+	sub.watch(ctx)
+	return sub
+}
+
+// Latest returns the most recent BookUpdate distributed for each of the
+// market's books, ordered by asset ID: one per outcome token of a
+// Polymarket market, one for a Kalshi market. It returns nil if none has
+// been seen since the market was last removed.
+func (b *Broadcaster) Latest(exchange Exchange, marketID string) []BookUpdate {
+	b.cacheMu.RLock()
+	books := b.cache[subKey{Exchange: exchange, MarketID: marketID}]
+	if len(books) == 0 {
+		b.cacheMu.RUnlock()
+		return nil
+	}
+	out := make([]BookUpdate, 0, len(books))
+	for _, update := range books {
+		out = append(out, update)
+	}
+	b.cacheMu.RUnlock()
+
+	sortBooks(out)
+	return out
+}
+
+// snapshot returns the cached update of every book.
+func (b *Broadcaster) snapshot() []BookUpdate {
+	b.cacheMu.RLock()
+	var out []BookUpdate
+	for _, books := range b.cache {
+		for _, update := range books {
+			out = append(out, update)
+		}
+	}
+	b.cacheMu.RUnlock()
+
+	sortBooks(out)
+	return out
+}
+
+// sortBooks orders updates by exchange, market ID and asset ID.
+func sortBooks(updates []BookUpdate) {
+	sort.Slice(updates, func(i, j int) bool {
+		a, b := updates[i], updates[j]
+		if a.Exchange != b.Exchange {
+			return a.Exchange < b.Exchange
+		}
+		if a.MarketID != b.MarketID {
+			return a.MarketID < b.MarketID
+		}
+		return a.AssetID < b.AssetID
+	})
+}
+
+// SubscribeTrades returns a buffered channel that receives Trades for the
+// given exchange and market until ctx is done.
+func (b *Broadcaster) SubscribeTrades(ctx context.Context, exchange Exchange, marketID string) <-chan Trade {
+	return b.SubscribeTradesWith(ctx, exchange, marketID, SubscribeOptions{}).C()
+}
+
+// SubscribeTradesWith is like SubscribeTrades but applies the given buffer
+// size and backpressure policy, and returns a handle.
+func (b *Broadcaster) SubscribeTradesWith(ctx context.Context, exchange Exchange, marketID string, opts SubscribeOptions) *Subscription[Trade] {
+	key := subKey{Exchange: exchange, MarketID: marketID}
+	sub := newSubscription[Trade](
+		fmt.Sprintf("broadcaster trades %s/%s", exchange, marketID), opts, 256)
+	sub.detach = func() {
+		b.tradeMu.Lock()
+		b.tradeSubs[key] = without(b.tradeSubs[key], []*Subscription[Trade]{sub})
+		if len(b.tradeSubs[key]) == 0 {
+			delete(b.tradeSubs, key)
+		}
+		b.tradeMu.Unlock()
+	}
+
+	b.tradeMu.Lock()
+	b.tradeSubs[key] = append(b.tradeSubs[key], sub)
+	b.tradeMu.Unlock()
+
+	sub.watch(ctx)
+	return sub
+}
+
+// SubscribeAllTrades returns a buffered channel that receives every Trade
+// regardless of exchange or market until ctx is done.
+func (b *Broadcaster) SubscribeAllTrades(ctx context.Context) <-chan Trade {
+	return b.SubscribeAllTradesWith(ctx, SubscribeOptions{}).C()
+}
+
+// SubscribeAllTradesWith is like SubscribeAllTrades but applies the given
+// buffer size and backpressure policy, and returns a handle.
+func (b *Broadcaster) SubscribeAllTradesWith(ctx context.Context, opts SubscribeOptions) *Subscription[Trade] {
+	sub := newSubscription[Trade]("broadcaster all trades", opts, 512)
+	sub.detach = func() {
+		b.tradeMu.Lock()
+		b.tradeAllSub = without(b.tradeAllSub, []*Subscription[Trade]{sub})
+		b.tradeMu.Unlock()
+	}
+
+	b.tradeMu.Lock()
+	b.tradeAllSub = append(b.tradeAllSub, sub)
+	b.tradeMu.Unlock()
+
+	sub.watch(ctx)
+	return sub
+}
+
+// RemoveMarket closes and drops every filtered book and trade subscriber of
+// the market with ErrMarketRemoved, telling its consumers that no more data
+// will arrive, and forgets its cached updates. Unified subscribers are
+// unaffected.
+func (b *Broadcaster) RemoveMarket(exchange Exchange, marketID string) {
+	b.closeMarket(subKey{Exchange: exchange, MarketID: marketID}, ErrMarketRemoved)
+}
+
+// closeMarket ends every filtered subscriber of key with err and drops the
+// cached updates.
+func (b *Broadcaster) closeMarket(key subKey, err error) {
+	b.mu.Lock()
+	subs := b.subs[key]
+	conflated := b.conflated[key]
+	delete(b.subs, key)
+	delete(b.conflated, key)
+	b.cacheMu.Lock()
+	delete(b.cache, key)
+	b.cacheMu.Unlock()
+	b.mu.Unlock()
+	for _, sub := range subs {
+		sub.end(err)
+	}
+	for _, sub := range conflated {
+		sub.end(err)
+	}
+
+	b.tradeMu.Lock()
+	tradeSubs := b.tradeSubs[key]
+	delete(b.tradeSubs, key)
+	b.tradeMu.Unlock()
+	for _, sub := range tradeSubs {
+		sub.end(err)
+	}
 }
 
 // Run starts consuming from all registered sources and distributing updates.
 // It blocks until ctx is cancelled. Each source gets its own goroutine.
 func (b *Broadcaster) Run(ctx context.Context) {
-	var wg sync.WaitGroup
-
-	for _, src := range b.sources {
-		wg.Add(1)
-		go func(ch <-chan BookUpdate) {
-			defer wg.Done()
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case update, ok := <-ch:
-					if !ok {
-						return
-					}
-					b.distribute(update)
-				}
+	b.srcMu.Lock()
+	b.runCtx = ctx
+	for _, r := range b.sources {
+		b.start(r)
+	}
+	b.srcMu.Unlock()
+
+	<-ctx.Done()
+	b.wg.Wait()
+}
+
+// start launches the goroutine reading r. Callers hold srcMu.
+func (b *Broadcaster) start(r *Registration) {
+	ctx, cancel := context.WithCancel(b.runCtx)
+	r.cancel = cancel
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer cancel()
+		if b.consume(ctx, r) {
+			b.srcMu.Lock()
+			dropped := b.dropSource(r)
+			b.srcMu.Unlock()
+			if dropped {
+				b.releaseMarkets(r)
 			}
-		}(src)
+		}
+	}()
+}
+
+// consume distributes r's updates and trades until ctx is done or the
+// update channel closes, reporting true in the latter case.
+func (b *Broadcaster) consume(ctx context.Context, r *Registration) bool {
+	books, trades := r.books, r.trades
+	seen := make(map[subKey]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case update, ok := <-books:
+			if !ok {
+				return true
+			}
+			b.note(r, seen, subKey{Exchange: update.Exchange, MarketID: update.MarketID})
+			b.distribute(update)
+		case trade, ok := <-trades:
+			if !ok {
+				trades = nil
+				continue
+			}
+			b.note(r, seen, subKey{Exchange: trade.Exchange, MarketID: trade.MarketID})
+			b.distributeTrade(trade)
+		}
+	}
+}
+
+// note records that r carries key. seen is the consuming goroutine's own
+// copy, keeping the lock off the hot path.
+func (b *Broadcaster) note(r *Registration, seen map[subKey]bool, key subKey) {
+	if seen[key] {
+		return
+	}
+	seen[key] = true
+	r.mu.Lock()
+	r.markets[key] = true
+	r.mu.Unlock()
+}
+
+// dropSource removes r from the registered sources, reporting whether it
+// was still registered. Callers hold srcMu.
+func (b *Broadcaster) dropSource(r *Registration) bool {
+	for i, s := range b.sources {
+		if s == r {
+			b.sources = append(b.sources[:i:i], b.sources[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// releaseMarkets closes the filtered subscribers of every market r carried
+// that no remaining source has carried.
+func (b *Broadcaster) releaseMarkets(r *Registration) {
+	r.mu.Lock()
+	orphaned := make(map[subKey]bool, len(r.markets))
+	for key := range r.markets {
+		orphaned[key] = true
+	}
+	r.mu.Unlock()
+
+	b.srcMu.Lock()
+	for _, other := range b.sources {
+		other.mu.Lock()
+		for key := range other.markets {
+			delete(orphaned, key)
+		}
+		other.mu.Unlock()
 	}
+	b.srcMu.Unlock()
 
-	wg.Wait()
+	for key := range orphaned {
+		b.closeMarket(key, ErrSourceClosed)
+	}
 }
 
-// distribute sends an update to all matching filtered subscribers and all
-// unified subscribers. Non-blocking: slow consumers get messages dropped.
+// distribute caches an update as its book's latest and sends it to all
+// matching filtered subscribers and all unified subscribers, applying each
+// subscriber's backpressure policy. Evicted subscribers are removed
+// afterwards.
 func (b *Broadcaster) distribute(update BookUpdate) {
 	key := subKey{Exchange: update.Exchange, MarketID: update.MarketID}
 
+	update.Trace.Mark(StageDistributed)
+	b.latency.ObserveStage(update, StageReceived)
+	b.latency.ObserveStage(update, StageParsed)
+	b.latency.ObserveStage(update, StageDistributed)
+
+	var evicted []*Subscription[BookUpdate]
 	b.mu.RLock()
-	if subs, ok := b.subs[key]; ok {
-		for _, ch := range subs {
-			select {
-			case ch <- update:
-			default:
-				log.Printf("broadcaster: dropping update for slow subscriber (%s/%s)",
-					update.Exchange, update.MarketID)
-			}
+	b.cacheMu.Lock()
+	books := b.cache[key]
+	if books == nil {
+		books = make(map[string]BookUpdate, 1)
+		b.cache[key] = books
+	}
+	books[update.AssetID] = update
+	b.cacheMu.Unlock()
+	for _, sub := range b.subs[key] {
+		if !sub.deliver(update) {
+			evicted = append(evicted, sub)
 		}
 	}
+	for _, sub := range b.conflated[key] {
+		sub.deliver(update)
+	}
+	var evictedFilters []*Subscription[BookUpdate]
+	b.filters.each(update, func(fs *filterSub) {
+		if !fs.sub.deliver(update) {
+			evictedFilters = append(evictedFilters, fs.sub)
+		}
+	})
 	b.mu.RUnlock()
+	if len(evicted) > 0 {
+		b.mu.Lock()
+		b.subs[key] = without(b.subs[key], evicted)
+		if len(b.subs[key]) == 0 {
+			delete(b.subs, key)
+		}
+		b.mu.Unlock()
+	}
+	for _, sub := range evictedFilters {
+		sub.end(ErrSlowSubscriber)
+	}
 
+	evicted = nil
 	b.allMu.RLock()
-	for _, ch := range b.allSub {
-		select {
-		case ch <- update:
-		default:
-			// Slow unified subscriber — drop.
+	for _, sub := range b.allSub {
+		if !sub.deliver(update) {
+			evicted = append(evicted, sub)
 		}
 	}
+	for _, sub := range b.conflatedAll {
+		sub.deliver(update)
+	}
 	b.allMu.RUnlock()
+	if len(evicted) > 0 {
+		b.allMu.Lock()
+		b.allSub = without(b.allSub, evicted)
+		b.allMu.Unlock()
+	}
+}
+
+// distributeTrade sends a trade to all matching filtered and unified trade
+// subscribers. Evicted subscribers are removed afterwards.
+func (b *Broadcaster) distributeTrade(trade Trade) {
+	key := subKey{Exchange: trade.Exchange, MarketID: trade.MarketID}
+
+	var evicted, evictedAll []*Subscription[Trade]
+	b.tradeMu.RLock()
+	for _, sub := range b.tradeSubs[key] {
+		if !sub.deliver(trade) {
+			evicted = append(evicted, sub)
+		}
+	}
+	for _, sub := range b.tradeAllSub {
+		if !sub.deliver(trade) {
+			evictedAll = append(evictedAll, sub)
+		}
+	}
+	b.tradeMu.RUnlock()
+
+	if len(evicted) == 0 && len(evictedAll) == 0 {
+		return
+	}
+	b.tradeMu.Lock()
+	if len(evicted) > 0 {
+		b.tradeSubs[key] = without(b.tradeSubs[key], evicted)
+		if len(b.tradeSubs[key]) == 0 {
+			delete(b.tradeSubs, key)
+		}
+	}
+	if len(evictedAll) > 0 {
+		b.tradeAllSub = without(b.tradeAllSub, evictedAll)
+	}
+	b.tradeMu.Unlock()
+}
+
+// withoutConflated returns subs minus drop, preserving order.
+func withoutConflated(subs []*ConflatedSubscription, drop *ConflatedSubscription) []*ConflatedSubscription {
+	out := subs[:0:0]
+	for _, s := range subs {
+		if s != drop {
+			out = append(out, s)
+		}
+	}
+	return out
 }
@@ -2,6 +2,7 @@ package adapter
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -19,6 +20,14 @@ func (m *mockProvider) Updates() <-chan BookUpdate { return m.ch }
 
 func (m *mockProvider) send(update BookUpdate) { m.ch <- update }
 
+// mockTradeProvider also emits trades.
+type mockTradeProvider struct {
+	*mockProvider
+	trades chan Trade
+}
+
+func (m *mockTradeProvider) Trades() <-chan Trade { return m.trades }
+
 func TestBroadcaster_MultipleAdapters(t *testing.T) {
 	poly := newMockProvider()
 	kalshi := newMockProvider()
@@ -27,7 +36,7 @@ func TestBroadcaster_MultipleAdapters(t *testing.T) {
 	bc.Register(poly)
 	bc.Register(kalshi)
 
-	all := bc.SubscribeAll()
+	all := bc.SubscribeAll(context.Background())
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -62,8 +71,8 @@ func TestBroadcaster_FilteredSubscribers(t *testing.T) {
 	bc := NewBroadcaster()
 	bc.Register(poly)
 
-	subA := bc.Subscribe(ExchangePolymarket, "mkt-A")
-	subB := bc.Subscribe(ExchangePolymarket, "mkt-B")
+	subA := bc.Subscribe(context.Background(), ExchangePolymarket, "mkt-A")
+	subB := bc.Subscribe(context.Background(), ExchangePolymarket, "mkt-B")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -112,14 +121,10 @@ func TestBroadcaster_SlowSubscriber(t *testing.T) {
 	bc.Register(poly)
 
 	// slowSub has a tiny buffer that will fill up immediately.
-	slowKey := subKey{Exchange: ExchangePolymarket, MarketID: "mkt-slow"}
-	slowCh := make(chan BookUpdate, 1)
-	bc.mu.Lock()
-	bc.subs[slowKey] = append(bc.subs[slowKey], slowCh)
-	bc.mu.Unlock()
+	slow := bc.SubscribeWith(context.Background(), ExchangePolymarket, "mkt-slow", SubscribeOptions{Buffer: 1})
 
 	// fastSub has a normal buffer.
-	fastSub := bc.Subscribe(ExchangePolymarket, "mkt-fast")
+	fastSub := bc.Subscribe(context.Background(), ExchangePolymarket, "mkt-fast")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -143,4 +148,390 @@ func TestBroadcaster_SlowSubscriber(t *testing.T) {
 	case <-time.After(time.Second):
 		t.Fatal("fast subscriber was blocked by slow subscriber")
 	}
+
+	if st := slow.Stats(); st.Delivered != 1 || st.Dropped != 1 {
+		t.Fatalf("slow subscriber stats: want 1 delivered / 1 dropped, got %+v", st)
+	}
+}
+
+func TestBroadcaster_DisconnectSlowSubscriber(t *testing.T) {
+	poly := newMockProvider()
+
+	bc := NewBroadcaster()
+	bc.Register(poly)
+
+	slow := bc.SubscribeAllWith(context.Background(), SubscribeOptions{Buffer: 1, Policy: DisconnectSlow})
+	healthy := bc.SubscribeAll(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	go bc.Run(ctx)
+
+	poly.send(BookUpdate{Exchange: ExchangePolymarket, MarketID: "mkt-1", AssetID: "a1"})
+	poly.send(BookUpdate{Exchange: ExchangePolymarket, MarketID: "mkt-1", AssetID: "a2"})
+
+	for _, want := range []string{"a1", "a2"} {
+		select {
+		case u := <-healthy:
+			if u.AssetID != want {
+				t.Fatalf("healthy subscriber: want %s, got %s", want, u.AssetID)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("healthy subscriber: timed out waiting for %s", want)
+		}
+	}
+
+	// The buffered update is still readable, then the channel is closed.
+	if u := <-slow.C(); u.AssetID != "a1" {
+		t.Fatalf("slow subscriber: want buffered a1, got %s", u.AssetID)
+	}
+	if _, ok := <-slow.C(); ok {
+		t.Fatal("slow subscriber channel should be closed after eviction")
+	}
+	if !slow.Stats().Evicted {
+		t.Fatal("expected Evicted=true")
+	}
+
+	bc.allMu.RLock()
+	n := len(bc.allSub)
+	bc.allMu.RUnlock()
+	if n != 1 {
+		t.Fatalf("evicted subscriber not removed: %d unified subscribers", n)
+	}
+}
+
+func TestBroadcaster_Trades(t *testing.T) {
+	books := newMockProvider()
+	withTrades := &mockTradeProvider{mockProvider: newMockProvider(), trades: make(chan Trade, 64)}
+
+	bc := NewBroadcaster()
+	bc.Register(books)
+	bc.Register(withTrades)
+
+	filtered := bc.SubscribeTrades(context.Background(), ExchangeKalshi, "mkt-1")
+	all := bc.SubscribeAllTrades(context.Background())
+	updates := bc.SubscribeAll(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go bc.Run(ctx)
+
+	withTrades.trades <- Trade{Exchange: ExchangeKalshi, MarketID: "mkt-2", Price: 0.4}
+	withTrades.trades <- Trade{Exchange: ExchangeKalshi, MarketID: "mkt-1", Price: 0.5, Side: SideBuy}
+
+	select {
+	case tr := <-filtered:
+		if tr.MarketID != "mkt-1" || tr.Price != 0.5 {
+			t.Fatalf("filtered subscriber got %+v", tr)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for filtered trade")
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-all:
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for trade %d on unified stream", i+1)
+		}
+	}
+	select {
+	case u := <-updates:
+		t.Fatalf("trades should not reach book subscribers: %+v", u)
+	default:
+	}
+}
+
+func TestBroadcaster_RemoveMarket(t *testing.T) {
+	bc := NewBroadcaster()
+	books := bc.Subscribe(context.Background(), ExchangeKalshi, "mkt-1")
+	trades := bc.SubscribeTrades(context.Background(), ExchangeKalshi, "mkt-1")
+	other := bc.Subscribe(context.Background(), ExchangeKalshi, "mkt-2")
+
+	bc.RemoveMarket(ExchangeKalshi, "mkt-1")
+
+	if _, ok := <-books; ok {
+		t.Fatal("expected book subscriber closed")
+	}
+	if _, ok := <-trades; ok {
+		t.Fatal("expected trade subscriber closed")
+	}
+
+	// Other markets still flow, and late updates for the removed market
+	// are harmless.
+	bc.distribute(BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-1"})
+	bc.distribute(BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-2"})
+	select {
+	case <-other:
+	default:
+		t.Fatal("expected mkt-2 update")
+	}
+}
+
+func TestBroadcaster_UnsubscribeAndContext(t *testing.T) {
+	bc := NewBroadcaster()
+
+	sub := bc.SubscribeWith(context.Background(), ExchangeKalshi, "mkt-1", SubscribeOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	all := bc.SubscribeAllWith(ctx, SubscribeOptions{})
+	trades := bc.SubscribeTradesWith(ctx, ExchangeKalshi, "mkt-1", SubscribeOptions{})
+
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	if _, ok := <-sub.C(); ok {
+		t.Fatal("expected unsubscribed channel closed")
+	}
+	if err := sub.Err(); !errors.Is(err, ErrUnsubscribed) {
+		t.Fatalf("expected ErrUnsubscribed, got %v", err)
+	}
+
+	cancel()
+	if _, ok := <-all.C(); ok {
+		t.Fatal("expected channel closed by context")
+	}
+	if _, ok := <-trades.C(); ok {
+		t.Fatal("expected trade channel closed by context")
+	}
+	if err := all.Err(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	bc.mu.RLock()
+	nSubs := len(bc.subs)
+	bc.mu.RUnlock()
+	bc.allMu.RLock()
+	nAll := len(bc.allSub)
+	bc.allMu.RUnlock()
+	bc.tradeMu.RLock()
+	nTrades := len(bc.tradeSubs)
+	bc.tradeMu.RUnlock()
+	if nSubs != 0 || nAll != 0 || nTrades != 0 {
+		t.Fatalf("expected every subscriber removed, got %d/%d/%d", nSubs, nAll, nTrades)
+	}
+}
+
+func TestBroadcaster_RuntimeRegistration(t *testing.T) {
+	bc := NewBroadcaster()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		bc.Run(ctx)
+		close(done)
+	}()
+
+	only := bc.SubscribeWith(ctx, ExchangeKalshi, "mkt-only", SubscribeOptions{})
+	shared := bc.SubscribeWith(ctx, ExchangeKalshi, "mkt-shared", SubscribeOptions{})
+	all := bc.SubscribeAll(ctx)
+
+	a := newMockProvider()
+	b := newMockProvider()
+	regA := bc.Register(a)
+	regB := bc.Register(b)
+
+	recv := func(ch <-chan BookUpdate) BookUpdate {
+		t.Helper()
+		select {
+		case u := <-ch:
+			return u
+		case <-ctx.Done():
+			t.Fatal("timed out")
+			return BookUpdate{}
+		}
+	}
+
+	a.send(BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-only"})
+	recv(only.C())
+	recv(all)
+	a.send(BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-shared"})
+	recv(shared.C())
+	recv(all)
+	b.send(BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-shared"})
+	recv(shared.C())
+	recv(all)
+
+	// Closing a drops the market only it carried.
+	close(a.ch)
+	if _, ok := <-only.C(); ok {
+		t.Fatal("expected mkt-only closed with its source")
+	}
+	if err := only.Err(); !errors.Is(err, ErrSourceClosed) {
+		t.Fatalf("expected ErrSourceClosed, got %v", err)
+	}
+	if shared.Err() != nil {
+		t.Fatal("shared market should survive while b carries it")
+	}
+	regA.Unregister() // already gone; no-op
+
+	regB.Unregister()
+	if _, ok := <-shared.C(); ok {
+		t.Fatal("expected mkt-shared closed once b is unregistered")
+	}
+	if err := shared.Err(); !errors.Is(err, ErrSourceClosed) {
+		t.Fatalf("expected ErrSourceClosed, got %v", err)
+	}
+
+	// Unified subscribers outlive their sources.
+	c := newMockProvider()
+	bc.Register(c)
+	c.send(BookUpdate{Exchange: ExchangePolymarket, MarketID: "mkt-new"})
+	if u := recv(all); u.MarketID != "mkt-new" {
+		t.Fatalf("unexpected update %+v", u)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
+
+func TestBroadcaster_LastValueCache(t *testing.T) {
+	src := newMockProvider()
+	bc := NewBroadcaster()
+	bc.Register(src)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go bc.Run(ctx)
+
+	if got := bc.Latest(ExchangeKalshi, "mkt-1"); got != nil {
+		t.Fatalf("expected no cached update before any data, got %+v", got)
+	}
+
+	// Sync on an existing subscriber so both updates have been distributed.
+	seen := bc.Subscribe(ctx, ExchangeKalshi, "mkt-2")
+	src.send(BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-1", Hash: "old"})
+	src.send(BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-1", Hash: "new"})
+	src.send(BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-2", Hash: "b"})
+	select {
+	case <-seen:
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+
+	if got := bc.Latest(ExchangeKalshi, "mkt-1"); len(got) != 1 || got[0].Hash != "new" {
+		t.Fatalf("expected latest mkt-1 update, got %+v", got)
+	}
+
+	// New subscribers are primed without waiting for the next tick.
+	one := bc.Subscribe(ctx, ExchangeKalshi, "mkt-1")
+	select {
+	case u := <-one:
+		if u.Hash != "new" {
+			t.Fatalf("expected snapshot of mkt-1, got %+v", u)
+		}
+	default:
+		t.Fatal("expected filtered subscriber primed immediately")
+	}
+
+	all := bc.SubscribeAll(ctx)
+	for _, want := range []string{"mkt-1", "mkt-2"} {
+		select {
+		case u := <-all:
+			if u.MarketID != want {
+				t.Fatalf("expected snapshot of %s, got %s", want, u.MarketID)
+			}
+		default:
+			t.Fatalf("expected unified subscriber primed with %s", want)
+		}
+	}
+
+	conflated := bc.SubscribeConflated(ctx, ExchangeKalshi, "mkt-2")
+	if got := conflated.Read(); len(got) != 1 || got[0].Hash != "b" {
+		t.Fatalf("expected conflated subscriber primed, got %+v", got)
+	}
+
+	bc.RemoveMarket(ExchangeKalshi, "mkt-1")
+	if got := bc.Latest(ExchangeKalshi, "mkt-1"); got != nil {
+		t.Fatalf("expected removed market forgotten, got %+v", got)
+	}
+}
+
+func TestBroadcaster_CachesEveryOutcomeBook(t *testing.T) {
+	src := newMockProvider()
+	bc := NewBroadcaster()
+	bc.Register(src)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conflated := bc.SubscribeConflated(ctx, ExchangePolymarket, "0xm")
+	go bc.Run(ctx)
+
+	// A Polymarket market's YES and NO books share its market ID.
+	book := func(asset, hash string) BookUpdate {
+		return BookUpdate{Exchange: ExchangePolymarket, MarketID: "0xm", AssetID: asset, Hash: hash}
+	}
+	src.send(book("yes", "y1"))
+	src.send(book("no", "n1"))
+	src.send(book("yes", "y2"))
+
+	got := map[string]string{}
+	for len(got) < 2 || got["yes"] != "y2" {
+		updates, err := conflated.Next(ctx)
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		for _, u := range updates {
+			got[u.AssetID] = u.Hash
+		}
+	}
+	if got["no"] != "n1" {
+		t.Fatalf("NO book lost to conflation: %v", got)
+	}
+
+	latest := bc.Latest(ExchangePolymarket, "0xm")
+	if len(latest) != 2 || latest[0].Hash != "n1" || latest[1].Hash != "y2" {
+		t.Fatalf("expected both outcome books cached, got %+v", latest)
+	}
+
+	// Late subscribers are primed with both books.
+	one := bc.Subscribe(ctx, ExchangePolymarket, "0xm")
+	for _, want := range []string{"n1", "y2"} {
+		select {
+		case u := <-one:
+			if u.Hash != want {
+				t.Fatalf("expected snapshot %s, got %s", want, u.Hash)
+			}
+		default:
+			t.Fatalf("expected subscriber primed with %s", want)
+		}
+	}
+	if primed := bc.SubscribeConflated(ctx, ExchangePolymarket, "0xm").Read(); len(primed) != 2 {
+		t.Fatalf("expected conflated subscriber primed with both books, got %+v", primed)
+	}
+}
+
+func TestBroadcaster_PrimingNeverBlocks(t *testing.T) {
+	src := newMockProvider()
+	bc := NewBroadcaster()
+	bc.Register(src)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	all := bc.SubscribeAll(ctx)
+	go bc.Run(ctx)
+
+	for _, asset := range []string{"a", "b", "c"} {
+		src.send(BookUpdate{Exchange: ExchangePolymarket, MarketID: "0xm", AssetID: asset})
+		<-all
+	}
+
+	// A blocking subscriber with room for one book is primed without
+	// waiting out its timeout for the other two.
+	opts := SubscribeOptions{Buffer: 1, Policy: BlockWithTimeout, Timeout: time.Second}
+	start := time.Now()
+	market := bc.SubscribeWith(ctx, ExchangePolymarket, "0xm", opts)
+	filtered := bc.SubscribeFilterWith(ctx, Filter{Exchange: ExchangePolymarket}, opts)
+	unified := bc.SubscribeAllWith(ctx, opts)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("priming blocked for %v", elapsed)
+	}
+	for name, sub := range map[string]*Subscription[BookUpdate]{"market": market, "filter": filtered, "all": unified} {
+		if st := sub.Stats(); st.Delivered != 1 || st.Dropped != 2 {
+			t.Fatalf("%s: want 1 delivered / 2 dropped, got %+v", name, st)
+		}
+	}
 }
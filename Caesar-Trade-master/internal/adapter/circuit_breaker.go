@@ -30,8 +30,22 @@ func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
 	}
 }
 
+// ConnectionHealth is a market-data connection the CircuitBreaker can
+// watch. WSClient and ShardedFeed implement it.
+type ConnectionHealth interface {
+	// CircuitFor returns the circuit state of the connection carrying the
+	// subscription key (the BookUpdate AssetID).
+	CircuitFor(key string) CircuitState
+
+	// Events returns the connection's lifecycle events.
+	Events() <-chan ConnEvent
+}
+
 // marketState tracks health for a single (exchange, market) pair.
 type marketState struct {
+	// AssetID is the subscription key of the market's most recent update,
+	// used to find the connection carrying it.
+	AssetID    string
 	LastUpdate time.Time
 	// recoveredAt is set when a market transitions from unhealthy→healthy.
 	// Trading is blocked until time.Since(recoveredAt) >= CoolOff.
@@ -41,47 +55,99 @@ type marketState struct {
 
 // CircuitBreaker monitors WebSocket connections and data freshness, gating
 // all trade execution behind CanTrade(). It enforces:
-//   - Connection health via WSClient.Circuit()
+//   - Connection health via ConnectionHealth.CircuitFor()
 //   - Data staleness via BookUpdate timestamps
 //   - Cool-off period after recovery
 //   - Manual emergency halt
+//   - Venue halts of a market or a whole exchange (HaltMarket, HaltExchange)
 type CircuitBreaker struct {
 	cfg  CircuitBreakerConfig
 	feed <-chan BookUpdate
 
-	// connections tracked for heartbeat monitoring.
-	connMu sync.RWMutex
-	conns  map[Exchange]*WSClient
+	// connections tracked for heartbeat monitoring, and the most recent
+	// lifecycle event seen on each.
+	connMu     sync.RWMutex
+	conns      map[Exchange]ConnectionHealth
+	connStatus map[Exchange]ConnEvent
 
 	// Per-market health state.
 	mu      sync.RWMutex
 	markets map[subKey]*marketState
 
-	// Global manual halt.
-	haltMu sync.RWMutex
-	halted bool
+	// Global manual halt, and venue halts by market and by exchange with
+	// their reasons.
+	haltMu        sync.RWMutex
+	halted        bool
+	marketHalts   map[subKey]string
+	exchangeHalts map[Exchange]string
 
 	nowFunc func() time.Time // injectable clock for testing
 }
 
 // NewCircuitBreaker creates a CircuitBreaker that monitors the given
-// Broadcaster feed for staleness. WSClients are registered separately
+// Broadcaster feed for staleness. Connections are registered separately
 // via WatchConnection.
 func NewCircuitBreaker(cfg CircuitBreakerConfig, feed <-chan BookUpdate) *CircuitBreaker {
 	return &CircuitBreaker{
-		cfg:     cfg,
-		feed:    feed,
-		conns:   make(map[Exchange]*WSClient),
-		markets: make(map[subKey]*marketState),
-		nowFunc: time.Now,
+		cfg:        cfg,
+		feed:       feed,
+		conns:      make(map[Exchange]ConnectionHealth),
+		connStatus: make(map[Exchange]ConnEvent),
+		markets:    make(map[subKey]*marketState),
+		nowFunc:    time.Now,
+
+		marketHalts:   make(map[subKey]string),
+		exchangeHalts: make(map[Exchange]string),
 	}
 }
 
-// WatchConnection registers a WSClient so its Circuit() state is monitored.
-func (cb *CircuitBreaker) WatchConnection(exchange Exchange, ws *WSClient) {
+// WatchConnection registers a connection so its circuit state is monitored.
+// The breaker also consumes the connection's lifecycle events: a disconnect
+// marks the markets it carried unhealthy (every market on the exchange if
+// the event names none), so trading resumes only after fresh data and a
+// full cool-off. With a ShardedFeed, a dead shard halts only its markets.
+func (cb *CircuitBreaker) WatchConnection(exchange Exchange, conn ConnectionHealth) {
 	cb.connMu.Lock()
-	cb.conns[exchange] = ws
+	cb.conns[exchange] = conn
 	cb.connMu.Unlock()
+
+	events := conn.Events()
+	go func() {
+		for ev := range events {
+			cb.recordConnEvent(exchange, ev)
+		}
+	}()
+}
+
+// ConnectionStatus returns the most recent lifecycle event observed for the
+// exchange's connection, so operators can see why a feed is flapping.
+func (cb *CircuitBreaker) ConnectionStatus(exchange Exchange) (ConnEvent, bool) {
+	cb.connMu.RLock()
+	defer cb.connMu.RUnlock()
+	ev, ok := cb.connStatus[exchange]
+	return ev, ok
+}
+
+func (cb *CircuitBreaker) recordConnEvent(exchange Exchange, ev ConnEvent) {
+	cb.connMu.Lock()
+	cb.connStatus[exchange] = ev
+	cb.connMu.Unlock()
+
+	if ev.Type != ConnDisconnected {
+		return
+	}
+	affected := make(map[string]bool, len(ev.Keys))
+	for _, k := range ev.Keys {
+		affected[k] = true
+	}
+
+	cb.mu.Lock()
+	for key, ms := range cb.markets {
+		if key.Exchange == exchange && (len(affected) == 0 || affected[ms.AssetID]) {
+			ms.Healthy = false
+		}
+	}
+	cb.mu.Unlock()
 }
 
 // ManualHalt forces all markets into a halted state. Trading is blocked
@@ -100,39 +166,115 @@ func (cb *CircuitBreaker) Resume() {
 	cb.haltMu.Unlock()
 }
 
-// CanTrade returns true only if ALL of the following hold:
-//  1. No manual halt is active.
-//  2. The exchange's WSClient circuit is Closed (healthy).
-//  3. The last BookUpdate for this market is within StaleThreshold.
-//  4. The cool-off period has elapsed since recovery.
-func (cb *CircuitBreaker) CanTrade(exchange Exchange, marketID string) bool {
-	// Check manual halt.
+// HaltMarket blocks trading in one market because the venue stopped it,
+// e.g. the market closed for settlement or was paused. Unlike MarkStale,
+// fresh book updates do not clear it; only ResumeMarket does. The market
+// need not have received any data yet.
+func (cb *CircuitBreaker) HaltMarket(exchange Exchange, marketID, reason string) {
+	cb.haltMu.Lock()
+	cb.marketHalts[subKey{Exchange: exchange, MarketID: marketID}] = reason
+	cb.haltMu.Unlock()
+}
+
+// ResumeMarket clears a venue halt of the market. Trading resumes only
+// after fresh data and a full cool-off.
+func (cb *CircuitBreaker) ResumeMarket(exchange Exchange, marketID string) {
+	key := subKey{Exchange: exchange, MarketID: marketID}
+
+	cb.haltMu.Lock()
+	_, was := cb.marketHalts[key]
+	delete(cb.marketHalts, key)
+	cb.haltMu.Unlock()
+
+	if was {
+		cb.MarkStale(exchange, marketID)
+	}
+}
+
+// HaltExchange blocks trading in every market of the exchange, e.g. during
+// venue maintenance, until ResumeExchange.
+func (cb *CircuitBreaker) HaltExchange(exchange Exchange, reason string) {
+	cb.haltMu.Lock()
+	cb.exchangeHalts[exchange] = reason
+	cb.haltMu.Unlock()
+}
+
+// ResumeExchange clears an exchange-wide halt. Its markets resume only
+// after fresh data and a full cool-off; market halts stay in force.
+func (cb *CircuitBreaker) ResumeExchange(exchange Exchange) {
+	cb.haltMu.Lock()
+	_, was := cb.exchangeHalts[exchange]
+	delete(cb.exchangeHalts, exchange)
+	cb.haltMu.Unlock()
+	if !was {
+		return
+	}
+
+	cb.mu.Lock()
+	for key, ms := range cb.markets {
+		if key.Exchange == exchange {
+			ms.Healthy = false
+		}
+	}
+	cb.mu.Unlock()
+}
+
+// HaltReason reports why trading in the market is halted: a manual halt,
+// an exchange-wide halt or a halt of the market itself, in that order.
+func (cb *CircuitBreaker) HaltReason(exchange Exchange, marketID string) (string, bool) {
 	cb.haltMu.RLock()
+	defer cb.haltMu.RUnlock()
+
 	if cb.halted {
-		cb.haltMu.RUnlock()
-		return false
+		return "manual halt", true
 	}
-	cb.haltMu.RUnlock()
-
-	// Check connection health.
-	cb.connMu.RLock()
-	ws, ok := cb.conns[exchange]
-	cb.connMu.RUnlock()
-	if ok && ws.Circuit() == CircuitOpen {
-		return false
+	if reason, ok := cb.exchangeHalts[exchange]; ok {
+		return reason, true
 	}
+	reason, ok := cb.marketHalts[subKey{Exchange: exchange, MarketID: marketID}]
+	return reason, ok
+}
 
-	// Check market staleness and cool-off.
+// CanTrade returns true only if ALL of the following hold:
+//  1. No manual, exchange-wide or market halt is active.
+//  2. The connection carrying the market has a Closed (healthy) circuit.
+//  3. The market has not been marked unhealthy since its last BookUpdate.
+//  4. The last BookUpdate for this market is within StaleThreshold.
+//  5. The cool-off period has elapsed since recovery.
+func (cb *CircuitBreaker) CanTrade(exchange Exchange, marketID string) bool {
 	key := subKey{Exchange: exchange, MarketID: marketID}
+
+	// Check manual and venue halts.
+	if _, halted := cb.HaltReason(exchange, marketID); halted {
+		return false
+	}
 	now := cb.nowFunc()
 
+	// Copy the state under the lock; recordUpdate mutates it in place.
 	cb.mu.RLock()
-	ms, exists := cb.markets[key]
+	var ms marketState
+	p, exists := cb.markets[key]
+	if exists {
+		ms = *p
+	}
 	cb.mu.RUnlock()
 
 	if !exists {
 		return false // no data received yet
 	}
+	if !ms.Healthy {
+		return false // disconnected or marked stale; awaiting fresh data
+	}
+
+	// Check connection health.
+	cb.connMu.RLock()
+	conn, ok := cb.conns[exchange]
+	cb.connMu.RUnlock()
+	if ok && conn.CircuitFor(ms.AssetID) == CircuitOpen {
+		return false
+	}
+
+	// Check market staleness and cool-off.
 
 	if now.Sub(ms.LastUpdate) > cb.cfg.StaleThreshold {
 		return false
@@ -173,6 +315,7 @@ func (cb *CircuitBreaker) recordUpdate(update BookUpdate) {
 	}
 
 	wasHealthy := ms.Healthy
+	ms.AssetID = update.AssetID
 	ms.LastUpdate = now
 
 	// Determine current health: data is fresh.
@@ -186,8 +329,25 @@ func (cb *CircuitBreaker) recordUpdate(update BookUpdate) {
 	cb.mu.Unlock()
 }
 
-// MarkStale can be called externally (e.g. by the heartbeat monitor) to
-// force a market into an unhealthy state.
+// RemoveMarket forgets the market's health state and any halt of it, once
+// no adapter carries it any more. CanTrade returns false until data for the
+// market arrives again.
+func (cb *CircuitBreaker) RemoveMarket(exchange Exchange, marketID string) {
+	key := subKey{Exchange: exchange, MarketID: marketID}
+
+	cb.mu.Lock()
+	delete(cb.markets, key)
+	cb.mu.Unlock()
+
+	cb.haltMu.Lock()
+	delete(cb.marketHalts, key)
+	cb.haltMu.Unlock()
+}
+
+// MarkStale can be called externally (e.g. by the heartbeat monitor or an
+// adapter that lost book consistency) to force a market into an unhealthy
+// state. Trading stays blocked until a fresh update arrives and the
+// cool-off elapses.
 func (cb *CircuitBreaker) MarkStale(exchange Exchange, marketID string) {
 	key := subKey{Exchange: exchange, MarketID: marketID}
 
@@ -131,6 +131,32 @@ func TestCircuitBreaker_StaleData(t *testing.T) {
 	}
 }
 
+// TestCircuitBreaker_ConcurrentCanTrade is meant for -race: CanTrade must
+// not read market state the update path is writing.
+func TestCircuitBreaker_ConcurrentCanTrade(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	cb, feed := newTestBreaker(clock)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go cb.Run(ctx)
+
+	feed <- BookUpdate{Exchange: ExchangeKalshi, MarketID: "FED-DEC", Timestamp: clock.Now()}
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 500; i++ {
+			cb.CanTrade(ExchangeKalshi, "FED-DEC")
+		}
+	}()
+	for i := 0; i < 500; i++ {
+		feed <- BookUpdate{Exchange: ExchangeKalshi, MarketID: "FED-DEC", Timestamp: clock.Now()}
+	}
+	<-done
+}
+
 func TestCircuitBreaker_CoolOff(t *testing.T) {
 	clock := newFakeClock(time.Now())
 	cb, feed := newTestBreaker(clock)
@@ -219,3 +245,151 @@ func TestCircuitBreaker_ManualHalt(t *testing.T) {
 		t.Fatal("expected CanTrade=true after Resume")
 	}
 }
+
+func TestCircuitBreaker_DisconnectStartsCoolOff(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	cb, feed := newTestBreaker(clock)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go cb.Run(ctx)
+
+	ws := NewWSClient(DefaultWSConfig("ws://example.invalid"))
+	cb.WatchConnection(ExchangeKalshi, ws)
+
+	// Healthy market past its initial cool-off.
+	feed <- BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-1"}
+	time.Sleep(20 * time.Millisecond)
+	clock.Advance(3 * time.Second)
+	feed <- BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-1"}
+	time.Sleep(20 * time.Millisecond)
+	if !cb.CanTrade(ExchangeKalshi, "mkt-1") {
+		t.Fatal("expected CanTrade=true before disconnect")
+	}
+
+	ws.emit(ConnEvent{Type: ConnDisconnected, Reason: context.DeadlineExceeded})
+	time.Sleep(20 * time.Millisecond)
+
+	status, ok := cb.ConnectionStatus(ExchangeKalshi)
+	if !ok || status.Type != ConnDisconnected {
+		t.Fatalf("expected last status Disconnected, got %+v", status)
+	}
+
+	// The first update after the drop must not re-enable trading at once.
+	feed <- BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-1"}
+	time.Sleep(20 * time.Millisecond)
+	if cb.CanTrade(ExchangeKalshi, "mkt-1") {
+		t.Fatal("expected cool-off after disconnect")
+	}
+}
+
+func TestCircuitBreaker_MarkStaleBlocksImmediately(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	cb, feed := newTestBreaker(clock)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go cb.Run(ctx)
+
+	feed <- BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-seq"}
+	time.Sleep(20 * time.Millisecond)
+	clock.Advance(3 * time.Second)
+	feed <- BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-seq"}
+	time.Sleep(20 * time.Millisecond)
+	if !cb.CanTrade(ExchangeKalshi, "mkt-seq") {
+		t.Fatal("expected CanTrade=true before MarkStale")
+	}
+
+	// The last update is still fresh, but the book behind it is not.
+	cb.MarkStale(ExchangeKalshi, "mkt-seq")
+	if cb.CanTrade(ExchangeKalshi, "mkt-seq") {
+		t.Fatal("expected CanTrade=false right after MarkStale")
+	}
+}
+
+func TestCircuitBreaker_VenueHalts(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	cb, feed := newTestBreaker(clock)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go cb.Run(ctx)
+
+	// Healthy markets past their initial cool-off.
+	for _, id := range []string{"mkt-1", "mkt-2"} {
+		feed <- BookUpdate{Exchange: ExchangeKalshi, MarketID: id}
+	}
+	time.Sleep(20 * time.Millisecond)
+	clock.Advance(3 * time.Second)
+	for _, id := range []string{"mkt-1", "mkt-2"} {
+		feed <- BookUpdate{Exchange: ExchangeKalshi, MarketID: id}
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	cb.HaltMarket(ExchangeKalshi, "mkt-1", "closed for settlement")
+	if cb.CanTrade(ExchangeKalshi, "mkt-1") {
+		t.Fatal("expected CanTrade=false immediately after HaltMarket")
+	}
+	if !cb.CanTrade(ExchangeKalshi, "mkt-2") {
+		t.Fatal("market halt must not affect other markets")
+	}
+	if reason, ok := cb.HaltReason(ExchangeKalshi, "mkt-1"); !ok || reason != "closed for settlement" {
+		t.Fatalf("HaltReason = %q, %v", reason, ok)
+	}
+
+	// Late book deltas do not lift a venue halt.
+	feed <- BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-1"}
+	time.Sleep(20 * time.Millisecond)
+	if cb.CanTrade(ExchangeKalshi, "mkt-1") {
+		t.Fatal("book update must not clear a market halt")
+	}
+
+	cb.HaltExchange(ExchangeKalshi, "maintenance")
+	if cb.CanTrade(ExchangeKalshi, "mkt-2") {
+		t.Fatal("expected CanTrade=false during exchange halt")
+	}
+	if reason, _ := cb.HaltReason(ExchangeKalshi, "mkt-1"); reason != "maintenance" {
+		t.Fatalf("exchange halt should take precedence, got %q", reason)
+	}
+
+	// Resuming requires fresh data and a full cool-off.
+	cb.ResumeExchange(ExchangeKalshi)
+	cb.ResumeMarket(ExchangeKalshi, "mkt-1")
+	if cb.CanTrade(ExchangeKalshi, "mkt-2") {
+		t.Fatal("expected cool-off after ResumeExchange")
+	}
+	feed <- BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-1"}
+	feed <- BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-2"}
+	time.Sleep(20 * time.Millisecond)
+	clock.Advance(3 * time.Second)
+	feed <- BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-1"}
+	feed <- BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-2"}
+	time.Sleep(20 * time.Millisecond)
+	if !cb.CanTrade(ExchangeKalshi, "mkt-1") || !cb.CanTrade(ExchangeKalshi, "mkt-2") {
+		t.Fatal("expected CanTrade=true after resume and cool-off")
+	}
+}
+
+func TestCircuitBreaker_RemoveMarket(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	cb, _ := newTestBreaker(clock)
+
+	cb.recordUpdate(BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-1"})
+	clock.Advance(3 * time.Second)
+	cb.recordUpdate(BookUpdate{Exchange: ExchangeKalshi, MarketID: "mkt-1"})
+	cb.HaltMarket(ExchangeKalshi, "mkt-1", "settled")
+
+	cb.RemoveMarket(ExchangeKalshi, "mkt-1")
+	if cb.CanTrade(ExchangeKalshi, "mkt-1") {
+		t.Fatal("expected CanTrade=false for a removed market")
+	}
+	if _, halted := cb.HaltReason(ExchangeKalshi, "mkt-1"); halted {
+		t.Fatal("expected the market's halt to be forgotten")
+	}
+	cb.mu.RLock()
+	n := len(cb.markets)
+	cb.mu.RUnlock()
+	if n != 0 {
+		t.Fatalf("expected no tracked markets, got %d", n)
+	}
+}
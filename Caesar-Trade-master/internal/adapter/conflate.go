@@ -0,0 +1,164 @@
+package adapter
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// ConflatedSubscription is a subscriber that never falls behind: it keeps
+// only the most recent BookUpdate per book (exchange, market, asset) and
+// replaces older unread ones instead of queueing or dropping the newest. A
+// reader waking up after any delay gets the latest state of every book
+// that changed since its previous read.
+//
+// Ready signals that updates are pending; Read takes them. Next combines
+// the two.
+type ConflatedSubscription struct {
+	// mu guards the pending updates and the close state.
+	mu      sync.Mutex
+	latest  map[bookKey]BookUpdate
+	order   []bookKey // books in order of their first unread change
+	ready   chan struct{}
+	closed  bool
+	err     error
+	detach  func()
+	stop    func() bool
+	removed sync.Once
+
+	delivered atomic.Uint64
+	conflated atomic.Uint64
+}
+
+func newConflatedSubscription() *ConflatedSubscription {
+	return &ConflatedSubscription{
+		latest: make(map[bookKey]BookUpdate),
+		ready:  make(chan struct{}, 1),
+	}
+}
+
+// Ready returns a channel that receives a value whenever updates are
+// pending, and is closed once the subscription ends. Updates pending at
+// that point can still be read.
+func (c *ConflatedSubscription) Ready() <-chan struct{} { return c.ready }
+
+// Read returns the latest unread update of every book that changed since
+// the previous Read, in the order the books first changed. It returns nil
+// if nothing is pending.
+func (c *ConflatedSubscription) Read() []BookUpdate {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.order) == 0 {
+		return nil
+	}
+	out := make([]BookUpdate, len(c.order))
+	for i, key := range c.order {
+		out[i] = c.latest[key]
+		delete(c.latest, key)
+	}
+	c.order = c.order[:0]
+	c.delivered.Add(uint64(len(out)))
+	return out
+}
+
+// Next blocks until updates are pending and reads them. Once the
+// subscription has ended and nothing is left, it returns Err. It also
+// returns if ctx is done.
+func (c *ConflatedSubscription) Next(ctx context.Context) ([]BookUpdate, error) {
+	for {
+		if updates := c.Read(); updates != nil {
+			return updates, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case _, ok := <-c.ready:
+			if !ok {
+				if updates := c.Read(); updates != nil {
+					return updates, nil
+				}
+				return nil, c.Err()
+			}
+		}
+	}
+}
+
+// Stats returns the subscriber's counters: Delivered counts updates
+// returned by Read, Conflated those replaced by a newer update for the same
+// book before being read.
+func (c *ConflatedSubscription) Stats() SubscriberStats {
+	return SubscriberStats{
+		Delivered: c.delivered.Load(),
+		Conflated: c.conflated.Load(),
+	}
+}
+
+// Err returns nil while the subscription is open, and the reason it ended
+// otherwise; see Subscription.Err.
+func (c *ConflatedSubscription) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Unsubscribe removes the subscriber from its fan-out and closes Ready. It
+// is safe to call more than once.
+func (c *ConflatedSubscription) Unsubscribe() {
+	c.end(ErrUnsubscribed)
+}
+
+// deliver stores update as its book's latest, replacing any unread one.
+// It returns false once the subscription has ended.
+func (c *ConflatedSubscription) deliver(update BookUpdate) bool {
+	key := bookKeyOf(update)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+	if _, pending := c.latest[key]; pending {
+		c.conflated.Add(1)
+	} else {
+		c.order = append(c.order, key)
+	}
+	c.latest[key] = update
+
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// watch ends the subscription with ctx's error once ctx is done.
+func (c *ConflatedSubscription) watch(ctx context.Context) {
+	if ctx.Done() == nil {
+		return
+	}
+	stop := context.AfterFunc(ctx, func() { c.end(ctx.Err()) })
+	c.mu.Lock()
+	c.stop = stop
+	c.mu.Unlock()
+}
+
+// end closes the subscription with err and detaches it once.
+func (c *ConflatedSubscription) end(err error) {
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		c.err = err
+		close(c.ready)
+	}
+	stop := c.stop
+	c.mu.Unlock()
+
+	c.removed.Do(func() {
+		if stop != nil {
+			stop()
+		}
+		if c.detach != nil {
+			c.detach()
+		}
+	})
+}
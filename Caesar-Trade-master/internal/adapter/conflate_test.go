@@ -0,0 +1,133 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConflatedSubscription_KeepsLatestPerMarket(t *testing.T) {
+	sub := newConflatedSubscription()
+	bid := func(market string, p float64) BookUpdate {
+		return BookUpdate{
+			Exchange: ExchangeKalshi,
+			MarketID: market,
+			Bids:     []PriceLevel{{Price: p, Size: 1}},
+		}
+	}
+
+	sub.deliver(bid("a", 0.40))
+	sub.deliver(bid("b", 0.10))
+	sub.deliver(bid("a", 0.41))
+	sub.deliver(bid("a", 0.42))
+
+	select {
+	case <-sub.Ready():
+	default:
+		t.Fatal("expected Ready to fire")
+	}
+	got := sub.Read()
+	if len(got) != 2 {
+		t.Fatalf("expected one update per market, got %d", len(got))
+	}
+	if got[0].MarketID != "a" || got[0].Bids[0].Price != 0.42 {
+		t.Fatalf("expected latest a first, got %+v", got[0])
+	}
+	if got[1].MarketID != "b" {
+		t.Fatalf("expected b second, got %+v", got[1])
+	}
+	if sub.Read() != nil {
+		t.Fatal("expected nothing pending after Read")
+	}
+	if st := sub.Stats(); st.Delivered != 2 || st.Conflated != 2 {
+		t.Fatalf("stats: want 2 delivered / 2 conflated, got %+v", st)
+	}
+
+	// Pending updates survive the close and are returned before Err.
+	sub.deliver(bid("b", 0.11))
+	sub.Unsubscribe()
+	if sub.deliver(bid("b", 0.12)) {
+		t.Fatal("expected delivery to fail after Unsubscribe")
+	}
+	ctx := context.Background()
+	if got, err := sub.Next(ctx); err != nil || len(got) != 1 || got[0].Bids[0].Price != 0.11 {
+		t.Fatalf("expected pending b, got %+v, %v", got, err)
+	}
+	if _, err := sub.Next(ctx); !errors.Is(err, ErrUnsubscribed) {
+		t.Fatalf("expected ErrUnsubscribed, got %v", err)
+	}
+}
+
+func TestConflatedSubscription_KeepsEveryOutcomeBook(t *testing.T) {
+	sub := newConflatedSubscription()
+	book := func(asset string, p float64) BookUpdate {
+		return BookUpdate{
+			Exchange: ExchangePolymarket,
+			MarketID: "0xm",
+			AssetID:  asset,
+			Bids:     []PriceLevel{{Price: p, Size: 1}},
+		}
+	}
+
+	sub.deliver(book("yes", 0.60))
+	sub.deliver(book("no", 0.38))
+	sub.deliver(book("yes", 0.61))
+
+	got := sub.Read()
+	if len(got) != 2 || got[0].AssetID != "yes" || got[0].Bids[0].Price != 0.61 || got[1].AssetID != "no" {
+		t.Fatalf("expected the latest YES and NO books, got %+v", got)
+	}
+	if st := sub.Stats(); st.Delivered != 2 || st.Conflated != 1 {
+		t.Fatalf("stats: want 2 delivered / 1 conflated, got %+v", st)
+	}
+}
+
+func TestBroadcaster_Conflated(t *testing.T) {
+	src := newMockProvider()
+	bc := NewBroadcaster()
+	bc.Register(src)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	one := bc.SubscribeConflated(ctx, ExchangePolymarket, "mkt-1")
+	all := bc.SubscribeAllConflated(ctx)
+	go bc.Run(ctx)
+
+	for i := 1; i <= 100; i++ {
+		src.send(BookUpdate{Exchange: ExchangePolymarket, MarketID: "mkt-1", Hash: string(rune('0' + i%10))})
+	}
+	src.send(BookUpdate{Exchange: ExchangePolymarket, MarketID: "mkt-2", Hash: "last"})
+
+	// Wait until the final update has been distributed, then read once.
+	var latest map[string]string
+	for latest["mkt-2"] != "last" {
+		updates, err := all.Next(ctx)
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if latest == nil {
+			latest = make(map[string]string)
+		}
+		for _, u := range updates {
+			latest[u.MarketID] = u.Hash
+		}
+	}
+	if latest["mkt-1"] != "0" {
+		t.Fatalf("expected the last mkt-1 update, got %q", latest["mkt-1"])
+	}
+
+	got := one.Read()
+	if len(got) != 1 || got[0].Hash != "0" {
+		t.Fatalf("expected only the latest mkt-1 update, got %+v", got)
+	}
+	if st := one.Stats(); st.Delivered+st.Conflated != 100 {
+		t.Fatalf("expected every update delivered or conflated, got %+v", st)
+	}
+
+	bc.RemoveMarket(ExchangePolymarket, "mkt-1")
+	if _, err := one.Next(ctx); !errors.Is(err, ErrMarketRemoved) {
+		t.Fatalf("expected ErrMarketRemoved, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package adapter
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConnEventType enumerates WSClient connection lifecycle transitions.
+type ConnEventType int
+
+const (
+	ConnConnected        ConnEventType = iota // initial dial succeeded
+	ConnDisconnected                          // read failed or liveness timed out
+	ConnReconnectAttempt                      // about to wait Delay, then redial
+	ConnReconnected                           // redial and resubscribe succeeded
+	ConnClosed                                // Close was called; no further events
+)
+
+func (t ConnEventType) String() string {
+	switch t {
+	case ConnConnected:
+		return "connected"
+	case ConnDisconnected:
+		return "disconnected"
+	case ConnReconnectAttempt:
+		return "reconnect_attempt"
+	case ConnReconnected:
+		return "reconnected"
+	case ConnClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// ConnEvent describes a single connection lifecycle transition.
+type ConnEvent struct {
+	Type ConnEventType
+	URL  string
+	Time time.Time
+
+	// Reason is the error that caused a Disconnected event, or the failure
+	// of the previous attempt for a ReconnectAttempt event (nil on the
+	// first attempt).
+	Reason error
+
+	// Attempt is the 1-based reconnect attempt number for ReconnectAttempt
+	// and Reconnected events.
+	Attempt int
+
+	// Delay is the backoff waited before a ReconnectAttempt.
+	Delay time.Duration
+
+	// Keys lists the subscription keys registered on the connection when
+	// the event was emitted, so consumers can tell which markets a
+	// transition affects. Empty if nothing was registered via
+	// SendSubscription.
+	Keys []string
+}
+
+func (e ConnEvent) String() string {
+	switch e.Type {
+	case ConnDisconnected:
+		return fmt.Sprintf("%s %s: %v", e.URL, e.Type, e.Reason)
+	case ConnReconnectAttempt:
+		return fmt.Sprintf("%s %s #%d in %v", e.URL, e.Type, e.Attempt, e.Delay)
+	case ConnReconnected:
+		return fmt.Sprintf("%s %s after %d attempt(s)", e.URL, e.Type, e.Attempt)
+	default:
+		return fmt.Sprintf("%s %s", e.URL, e.Type)
+	}
+}
+
+// Events returns a channel of connection lifecycle events. Events are
+// delivered without blocking the connection; a consumer that falls more
+// than 64 events behind misses events. The channel is closed after the
+// Closed event.
+func (ws *WSClient) Events() <-chan ConnEvent {
+	ch := make(chan ConnEvent, 64)
+	ws.evMu.Lock()
+	ws.evSubs = append(ws.evSubs, ch)
+	ws.evMu.Unlock()
+	return ch
+}
+
+// emit stamps ev and delivers it to every Events subscriber.
+func (ws *WSClient) emit(ev ConnEvent) {
+	ws.mu.RLock()
+	ev.URL = ws.cfg.URL
+	ws.mu.RUnlock()
+	ev.Time = time.Now()
+	ev.Keys = ws.subscriptionKeys()
+
+	ws.evMu.RLock()
+	defer ws.evMu.RUnlock()
+	for _, ch := range ws.evSubs {
+		select {
+		case ch <- ev:
+		default:
+			// Lifecycle events are advisory; never stall the connection.
+		}
+	}
+}
+
+// closeEvents emits Closed and closes every Events subscriber channel.
+func (ws *WSClient) closeEvents() {
+	ws.emit(ConnEvent{Type: ConnClosed})
+
+	ws.evMu.Lock()
+	for _, ch := range ws.evSubs {
+		close(ch)
+	}
+	ws.evSubs = nil
+	ws.evMu.Unlock()
+}
@@ -0,0 +1,79 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// nextEvent waits for the next lifecycle event of the given type, skipping
+// any others.
+func nextEvent(t *testing.T, events <-chan ConnEvent, want ConnEventType) ConnEvent {
+	t.Helper()
+	deadline := time.After(3 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatalf("event channel closed while waiting for %s", want)
+			}
+			if ev.Type == want {
+				return ev
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for %s event", want)
+		}
+	}
+}
+
+func TestWSClient_LifecycleEvents(t *testing.T) {
+	srv, _, drop := recordingServer(t)
+	defer srv.Close()
+
+	cfg := DefaultWSConfig(wsURL(srv))
+	cfg.HeartbeatTimeout = 200 * time.Millisecond
+	cfg.PingInterval = 50 * time.Millisecond
+	cfg.BackoffInitial = 50 * time.Millisecond
+
+	client := NewWSClient(cfg)
+	events := client.Events()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	ev := nextEvent(t, events, ConnConnected)
+	if ev.URL != wsURL(srv) {
+		t.Fatalf("Connected URL: want %s, got %s", wsURL(srv), ev.URL)
+	}
+	if ev.Time.IsZero() {
+		t.Fatal("event missing timestamp")
+	}
+
+	drop()
+
+	ev = nextEvent(t, events, ConnDisconnected)
+	if ev.Reason == nil {
+		t.Fatal("Disconnected event missing reason")
+	}
+
+	ev = nextEvent(t, events, ConnReconnectAttempt)
+	if ev.Attempt != 1 || ev.Delay != cfg.BackoffInitial {
+		t.Fatalf("first attempt: want #1 after %v, got #%d after %v",
+			cfg.BackoffInitial, ev.Attempt, ev.Delay)
+	}
+
+	ev = nextEvent(t, events, ConnReconnected)
+	if ev.Attempt != 1 {
+		t.Fatalf("Reconnected: want attempt 1, got %d", ev.Attempt)
+	}
+
+	client.Close()
+	nextEvent(t, events, ConnClosed)
+	if _, ok := <-events; ok {
+		t.Fatal("event channel should be closed after Closed")
+	}
+}
@@ -0,0 +1,85 @@
+package adapter
+
+import (
+	"sync"
+	"time"
+)
+
+// MarketConstraints are the venue's current order limits for one market.
+// Zero fields are unknown; callers fall back to venue-wide defaults.
+type MarketConstraints struct {
+	// TickSize is the minimum price increment on the 0.0-1.0 scale.
+	TickSize float64
+
+	// MinOrderSize is the smallest accepted order quantity.
+	MinOrderSize float64
+
+	// UpdatedAt is when any field last changed.
+	UpdatedAt time.Time
+}
+
+// ConstraintsRegistry tracks MarketConstraints per exchange and market.
+// Adapters update it from venue events and metadata; the engine's Validator
+// reads it. All methods are safe for concurrent use and on a nil registry,
+// so adapters need no registry to run.
+type ConstraintsRegistry struct {
+	mu      sync.RWMutex
+	markets map[subKey]MarketConstraints
+}
+
+// NewConstraintsRegistry creates an empty registry.
+func NewConstraintsRegistry() *ConstraintsRegistry {
+	return &ConstraintsRegistry{markets: make(map[subKey]MarketConstraints)}
+}
+
+// SetTickSize records the current tick size of a market. Non-positive
+// values are ignored.
+func (r *ConstraintsRegistry) SetTickSize(exchange Exchange, marketID string, tick float64) {
+	r.update(exchange, marketID, func(c *MarketConstraints) bool {
+		if tick <= 0 || c.TickSize == tick {
+			return false
+		}
+		c.TickSize = tick
+		return true
+	})
+}
+
+// SetMinOrderSize records the minimum order size of a market. Non-positive
+// values are ignored.
+func (r *ConstraintsRegistry) SetMinOrderSize(exchange Exchange, marketID string, size float64) {
+	r.update(exchange, marketID, func(c *MarketConstraints) bool {
+		if size <= 0 || c.MinOrderSize == size {
+			return false
+		}
+		c.MinOrderSize = size
+		return true
+	})
+}
+
+// Lookup returns the known constraints for a market.
+func (r *ConstraintsRegistry) Lookup(exchange Exchange, marketID string) (MarketConstraints, bool) {
+	if r == nil {
+		return MarketConstraints{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.markets[subKey{Exchange: exchange, MarketID: marketID}]
+	return c, ok
+}
+
+// update applies fn to a market's constraints and stamps UpdatedAt if fn
+// reports a change.
+func (r *ConstraintsRegistry) update(exchange Exchange, marketID string, fn func(*MarketConstraints) bool) {
+	if r == nil || marketID == "" {
+		return
+	}
+	key := subKey{Exchange: exchange, MarketID: marketID}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.markets[key]
+	if fn(&c) {
+		c.UpdatedAt = time.Now()
+		r.markets[key] = c
+	}
+}
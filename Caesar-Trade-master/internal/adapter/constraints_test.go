@@ -0,0 +1,40 @@
+package adapter
+
+import "testing"
+
+func TestConstraintsRegistry(t *testing.T) {
+	r := NewConstraintsRegistry()
+	if _, ok := r.Lookup(ExchangePolymarket, "m1"); ok {
+		t.Fatal("expected no constraints for unknown market")
+	}
+
+	r.SetTickSize(ExchangePolymarket, "m1", 0.01)
+	r.SetMinOrderSize(ExchangePolymarket, "m1", 5)
+	c, ok := r.Lookup(ExchangePolymarket, "m1")
+	if !ok || c.TickSize != 0.01 || c.MinOrderSize != 5 {
+		t.Fatalf("unexpected constraints: %+v", c)
+	}
+	first := c.UpdatedAt
+
+	r.SetTickSize(ExchangePolymarket, "m1", 0.001)
+	r.SetTickSize(ExchangePolymarket, "m1", 0) // ignored
+	c, _ = r.Lookup(ExchangePolymarket, "m1")
+	if c.TickSize != 0.001 || c.MinOrderSize != 5 {
+		t.Fatalf("expected tick 0.001 with size kept, got %+v", c)
+	}
+	if c.UpdatedAt.Before(first) {
+		t.Fatal("UpdatedAt should advance on change")
+	}
+
+	if _, ok := r.Lookup(ExchangeKalshi, "m1"); ok {
+		t.Fatal("constraints must be keyed by exchange")
+	}
+}
+
+func TestConstraintsRegistry_Nil(t *testing.T) {
+	var r *ConstraintsRegistry
+	r.SetTickSize(ExchangeKalshi, "m1", 0.01)
+	if _, ok := r.Lookup(ExchangeKalshi, "m1"); ok {
+		t.Fatal("nil registry should report nothing")
+	}
+}
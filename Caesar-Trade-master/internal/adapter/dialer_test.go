@@ -0,0 +1,231 @@
+package adapter
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tcpEchoServer listens on loopback and echoes every connection.
+func tcpEchoServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				io.Copy(c, c)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// testProxy is a minimal HTTP CONNECT or SOCKS5 proxy that counts tunnels.
+type testProxy struct {
+	addr    string
+	tunnels atomic.Int32
+}
+
+func startProxy(t *testing.T, handshake func(c net.Conn, br *bufio.Reader) (string, bool)) *testProxy {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	p := &testProxy{addr: ln.Addr().String()}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				br := bufio.NewReader(c)
+				target, ok := handshake(c, br)
+				if !ok {
+					return
+				}
+				up, err := net.Dial("tcp", target)
+				if err != nil {
+					return
+				}
+				defer up.Close()
+				p.tunnels.Add(1)
+				go io.Copy(up, br)
+				io.Copy(c, up)
+			}()
+		}
+	}()
+	return p
+}
+
+// httpConnectProxy requires Basic auth user:pass when user is non-empty.
+func httpConnectProxy(t *testing.T, user, pass string) *testProxy {
+	return startProxy(t, func(c net.Conn, br *bufio.Reader) (string, bool) {
+		req, err := http.ReadRequest(br)
+		if err != nil || req.Method != http.MethodConnect {
+			return "", false
+		}
+		if user != "" {
+			want := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+			if req.Header.Get("Proxy-Authorization") != want {
+				io.WriteString(c, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+				return "", false
+			}
+		}
+		io.WriteString(c, "HTTP/1.1 200 Connection established\r\n\r\n")
+		return req.Host, true
+	})
+}
+
+// socks5Proxy requires username/password auth when user is non-empty.
+func socks5Proxy(t *testing.T, user, pass string) *testProxy {
+	return startProxy(t, func(c net.Conn, br *bufio.Reader) (string, bool) {
+		var head [2]byte
+		if _, err := io.ReadFull(br, head[:]); err != nil {
+			return "", false
+		}
+		methods := make([]byte, head[1])
+		io.ReadFull(br, methods)
+
+		if user == "" {
+			c.Write([]byte{0x05, 0x00})
+		} else {
+			c.Write([]byte{0x05, 0x02})
+			var ver [2]byte
+			io.ReadFull(br, ver[:])
+			name := make([]byte, ver[1])
+			io.ReadFull(br, name)
+			var plen [1]byte
+			io.ReadFull(br, plen[:])
+			pw := make([]byte, plen[0])
+			io.ReadFull(br, pw)
+			if string(name) != user || string(pw) != pass {
+				c.Write([]byte{0x01, 0x01})
+				return "", false
+			}
+			c.Write([]byte{0x01, 0x00})
+		}
+
+		var req [4]byte
+		if _, err := io.ReadFull(br, req[:]); err != nil {
+			return "", false
+		}
+		var host string
+		switch req[3] {
+		case 0x01:
+			ip := make([]byte, 4)
+			io.ReadFull(br, ip)
+			host = net.IP(ip).String()
+		case 0x03:
+			var n [1]byte
+			io.ReadFull(br, n[:])
+			name := make([]byte, n[0])
+			io.ReadFull(br, name)
+			host = string(name)
+		default:
+			return "", false
+		}
+		var port [2]byte
+		io.ReadFull(br, port[:])
+		c.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port[:])))), true
+	})
+}
+
+func assertEcho(t *testing.T, conn net.Conn) {
+	t.Helper()
+	conn.SetDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(buf) != "ping" {
+		t.Fatalf("expected echo 'ping', got %q", buf)
+	}
+}
+
+func TestDialVia_LocalAddr(t *testing.T) {
+	target := tcpEchoServer(t)
+
+	conn, err := DialVia(context.Background(), "127.0.0.1", "tcp", target)
+	if err != nil {
+		t.Fatalf("DialVia: %v", err)
+	}
+	defer conn.Close()
+
+	if ip := conn.LocalAddr().(*net.TCPAddr).IP; !ip.Equal(net.ParseIP("127.0.0.1")) {
+		t.Fatalf("expected source 127.0.0.1, got %s", ip)
+	}
+	assertEcho(t, conn)
+}
+
+func TestDialVia_HTTPConnect(t *testing.T) {
+	target := tcpEchoServer(t)
+	proxy := httpConnectProxy(t, "alice", "s3cret")
+
+	conn, err := DialVia(context.Background(), "http://alice:s3cret@"+proxy.addr, "tcp", target)
+	if err != nil {
+		t.Fatalf("DialVia: %v", err)
+	}
+	defer conn.Close()
+	assertEcho(t, conn)
+
+	if _, err := DialVia(context.Background(), "http://alice:wrong@"+proxy.addr, "tcp", target); err == nil {
+		t.Fatal("expected error for rejected proxy credentials")
+	}
+}
+
+func TestDialVia_SOCKS5(t *testing.T) {
+	target := tcpEchoServer(t)
+	proxy := socks5Proxy(t, "bob", "hunter2")
+
+	conn, err := DialVia(context.Background(), "socks5://bob:hunter2@"+proxy.addr, "tcp", target)
+	if err != nil {
+		t.Fatalf("DialVia: %v", err)
+	}
+	defer conn.Close()
+	assertEcho(t, conn)
+
+	// Domain-name targets are resolved by the proxy.
+	_, port, _ := net.SplitHostPort(target)
+	conn2, err := DialVia(context.Background(), "socks5://bob:hunter2@"+proxy.addr, "tcp", "localhost:"+port)
+	if err != nil {
+		t.Fatalf("DialVia (domain): %v", err)
+	}
+	defer conn2.Close()
+	assertEcho(t, conn2)
+
+	if _, err := DialVia(context.Background(), "socks5://"+proxy.addr, "tcp", target); err == nil {
+		t.Fatal("expected error when proxy requires credentials")
+	}
+}
+
+func TestDialVia_UnsupportedScheme(t *testing.T) {
+	if _, err := DialVia(context.Background(), "ftp://proxy:21", "tcp", "127.0.0.1:1"); err == nil {
+		t.Fatal("expected error for unsupported scheme")
+	}
+}
@@ -0,0 +1,117 @@
+package adapter
+
+import "sync"
+
+// Feed is the raw message source an exchange adapter reads from. WSClient
+// is the live implementation; ShardedFeed and RedundantFeed combine several
+// connections, and Replayer plays back a recording.
+type Feed interface {
+	// Subscribe returns a channel of raw inbound messages. Subscriptions
+	// must be taken before the feed starts so no messages are missed.
+	Subscribe() <-chan []byte
+
+	// SubscribeFrames is like Subscribe but each message carries its
+	// receive time, the first stage of an update's Trace.
+	SubscribeFrames() <-chan Frame
+
+	// SendSubscription sends a subscription request, remembered under key.
+	SendSubscription(key string, data []byte)
+
+	// SendCommand sends a one-off message, such as an unsubscribe, on the
+	// connection carrying key. Unlike SendSubscription it is not replayed
+	// after a reconnect.
+	SendCommand(key string, data []byte)
+
+	// SendSubscriptions subscribes several keys with one message per
+	// connection, built by build from the keys placed on it, and a
+	// reconnect replays them as one message, rebuilt without any keys
+	// forgotten since. Feeds that move keys between connections build a
+	// key's own message the same way.
+	SendSubscriptions(keys []string, build SubscribeFunc)
+
+	// SendCommands sends batch once on each connection carrying any of keys,
+	// passing it the keys that connection carries. Keys on no connection
+	// are skipped. Like SendCommand it is not replayed.
+	SendCommands(keys []string, batch func(keys []string) []byte)
+
+	// ForgetSubscription removes key from the replay registry. It sends
+	// nothing; callers unsubscribe explicitly.
+	ForgetSubscription(key string)
+}
+
+// SubscribeFunc builds the message subscribing keys on one connection.
+// initial is set for the first subscription on a connection, for venues
+// such as Polymarket whose opening message differs from later additions;
+// other venues ignore it. It may be called again when the message is
+// replayed.
+type SubscribeFunc func(keys []string, initial bool) []byte
+
+var (
+	_ Feed = (*WSClient)(nil)
+	_ Feed = (*Replayer)(nil)
+)
+
+// frameFanOut holds a feed's subscribers, both raw and frame-level, and
+// delivers every frame to all of them according to their backpressure
+// policies.
+type frameFanOut struct {
+	name string // identifies the feed in subscriber logs
+
+	mu     sync.RWMutex
+	raw    []*Subscription[[]byte]
+	frames []*Subscription[Frame]
+}
+
+func (fo *frameFanOut) subscribe(opts SubscribeOptions) *Subscription[[]byte] {
+	sub := newSubscription[[]byte](fo.name, opts, 512)
+	fo.mu.Lock()
+	fo.raw = append(fo.raw, sub)
+	fo.mu.Unlock()
+	return sub
+}
+
+func (fo *frameFanOut) subscribeFrames(opts SubscribeOptions) *Subscription[Frame] {
+	sub := newSubscription[Frame](fo.name, opts, 512)
+	fo.mu.Lock()
+	fo.frames = append(fo.frames, sub)
+	fo.mu.Unlock()
+	return sub
+}
+
+// deliver hands f to every subscriber, then removes any that were evicted.
+func (fo *frameFanOut) deliver(f Frame) {
+	var evictedRaw []*Subscription[[]byte]
+	var evictedFrames []*Subscription[Frame]
+
+	fo.mu.RLock()
+	for _, sub := range fo.raw {
+		if !sub.deliver(f.Data) {
+			evictedRaw = append(evictedRaw, sub)
+		}
+	}
+	for _, sub := range fo.frames {
+		if !sub.deliver(f) {
+			evictedFrames = append(evictedFrames, sub)
+		}
+	}
+	fo.mu.RUnlock()
+
+	if len(evictedRaw) > 0 || len(evictedFrames) > 0 {
+		fo.mu.Lock()
+		fo.raw = without(fo.raw, evictedRaw)
+		fo.frames = without(fo.frames, evictedFrames)
+		fo.mu.Unlock()
+	}
+}
+
+// close closes every subscriber channel.
+func (fo *frameFanOut) close() {
+	fo.mu.RLock()
+	defer fo.mu.RUnlock()
+	for _, sub := range fo.raw {
+		sub.close()
+	}
+	for _, sub := range fo.frames {
+		sub.close()
+	}
+}
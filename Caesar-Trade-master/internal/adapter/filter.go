@@ -0,0 +1,190 @@
+package adapter
+
+import (
+	"sort"
+	"strings"
+)
+
+// Filter selects the BookUpdates a Broadcaster subscriber receives. Every
+// field that is set must match; the zero Filter matches everything.
+type Filter struct {
+	// Exchange restricts updates to one exchange.
+	Exchange Exchange
+	// MarketIDs, if non-empty, restricts updates to a set of markets,
+	// each named by its market ID or, for Kalshi, its ticker.
+	MarketIDs []string
+	// Prefix restricts updates to markets whose symbol starts with it: the
+	// ticker for Kalshi, e.g. the series "KXBTC-", else the market ID.
+	Prefix string
+	// Match, if set, is called for updates passing the other fields. It
+	// runs on the distributing goroutine and must be fast and must not
+	// block.
+	Match func(BookUpdate) bool
+}
+
+// filterSub is a subscriber registered in a filterIndex.
+type filterSub struct {
+	filter Filter
+	ids    map[string]bool // Filter.MarketIDs as a set; nil if unset
+	sub    *Subscription[BookUpdate]
+}
+
+func newFilterSub(f Filter, sub *Subscription[BookUpdate]) *filterSub {
+	fs := &filterSub{filter: f, sub: sub}
+	if len(f.MarketIDs) > 0 {
+		fs.ids = make(map[string]bool, len(f.MarketIDs))
+		for _, id := range f.MarketIDs {
+			fs.ids[id] = true
+		}
+	}
+	return fs
+}
+
+// symbol names update's market the way people refer to it: Kalshi updates
+// carry the venue's market UUID as MarketID and the ticker as AssetID.
+func symbol(update BookUpdate) string {
+	if update.Exchange == ExchangeKalshi && update.AssetID != "" {
+		return update.AssetID
+	}
+	return update.MarketID
+}
+
+// matches reports whether update passes every field of the filter.
+func (fs *filterSub) matches(update BookUpdate) bool {
+	f := fs.filter
+	if f.Exchange != "" && update.Exchange != f.Exchange {
+		return false
+	}
+	if fs.ids != nil && !fs.ids[update.MarketID] && !fs.ids[symbol(update)] {
+		return false
+	}
+	if f.Prefix != "" && !strings.HasPrefix(symbol(update), f.Prefix) {
+		return false
+	}
+	return f.Match == nil || f.Match(update)
+}
+
+// filterIndex routes an update to the filter subscribers that can match it
+// without scanning all of them. Each subscriber is indexed once by its most
+// selective field (market IDs, then prefix, then exchange) and the rest of
+// its filter is checked on lookup. Only subscribers with nothing but a
+// Match function, or nothing at all, are scanned for every update.
+type filterIndex struct {
+	byMarket   map[string][]*filterSub
+	byPrefix   map[string][]*filterSub
+	prefixLens []int // distinct lengths of byPrefix keys, ascending
+	byExchange map[Exchange][]*filterSub
+	rest       []*filterSub
+}
+
+func newFilterIndex() filterIndex {
+	return filterIndex{
+		byMarket:   make(map[string][]*filterSub),
+		byPrefix:   make(map[string][]*filterSub),
+		byExchange: make(map[Exchange][]*filterSub),
+	}
+}
+
+func (ix *filterIndex) add(fs *filterSub) {
+	f := fs.filter
+	switch {
+	case fs.ids != nil:
+		for id := range fs.ids {
+			ix.byMarket[id] = append(ix.byMarket[id], fs)
+		}
+	case f.Prefix != "":
+		if len(ix.byPrefix[f.Prefix]) == 0 {
+			ix.addPrefixLen(len(f.Prefix))
+		}
+		ix.byPrefix[f.Prefix] = append(ix.byPrefix[f.Prefix], fs)
+	case f.Exchange != "":
+		ix.byExchange[f.Exchange] = append(ix.byExchange[f.Exchange], fs)
+	default:
+		ix.rest = append(ix.rest, fs)
+	}
+}
+
+func (ix *filterIndex) remove(fs *filterSub) {
+	f := fs.filter
+	switch {
+	case fs.ids != nil:
+		for id := range fs.ids {
+			ix.byMarket[id] = withoutFilter(ix.byMarket[id], fs)
+			if len(ix.byMarket[id]) == 0 {
+				delete(ix.byMarket, id)
+			}
+		}
+	case f.Prefix != "":
+		ix.byPrefix[f.Prefix] = withoutFilter(ix.byPrefix[f.Prefix], fs)
+		if len(ix.byPrefix[f.Prefix]) == 0 {
+			delete(ix.byPrefix, f.Prefix)
+			ix.rebuildPrefixLens()
+		}
+	case f.Exchange != "":
+		ix.byExchange[f.Exchange] = withoutFilter(ix.byExchange[f.Exchange], fs)
+		if len(ix.byExchange[f.Exchange]) == 0 {
+			delete(ix.byExchange, f.Exchange)
+		}
+	default:
+		ix.rest = withoutFilter(ix.rest, fs)
+	}
+}
+
+// each calls fn for every subscriber whose filter matches update.
+func (ix *filterIndex) each(update BookUpdate, fn func(*filterSub)) {
+	visit := func(list []*filterSub) {
+		for _, fs := range list {
+			if fs.matches(update) {
+				fn(fs)
+			}
+		}
+	}
+
+	visit(ix.byMarket[update.MarketID])
+	sym := symbol(update)
+	if sym != update.MarketID {
+		// Skip watchlists naming both the market ID and the ticker,
+		// already visited above.
+		for _, fs := range ix.byMarket[sym] {
+			if !fs.ids[update.MarketID] && fs.matches(update) {
+				fn(fs)
+			}
+		}
+	}
+	for _, n := range ix.prefixLens {
+		if n > len(sym) {
+			break
+		}
+		visit(ix.byPrefix[sym[:n]])
+	}
+	visit(ix.byExchange[update.Exchange])
+	visit(ix.rest)
+}
+
+func (ix *filterIndex) addPrefixLen(n int) {
+	i := sort.SearchInts(ix.prefixLens, n)
+	if i < len(ix.prefixLens) && ix.prefixLens[i] == n {
+		return
+	}
+	ix.prefixLens = append(ix.prefixLens, 0)
+	copy(ix.prefixLens[i+1:], ix.prefixLens[i:])
+	ix.prefixLens[i] = n
+}
+
+func (ix *filterIndex) rebuildPrefixLens() {
+	ix.prefixLens = ix.prefixLens[:0]
+	for p := range ix.byPrefix {
+		ix.addPrefixLen(len(p))
+	}
+}
+
+// withoutFilter returns list minus drop, preserving order.
+func withoutFilter(list []*filterSub, drop *filterSub) []*filterSub {
+	out := list[:0:0]
+	for _, fs := range list {
+		if fs != drop {
+			out = append(out, fs)
+		}
+	}
+	return out
+}
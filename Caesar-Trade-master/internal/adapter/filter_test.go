@@ -0,0 +1,153 @@
+package adapter
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+)
+
+// kalshiBook builds an update shaped like KalshiAdapter's: the venue's
+// market UUID as MarketID, the ticker as AssetID.
+func kalshiBook(ticker string) BookUpdate {
+	return BookUpdate{Exchange: ExchangeKalshi, MarketID: "uuid-" + ticker, AssetID: ticker}
+}
+
+func TestFilterIndex_Routing(t *testing.T) {
+	ix := newFilterIndex()
+	add := func(f Filter) *filterSub {
+		fs := newFilterSub(f, nil)
+		ix.add(fs)
+		return fs
+	}
+
+	watch := add(Filter{MarketIDs: []string{"KXBTC-A", "0xeth"}})
+	kalshiWatch := add(Filter{Exchange: ExchangeKalshi, MarketIDs: []string{"uuid-KXETH-A"}})
+	both := add(Filter{MarketIDs: []string{"uuid-KXBTC-B", "KXBTC-B"}})
+	series := add(Filter{Prefix: "KXBTC-"})
+	short := add(Filter{Prefix: "KX"})
+	poly := add(Filter{Exchange: ExchangePolymarket})
+	wide := add(Filter{Match: func(u BookUpdate) bool { return len(u.Bids) > 0 }})
+
+	names := map[*filterSub]string{
+		watch: "watch", kalshiWatch: "kalshiWatch", both: "both", series: "series",
+		short: "short", poly: "poly", wide: "wide",
+	}
+	hits := func(u BookUpdate) []string {
+		var out []string
+		ix.each(u, func(fs *filterSub) { out = append(out, names[fs]) })
+		sort.Strings(out)
+		return out
+	}
+	check := func(u BookUpdate, want ...string) {
+		t.Helper()
+		got := hits(u)
+		if len(got) != len(want) {
+			t.Fatalf("%s/%s: want %v, got %v", u.Exchange, symbol(u), want, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s/%s: want %v, got %v", u.Exchange, symbol(u), want, got)
+			}
+		}
+	}
+
+	// Kalshi watchlists and prefixes match the ticker; a watchlist may
+	// also name the market ID, and naming both matches once.
+	withBid := kalshiBook("KXBTC-B")
+	withBid.Bids = []PriceLevel{{Price: 0.5, Size: 1}}
+	check(kalshiBook("KXBTC-A"), "series", "short", "watch")
+	check(withBid, "both", "series", "short", "wide")
+	check(kalshiBook("KXETH-A"), "kalshiWatch", "short")
+	check(BookUpdate{Exchange: ExchangePolymarket, MarketID: "0xeth", AssetID: "KX-token"}, "poly", "watch")
+	check(kalshiBook("K"))
+
+	// Removing the only "KX" prefix stops lookups at that length.
+	ix.remove(short)
+	ix.remove(watch)
+	if len(ix.prefixLens) != 1 || ix.prefixLens[0] != len("KXBTC-") {
+		t.Fatalf("expected one prefix length left, got %v", ix.prefixLens)
+	}
+	if _, ok := ix.byMarket["KXBTC-A"]; ok {
+		t.Fatal("expected market index entry dropped")
+	}
+	check(kalshiBook("KXBTC-A"), "series")
+	check(kalshiBook("KXETH-A"), "kalshiWatch")
+}
+
+func TestBroadcaster_SubscribeFilter(t *testing.T) {
+	src := newMockProvider()
+	bc := NewBroadcaster()
+	bc.Register(src)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go bc.Run(ctx)
+
+	book := func(ticker, hash string) BookUpdate {
+		u := kalshiBook(ticker)
+		u.Hash = hash
+		return u
+	}
+
+	// Cached before subscribing, so it primes the series subscriber.
+	src.send(book("KXBTC-A", "cached"))
+	for {
+		if len(bc.Latest(ExchangeKalshi, "uuid-KXBTC-A")) > 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for cache")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	series := bc.SubscribeFilterWith(ctx, Filter{Exchange: ExchangeKalshi, Prefix: "KXBTC-"}, SubscribeOptions{})
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	watch := bc.SubscribeFilter(watchCtx, Filter{MarketIDs: []string{"0xeth", "KXBTC-B"}})
+
+	src.send(BookUpdate{Exchange: ExchangePolymarket, MarketID: "0xeth", AssetID: "tok", Hash: "eth"})
+	src.send(book("KXETH-A", "skip"))
+	src.send(book("KXBTC-B", "btc"))
+
+	recv := func(ch <-chan BookUpdate) string {
+		t.Helper()
+		select {
+		case u := <-ch:
+			return u.Hash
+		case <-ctx.Done():
+			t.Fatal("timed out")
+			return ""
+		}
+	}
+	if got := recv(series.C()); got != "cached" {
+		t.Fatalf("expected cached snapshot first, got %q", got)
+	}
+	if got := recv(series.C()); got != "btc" {
+		t.Fatalf("expected KXBTC-B, got %q", got)
+	}
+	if got := recv(watch); got != "eth" {
+		t.Fatalf("expected 0xeth, got %q", got)
+	}
+	if got := recv(watch); got != "btc" {
+		t.Fatalf("expected KXBTC-B, got %q", got)
+	}
+
+	stopWatch()
+	if _, ok := <-watch; ok {
+		t.Fatal("expected watchlist closed by its context")
+	}
+	bc.mu.RLock()
+	_, indexed := bc.filters.byMarket["0xeth"]
+	bc.mu.RUnlock()
+	if indexed {
+		t.Fatal("expected watchlist removed from the index")
+	}
+
+	// Filter subscribers outlive the removal of a market they match.
+	bc.RemoveMarket(ExchangeKalshi, "uuid-KXBTC-B")
+	if series.Err() != nil {
+		t.Fatalf("expected series subscriber open, got %v", series.Err())
+	}
+}
@@ -243,7 +243,7 @@ func TestIntegration_Phase2_SuccessAndFailure(t *testing.T) {
 
 	// RedisWriter: mock Redis.
 	redis := &mockRedisForIntegration{}
-	redisFeed := bc.SubscribeAll()
+	redisFeed := bc.SubscribeAll(context.Background())
 	rw := NewRedisWriter(redis, redisFeed)
 
 	// UnifiedBook: pair poly and kalshi markets.
@@ -261,7 +261,7 @@ func TestIntegration_Phase2_SuccessAndFailure(t *testing.T) {
 		StaleThreshold: 1 * time.Second,
 		CoolOff:        500 * time.Millisecond, // short for test
 	}
-	cbFeed := bc.SubscribeAll()
+	cbFeed := bc.SubscribeAll(context.Background())
 	cbr := NewCircuitBreaker(cbCfg, cbFeed)
 	cbr.nowFunc = clock.Now
 	cbr.WatchConnection(ExchangePolymarket, ws)
@@ -0,0 +1,246 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// IPPool rotates outbound routes so that per-user tunnels are spread across
+// source addresses and proxies, avoiding exchange rate-limiting. A route is
+// anything DialVia accepts: a local source address, an http:// CONNECT
+// proxy, or a socks5:// proxy.
+type IPPool interface {
+	// Next returns the next outbound route to use.
+	Next() string
+
+	// ReportFailure tells the pool that dialing through route failed, so
+	// it can be ejected until it passes a health check.
+	ReportFailure(route string, err error)
+}
+
+// RotationStrategy selects how a RoutePool picks the next route.
+type RotationStrategy int
+
+const (
+	// RoundRobin cycles through healthy routes in configuration order.
+	RoundRobin RotationStrategy = iota
+	// LeastRecentlyUsed picks the healthy route that has gone unused the
+	// longest, which spreads load evenly even as routes are ejected and
+	// re-admitted.
+	LeastRecentlyUsed
+)
+
+// RoutePoolConfig holds tunable parameters for a RoutePool.
+type RoutePoolConfig struct {
+	Strategy RotationStrategy
+
+	// HealthCheckInterval is how often Run probes every route, including
+	// ejected ones so they can be re-admitted. Default: 30s.
+	HealthCheckInterval time.Duration
+
+	// HealthCheckTimeout bounds a single probe. Default: 5s.
+	HealthCheckTimeout time.Duration
+
+	// ProbeAddr is the host:port dialled through each route by the default
+	// health check, typically the venue's WebSocket endpoint.
+	ProbeAddr string
+
+	// Check overrides the default health check. It should return nil if
+	// route is usable.
+	Check func(ctx context.Context, route string) error
+}
+
+// DefaultRoutePoolConfig returns production defaults probing probeAddr.
+func DefaultRoutePoolConfig(probeAddr string) RoutePoolConfig {
+	return RoutePoolConfig{
+		Strategy:            RoundRobin,
+		HealthCheckInterval: 30 * time.Second,
+		HealthCheckTimeout:  5 * time.Second,
+		ProbeAddr:           probeAddr,
+	}
+}
+
+// routeState tracks rotation and health for a single route.
+type routeState struct {
+	Route    string
+	Healthy  bool
+	LastUsed time.Time
+	LastErr  error
+}
+
+// RouteStatus is a point-in-time view of one route in a RoutePool.
+type RouteStatus struct {
+	Route    string
+	Healthy  bool
+	LastUsed time.Time
+	LastErr  error
+}
+
+// RoutePool is an IPPool over a fixed set of routes with round-robin or
+// least-recently-used rotation and health-check ejection.
+type RoutePool struct {
+	cfg RoutePoolConfig
+
+	mu     sync.Mutex
+	routes []*routeState
+	next   int // round-robin cursor
+
+	nowFunc func() time.Time // injectable clock for testing
+}
+
+// NewRoutePool creates a RoutePool over routes. All routes start healthy.
+func NewRoutePool(routes []string, cfg RoutePoolConfig) (*RoutePool, error) {
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("ippool: no routes")
+	}
+	if cfg.HealthCheckInterval <= 0 {
+		cfg.HealthCheckInterval = 30 * time.Second
+	}
+	if cfg.HealthCheckTimeout <= 0 {
+		cfg.HealthCheckTimeout = 5 * time.Second
+	}
+
+	p := &RoutePool{cfg: cfg, nowFunc: time.Now}
+	for _, r := range routes {
+		if err := validateRoute(r); err != nil {
+			return nil, fmt.Errorf("ippool: %w", err)
+		}
+		p.routes = append(p.routes, &routeState{Route: r, Healthy: true})
+	}
+	return p, nil
+}
+
+// Next returns the next healthy route according to the pool's strategy.
+// If every route is ejected it still rotates through all of them rather
+// than silently falling back to the host's own address.
+func (p *RoutePool) Next() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	candidates := make([]int, 0, len(p.routes))
+	for i, rs := range p.routes {
+		if rs.Healthy {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		log.Printf("ippool: all %d routes unhealthy, rotating through ejected routes", len(p.routes))
+		for i := range p.routes {
+			candidates = append(candidates, i)
+		}
+	}
+
+	var pick int
+	switch p.cfg.Strategy {
+	case LeastRecentlyUsed:
+		pick = candidates[0]
+		for _, i := range candidates[1:] {
+			if p.routes[i].LastUsed.Before(p.routes[pick].LastUsed) {
+				pick = i
+			}
+		}
+	default:
+		// First candidate at or after the cursor, wrapping around.
+		pick = candidates[0]
+		for _, i := range candidates {
+			if i >= p.next {
+				pick = i
+				break
+			}
+		}
+		p.next = pick + 1
+	}
+
+	rs := p.routes[pick]
+	rs.LastUsed = p.nowFunc()
+	return rs.Route
+}
+
+// ReportFailure ejects route until the next successful health check.
+func (p *RoutePool) ReportFailure(route string, err error) {
+	p.setHealth(route, err)
+}
+
+// Status returns a snapshot of every route in configuration order.
+func (p *RoutePool) Status() []RouteStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]RouteStatus, len(p.routes))
+	for i, rs := range p.routes {
+		out[i] = RouteStatus{Route: rs.Route, Healthy: rs.Healthy, LastUsed: rs.LastUsed, LastErr: rs.LastErr}
+	}
+	return out
+}
+
+// Run probes every route each HealthCheckInterval, ejecting routes that
+// fail and re-admitting those that recover. It blocks until ctx is
+// cancelled.
+func (p *RoutePool) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		p.CheckAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll probes every route once, concurrently, and updates health.
+func (p *RoutePool) CheckAll(ctx context.Context) {
+	p.mu.Lock()
+	routes := make([]string, len(p.routes))
+	for i, rs := range p.routes {
+		routes[i] = rs.Route
+	}
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, r := range routes {
+		wg.Add(1)
+		go func(route string) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, p.cfg.HealthCheckTimeout)
+			defer cancel()
+			p.setHealth(route, p.check(checkCtx, route))
+		}(r)
+	}
+	wg.Wait()
+}
+
+func (p *RoutePool) check(ctx context.Context, route string) error {
+	if p.cfg.Check != nil {
+		return p.cfg.Check(ctx, route)
+	}
+	conn, err := DialVia(ctx, route, "tcp", p.cfg.ProbeAddr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (p *RoutePool) setHealth(route string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, rs := range p.routes {
+		if rs.Route != route {
+			continue
+		}
+		if err != nil && rs.Healthy {
+			log.Printf("ippool: ejecting route %s: %v", redactRoute(route), err)
+		} else if err == nil && !rs.Healthy {
+			log.Printf("ippool: route %s recovered", redactRoute(route))
+		}
+		rs.Healthy = err == nil
+		rs.LastErr = err
+		return
+	}
+}
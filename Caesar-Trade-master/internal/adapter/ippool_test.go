@@ -0,0 +1,142 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoutePool_RoundRobin(t *testing.T) {
+	pool, err := NewRoutePool([]string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, RoutePoolConfig{})
+	if err != nil {
+		t.Fatalf("NewRoutePool: %v", err)
+	}
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, pool.Next())
+	}
+	if strings.Join(got, ",") != "10.0.0.1,10.0.0.2,10.0.0.3,10.0.0.1" {
+		t.Fatalf("unexpected rotation: %v", got)
+	}
+
+	// An ejected route is skipped until it recovers.
+	pool.ReportFailure("10.0.0.2", errors.New("refused"))
+	got = got[:0]
+	for i := 0; i < 3; i++ {
+		got = append(got, pool.Next())
+	}
+	if strings.Join(got, ",") != "10.0.0.3,10.0.0.1,10.0.0.3" {
+		t.Fatalf("unexpected rotation with ejected route: %v", got)
+	}
+}
+
+func TestRoutePool_LeastRecentlyUsed(t *testing.T) {
+	pool, err := NewRoutePool([]string{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		RoutePoolConfig{Strategy: LeastRecentlyUsed})
+	if err != nil {
+		t.Fatalf("NewRoutePool: %v", err)
+	}
+	clock := newFakeClock(time.Now())
+	pool.nowFunc = func() time.Time {
+		clock.Advance(time.Millisecond)
+		return clock.Now()
+	}
+
+	// Use .1 and .3 while .2 is ejected; on re-admission .2 is the least
+	// recently used and must be picked first.
+	pool.ReportFailure("10.0.0.2", errors.New("timeout"))
+	pool.Next()
+	pool.Next()
+	pool.Next()
+	pool.setHealth("10.0.0.2", nil)
+
+	if got := pool.Next(); got != "10.0.0.2" {
+		t.Fatalf("expected re-admitted route 10.0.0.2, got %s", got)
+	}
+}
+
+func TestRoutePool_HealthCheckEjectsDeadProxy(t *testing.T) {
+	target := tcpEchoServer(t)
+	live := socks5Proxy(t, "", "")
+
+	// Reserve a port, then close it so the proxy is dead.
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	dead := ln.Addr().String()
+	ln.Close()
+
+	cfg := DefaultRoutePoolConfig(target)
+	cfg.HealthCheckTimeout = time.Second
+	pool, err := NewRoutePool([]string{"socks5://" + dead, "socks5://" + live.addr}, cfg)
+	if err != nil {
+		t.Fatalf("NewRoutePool: %v", err)
+	}
+
+	pool.CheckAll(context.Background())
+
+	status := pool.Status()
+	if status[0].Healthy || status[0].LastErr == nil {
+		t.Fatalf("dead proxy should be ejected: %+v", status[0])
+	}
+	if !status[1].Healthy {
+		t.Fatalf("live proxy should stay healthy: %+v", status[1])
+	}
+	for i := 0; i < 3; i++ {
+		if got := pool.Next(); got != "socks5://"+live.addr {
+			t.Fatalf("expected only the live proxy, got %s", got)
+		}
+	}
+}
+
+func TestRoutePool_RejectsInvalidRoute(t *testing.T) {
+	if _, err := NewRoutePool([]string{"socks5://no-port"}, RoutePoolConfig{}); err == nil {
+		t.Fatal("expected error for route without port")
+	}
+	if _, err := NewRoutePool(nil, RoutePoolConfig{}); err == nil {
+		t.Fatal("expected error for empty pool")
+	}
+}
+
+func TestTunnelManager_DialsThroughIPPool(t *testing.T) {
+	srv := echoServer(t)
+	defer srv.Close()
+
+	proxy := httpConnectProxy(t, "", "")
+	pool, err := NewRoutePool([]string{"http://" + proxy.addr}, RoutePoolConfig{})
+	if err != nil {
+		t.Fatalf("NewRoutePool: %v", err)
+	}
+
+	tm := NewTunnelManager()
+	tm.SetIPPool(pool)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tunnel, err := tm.Open(ctx, TunnelConfig{
+		UserID:   "user-1",
+		Exchange: ExchangeKalshi,
+		URL:      toWS(srv),
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer tm.CloseAll()
+
+	if n := proxy.tunnels.Load(); n != 1 {
+		t.Fatalf("expected 1 proxied connection, got %d", n)
+	}
+
+	tunnel.ws.Send([]byte("via-proxy"))
+	select {
+	case msg := <-tunnel.Messages():
+		if string(msg) != "via-proxy" {
+			t.Fatalf("expected 'via-proxy', got %q", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for echo through proxy")
+	}
+}
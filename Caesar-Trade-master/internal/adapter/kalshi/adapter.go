@@ -13,8 +13,11 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/caesar-terminal/caesar/internal/adapter"
@@ -30,16 +33,74 @@ type command struct {
 }
 
 type commandParams struct {
-	Channels     []string `json:"channels"`
-	MarketTicker string   `json:"market_ticker"`
+	Channels      []string `json:"channels,omitempty"`
+	MarketTicker  string   `json:"market_ticker,omitempty"`
+	MarketTickers []string `json:"market_tickers,omitempty"`
+	SIDs          []int    `json:"sids,omitempty"`
+	Action        string   `json:"action,omitempty"` // update_subscription: add_markets, delete_markets
 }
 
+// pendingCmd is a command sent to the venue. Subscribe commands stay
+// registered while any of their tickers is subscribed, since a reconnect
+// replays them under the same ID; other commands are dropped once
+// acknowledged or rejected.
+type pendingCmd struct {
+	cmd     string
+	tickers []string
+	acked   bool
+}
+
+// keyedCmd is a command to send on the connection carrying key.
+type keyedCmd struct {
+	key  string
+	data []byte
+}
+
+// subscribeChannels are the channels Subscribe opens for every ticker.
+var subscribeChannels = []string{"orderbook_delta", "trade"}
+
+// lifecycleChannel carries market lifecycle events for every market; it is
+// subscribed once, without a ticker, when a Halter is set.
+const lifecycleChannel = "market_lifecycle_v2"
+
+// resyncTimeout is how long a resync may await its snapshot before it is
+// retried.
+const resyncTimeout = 5 * time.Second
+
 // --- Raw wire types ---
 
 type rawEnvelope struct {
 	Type string `json:"type"`
 }
 
+// rawSubscribed acknowledges one channel of a subscribe command; ID is the
+// command's.
+type rawSubscribed struct {
+	ID  int `json:"id"`
+	Msg struct {
+		Channel string `json:"channel"`
+		SID     int    `json:"sid"`
+	} `json:"msg"`
+}
+
+// rawAck acknowledges an unsubscribe ("unsubscribed") or
+// update_subscription ("ok") command. An "ok" takes a sequence number in
+// its subscription's stream.
+type rawAck struct {
+	ID  int `json:"id"`
+	SID int `json:"sid"`
+	Seq int `json:"seq"`
+}
+
+// rawError rejects the command with the given ID.
+type rawError struct {
+	ID  int `json:"id"`
+	Msg struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	} `json:"msg"`
+}
+
 type rawSnapshot struct {
 	Type string `json:"type"`
 	SID  int    `json:"sid"`
@@ -66,32 +127,138 @@ type rawDelta struct {
 	} `json:"msg"`
 }
 
-// orderBook is the internal order book state for a single market.
+// rawTrade is a trade channel message. Prices are in cents; TakerSide is
+// the contract the taker bought and Ts is in Unix seconds.
+type rawTrade struct {
+	Type string `json:"type"`
+	SID  int    `json:"sid"`
+	Seq  int    `json:"seq"`
+	Msg  struct {
+		TradeID      string `json:"trade_id"`
+		MarketTicker string `json:"market_ticker"`
+		YesPrice     int    `json:"yes_price"`
+		NoPrice      int    `json:"no_price"`
+		Count        int    `json:"count"`
+		TakerSide    string `json:"taker_side"`
+		Ts           int64  `json:"ts"`
+	} `json:"msg"`
+}
+
+// rawLifecycle is a market lifecycle event. EventType is one of created,
+// activated, deactivated, close_date_updated, determined or settled;
+// IsDeactivated distinguishes a pause from its reversal and CloseTs is in
+// Unix seconds.
+type rawLifecycle struct {
+	Type string `json:"type"`
+	Msg  struct {
+		EventType     string `json:"event_type"`
+		MarketTicker  string `json:"market_ticker"`
+		IsDeactivated bool   `json:"is_deactivated"`
+		CloseTs       int64  `json:"close_ts"`
+		Result        string `json:"result"`
+	} `json:"msg"`
+}
+
+// orderBook is the internal order book state for a single market. SID
+// identifies the subscription that seeded it.
 type orderBook struct {
 	MarketTicker string
 	MarketID     string
 	Yes          map[int]int // price (cents) → quantity
 	No           map[int]int
+	SID          subID
+}
+
+// subID identifies a subscription. Kalshi numbers subscriptions per
+// connection from 1, so behind a ShardedFeed a sid is only unique together
+// with the connection it arrived on.
+type subID struct {
+	conn uint64
+	sid  int
 }
 
 // KalshiAdapter connects to the Kalshi WebSocket and normalises order book
-// data into unified BookUpdate values.
+// data into unified BookUpdate values and trades into Trade values.
 type KalshiAdapter struct {
-	ws *adapter.WSClient
+	feed adapter.Feed
 
-	raw     <-chan []byte
+	frames  <-chan adapter.Frame
 	updates chan adapter.BookUpdate
+	trades  chan adapter.Trade
 
 	mu    sync.RWMutex
 	books map[string]*orderBook // keyed by market_ticker
 
+	// Subscription bookkeeping, guarded by mu. cmds maps command IDs to
+	// the commands so acks and errors can be attributed; sids holds each
+	// ticker's subscription ID per channel and sidTickers the reverse, as a
+	// batch subscription shares its IDs between tickers; resyncing holds
+	// tickers awaiting a fresh snapshot and when the resync was requested;
+	// removed holds tickers unsubscribed since their last Subscribe, whose
+	// in-flight messages are ignored. seqs holds the last sequence number
+	// seen per orderbook subscription ID.
+	cmdID      int
+	cmds       map[int]*pendingCmd
+	sids       map[string]map[string]subID
+	sidTickers map[subID]map[string]bool
+	seqs       map[subID]int
+	resyncing  map[string]time.Time
+	removed    map[string]bool
+
+	cmdErrors atomic.Uint64
+	removers  []adapter.MarketRemover
+
+	// unkeyedTrades counts trades dropped because their ticker's market ID
+	// was not yet known.
+	unkeyedTrades atomic.Uint64
+
+	stale   StaleMarker // nil disables
+	resyncs atomic.Uint64
+
+	// Lifecycle state, guarded by mu. halter is told about venue halts of
+	// subscribed tickers; marketIDs maps tickers to the market IDs their
+	// updates carry, and halts holds each halted ticker's reason so a halt
+	// seen before the first snapshot is applied once the ID is known.
+	// closeTimers halt tickers at the close time the venue announced.
+	halter        Halter // nil disables
+	lifecycleSent bool
+	subscribed    map[string]bool
+	marketIDs     map[string]string
+	halts         map[string]string
+	closeTimers   map[string]*time.Timer
+
+	maxDepth int // levels per side of emitted updates; 0 keeps all
+
 	levelPool sync.Pool
-	cmdID     int
 }
 
-// AuthHeaders computes the RSA-PSS authentication headers required for the
-// Kalshi WebSocket upgrade request.
-func AuthHeaders(apiKey string, privateKeyPEM []byte) (http.Header, error) {
+// StaleMarker is told when a market's book can no longer be trusted.
+// Satisfied by adapter.CircuitBreaker.
+type StaleMarker interface {
+	MarkStale(exchange adapter.Exchange, marketID string)
+}
+
+// Halter is told when the venue halts or reopens trading in a market or on
+// the whole exchange. Satisfied by adapter.CircuitBreaker.
+type Halter interface {
+	HaltMarket(exchange adapter.Exchange, marketID, reason string)
+	ResumeMarket(exchange adapter.Exchange, marketID string)
+	HaltExchange(exchange adapter.Exchange, reason string)
+	ResumeExchange(exchange adapter.Exchange)
+}
+
+// Signer produces the RSA-PSS authentication headers Kalshi requires on the
+// WebSocket upgrade and on every authenticated REST request. The signature
+// covers a millisecond timestamp and is only accepted for a short window,
+// so a Signer re-signs on every call rather than caching headers.
+type Signer struct {
+	apiKey string
+	key    *rsa.PrivateKey
+	now    func() time.Time
+}
+
+// NewSigner parses a PKCS#8 PEM-encoded RSA private key for apiKey.
+func NewSigner(apiKey string, privateKeyPEM []byte) (*Signer, error) {
 	block, _ := pem.Decode(privateKeyPEM)
 	if block == nil {
 		return nil, fmt.Errorf("kalshi: failed to decode PEM block")
@@ -107,11 +274,24 @@ func AuthHeaders(apiKey string, privateKeyPEM []byte) (http.Header, error) {
 		return nil, fmt.Errorf("kalshi: key is not RSA")
 	}
 
-	ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
-	msg := ts + "GET" + wsPath
+	return &Signer{apiKey: apiKey, key: rsaKey, now: time.Now}, nil
+}
+
+// Headers signs the WebSocket handshake with the current timestamp.
+func (s *Signer) Headers() (http.Header, error) {
+	return s.Sign(http.MethodGet, wsPath)
+}
+
+// Sign signs a request for method and path with the current timestamp.
+// path is the full URL path, e.g. "/trade-api/v2/portfolio/orders"; any
+// query string is not part of the signature and is stripped.
+func (s *Signer) Sign(method, path string) (http.Header, error) {
+	path, _, _ = strings.Cut(path, "?")
+	ts := strconv.FormatInt(s.now().UnixMilli(), 10)
+	msg := ts + method + path
 
 	h := sha256.Sum256([]byte(msg))
-	sig, err := rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, h[:], &rsa.PSSOptions{
+	sig, err := rsa.SignPSS(rand.Reader, s.key, crypto.SHA256, h[:], &rsa.PSSOptions{
 		SaltLength: rsa.PSSSaltLengthEqualsHash,
 	})
 	if err != nil {
@@ -119,21 +299,63 @@ func AuthHeaders(apiKey string, privateKeyPEM []byte) (http.Header, error) {
 	}
 
 	headers := http.Header{}
-	headers.Set("KALSHI-ACCESS-KEY", apiKey)
+	headers.Set("KALSHI-ACCESS-KEY", s.apiKey)
 	headers.Set("KALSHI-ACCESS-TIMESTAMP", ts)
 	headers.Set("KALSHI-ACCESS-SIGNATURE", base64.StdEncoding.EncodeToString(sig))
 
 	return headers, nil
 }
 
-// New creates a KalshiAdapter backed by the given WSClient.
-// It immediately subscribes to the WSClient fan-out so no messages are missed.
-func New(ws *adapter.WSClient) *KalshiAdapter {
+// HeaderProvider returns a provider for WSConfig or TunnelConfig that
+// re-signs on every dial, so reconnects never present a stale signature.
+func (s *Signer) HeaderProvider() adapter.HeaderProvider {
+	return func(context.Context) (http.Header, error) {
+		return s.Headers()
+	}
+}
+
+// AuthHeaders computes the RSA-PSS authentication headers once. The result
+// expires shortly after it is created; prefer DefaultWSConfig or
+// Signer.HeaderProvider for long-lived connections.
+func AuthHeaders(apiKey string, privateKeyPEM []byte) (http.Header, error) {
+	s, err := NewSigner(apiKey, privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return s.Headers()
+}
+
+// DefaultWSConfig returns adapter.DefaultWSConfig with the handshake signed
+// by signer on every dial.
+func DefaultWSConfig(url string, signer *Signer) adapter.WSConfig {
+	cfg := adapter.DefaultWSConfig(url)
+	cfg.HeaderProvider = signer.HeaderProvider()
+	return cfg
+}
+
+// New creates a KalshiAdapter backed by the given feed, normally a WSClient
+// or, for offline runs, a Replayer. It immediately subscribes to the feed so
+// no messages are missed.
+func New(feed adapter.Feed) *KalshiAdapter {
 	return &KalshiAdapter{
-		ws:      ws,
-		raw:     ws.Subscribe(),
+		feed:    feed,
+		frames:  feed.SubscribeFrames(),
 		updates: make(chan adapter.BookUpdate, 1024),
+		trades:  make(chan adapter.Trade, 1024),
 		books:   make(map[string]*orderBook),
+
+		cmds:       make(map[int]*pendingCmd),
+		sids:       make(map[string]map[string]subID),
+		sidTickers: make(map[subID]map[string]bool),
+		seqs:       make(map[subID]int),
+		resyncing:  make(map[string]time.Time),
+		removed:    make(map[string]bool),
+		subscribed: make(map[string]bool),
+		marketIDs:  make(map[string]string),
+		halts:      make(map[string]string),
+
+		closeTimers: make(map[string]*time.Timer),
+
 		levelPool: sync.Pool{
 			New: func() any {
 				s := make([]adapter.PriceLevel, 0, 32)
@@ -148,37 +370,296 @@ func (ka *KalshiAdapter) Updates() <-chan adapter.BookUpdate {
 	return ka.updates
 }
 
-// Subscribe sends a Kalshi orderbook_delta subscription for the given ticker.
+// Trades returns the channel of normalised trade prints.
+func (ka *KalshiAdapter) Trades() <-chan adapter.Trade {
+	return ka.trades
+}
+
+// SetStaleMarker makes the adapter report markets whose book lost sequence
+// consistency, typically to the CircuitBreaker. Must be called before Run.
+func (ka *KalshiAdapter) SetStaleMarker(m StaleMarker) {
+	ka.stale = m
+}
+
+// SetHalter makes the adapter follow the market lifecycle channel and halt
+// or resume subscribed markets on h, typically the CircuitBreaker. Must be
+// called before Subscribe.
+func (ka *KalshiAdapter) SetHalter(h Halter) {
+	ka.mu.Lock()
+	ka.halter = h
+	ka.mu.Unlock()
+}
+
+// SetMaxDepth caps each side of emitted updates to the best n levels. The
+// internal books keep full depth. Zero, the default, emits every level.
+// Must be called before Run.
+func (ka *KalshiAdapter) SetMaxDepth(n int) {
+	ka.maxDepth = n
+}
+
+// Resyncs returns how many times a book was dropped and resubscribed after
+// a sequence gap, duplicate or delta without snapshot.
+func (ka *KalshiAdapter) Resyncs() uint64 {
+	return ka.resyncs.Load()
+}
+
+// SetMarketRemovers registers components, typically the Broadcaster and
+// CircuitBreaker, to tell when a ticker is unsubscribed. Must be called
+// before Run.
+func (ka *KalshiAdapter) SetMarketRemovers(rs ...adapter.MarketRemover) {
+	ka.removers = rs
+}
+
+// MarketID returns the market ID a subscribed ticker's updates carry. It
+// is known once the ticker's first snapshot has arrived. It satisfies
+// MarketIDResolver.
+func (ka *KalshiAdapter) MarketID(ticker string) (string, bool) {
+	ka.mu.RLock()
+	defer ka.mu.RUnlock()
+	id, ok := ka.marketIDs[ticker]
+	return id, ok
+}
+
+// CommandErrors returns how many commands the venue rejected.
+func (ka *KalshiAdapter) CommandErrors() uint64 {
+	return ka.cmdErrors.Load()
+}
+
+// UnkeyedTrades returns how many trades were dropped because they arrived
+// before their ticker's first snapshot, when its market ID is not known.
+func (ka *KalshiAdapter) UnkeyedTrades() uint64 {
+	return ka.unkeyedTrades.Load()
+}
+
+// Subscribe sends a Kalshi orderbook_delta and trade subscription for the
+// given ticker, and with a Halter set, the lifecycle subscription on first
+// use. Subscriptions are replayed automatically after every reconnect.
 func (ka *KalshiAdapter) Subscribe(ticker string) {
+	ka.SubscribeMany([]string{ticker})
+}
+
+// SubscribeMany subscribes several tickers with one multi-market command
+// per connection, which is replayed as one command after a reconnect.
+func (ka *KalshiAdapter) SubscribeMany(tickers []string) {
+	if len(tickers) == 0 {
+		return
+	}
+	ka.mu.Lock()
+	for _, t := range tickers {
+		ka.subscribed[t] = true
+		delete(ka.removed, t)
+	}
+	var single []byte
+	if len(tickers) == 1 {
+		single = ka.subscribeCmd(tickers)
+	}
+	var lifecycle []byte
+	if ka.halter != nil && !ka.lifecycleSent {
+		ka.lifecycleSent = true
+		lifecycle = ka.newCmd("subscribe", nil, commandParams{Channels: []string{lifecycleChannel}})
+	}
+	ka.mu.Unlock()
+
+	if single != nil {
+		ka.feed.SendSubscription(tickers[0], single)
+	} else {
+		ka.feed.SendSubscriptions(tickers, ka.lockedSubscribeCmd)
+	}
+	if lifecycle != nil {
+		ka.feed.SendSubscription(lifecycleChannel, lifecycle)
+	}
+}
+
+// lockedSubscribeCmd is subscribeCmd for feed callbacks, which run without
+// mu held. Kalshi subscribes the same way on a fresh connection.
+func (ka *KalshiAdapter) lockedSubscribeCmd(tickers []string, _ bool) []byte {
+	ka.mu.Lock()
+	defer ka.mu.Unlock()
+	return ka.subscribeCmd(tickers)
+}
+
+// subscribeCmd builds a subscribe command for tickers under a fresh command
+// ID. Caller holds mu.
+func (ka *KalshiAdapter) subscribeCmd(tickers []string) []byte {
+	params := commandParams{Channels: subscribeChannels}
+	if len(tickers) == 1 {
+		params.MarketTicker = tickers[0]
+	} else {
+		params.MarketTickers = tickers
+	}
+	return ka.newCmd("subscribe", tickers, params)
+}
+
+// newCmd registers a command under a fresh ID and encodes it. Caller holds
+// mu.
+func (ka *KalshiAdapter) newCmd(cmd string, tickers []string, params commandParams) []byte {
 	ka.cmdID++
-	msg, _ := json.Marshal(command{
-		ID:  ka.cmdID,
-		Cmd: "subscribe",
-		Params: commandParams{
-			Channels:     []string{"orderbook_delta"},
-			MarketTicker: ticker,
-		},
+	ka.cmds[ka.cmdID] = &pendingCmd{cmd: cmd, tickers: append([]string(nil), tickers...)}
+	msg, _ := json.Marshal(command{ID: ka.cmdID, Cmd: cmd, Params: params})
+	return msg
+}
+
+// Unsubscribe stops a ticker; see UnsubscribeMany.
+func (ka *KalshiAdapter) Unsubscribe(ticker string) {
+	ka.UnsubscribeMany([]string{ticker})
+}
+
+// UnsubscribeMany stops the given tickers. Subscriptions carrying only
+// these tickers are unsubscribed; those shared with other tickers drop
+// them with update_subscription. The tickers' books and bookkeeping are
+// discarded, they are dropped from replay, and every MarketRemover is told
+// the markets are gone.
+func (ka *KalshiAdapter) UnsubscribeMany(tickers []string) {
+	if len(tickers) == 0 {
+		return
+	}
+
+	ka.mu.Lock()
+	cmds := ka.removalCmds(tickers)
+	var marketIDs []string
+	for _, t := range tickers {
+		id := ka.marketIDs[t]
+		if book, ok := ka.books[t]; ok && id == "" {
+			id = book.MarketID
+		}
+		if id != "" {
+			marketIDs = append(marketIDs, id)
+		}
+		ka.removed[t] = true
+		delete(ka.subscribed, t)
+		delete(ka.books, t)
+		delete(ka.resyncing, t)
+		delete(ka.marketIDs, t)
+		delete(ka.halts, t)
+		if timer := ka.closeTimers[t]; timer != nil {
+			timer.Stop()
+			delete(ka.closeTimers, t)
+		}
+	}
+	ka.dropCmdTickers(tickers)
+	ka.mu.Unlock()
+
+	for _, c := range cmds {
+		ka.feed.SendCommand(c.key, c.data)
+	}
+	for _, t := range tickers {
+		ka.feed.ForgetSubscription(t)
+	}
+	for _, id := range marketIDs {
+		for _, r := range ka.removers {
+			r.RemoveMarket(adapter.ExchangeKalshi, id)
+		}
+	}
+}
+
+// removalCmds builds the commands that stop tickers' channels and drops
+// their subscription IDs: one unsubscribe per group of IDs carrying only
+// these tickers, keyed by a ticker on their connection, and one
+// update_subscription delete_markets per ID shared with other tickers.
+// Caller holds mu.
+func (ka *KalshiAdapter) removalCmds(tickers []string) []keyedCmd {
+	drop := make(map[subID][]string)
+	for _, t := range tickers {
+		for _, id := range ka.sids[t] {
+			drop[id] = append(drop[id], t)
+		}
+		delete(ka.sids, t)
+	}
+	ids := make([]subID, 0, len(drop))
+	for id := range drop {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].conn != ids[j].conn {
+			return ids[i].conn < ids[j].conn
+		}
+		return ids[i].sid < ids[j].sid
 	})
-	ka.ws.Send(msg)
+
+	// An unsubscribe names sids of one connection only.
+	type group struct {
+		key  string // representative ticker
+		conn uint64
+	}
+	var cmds []keyedCmd
+	var wholeKeys []group
+	whole := make(map[group][]int)
+	wholeTickers := make(map[group][]string)
+	for _, id := range ids {
+		gone := drop[id]
+		sort.Strings(gone)
+		for _, t := range gone {
+			delete(ka.sidTickers[id], t)
+		}
+		if len(ka.sidTickers[id]) > 0 {
+			cmds = append(cmds, keyedCmd{key: gone[0], data: ka.newCmd("update_subscription", gone, commandParams{
+				SIDs:          []int{id.sid},
+				MarketTickers: gone,
+				Action:        "delete_markets",
+			})})
+			continue
+		}
+		delete(ka.sidTickers, id)
+		delete(ka.seqs, id)
+		g := group{key: gone[0], conn: id.conn}
+		if whole[g] == nil {
+			wholeKeys = append(wholeKeys, g)
+			wholeTickers[g] = gone
+		}
+		whole[g] = append(whole[g], id.sid)
+	}
+
+	unsubs := make([]keyedCmd, 0, len(wholeKeys))
+	for _, g := range wholeKeys {
+		data := ka.newCmd("unsubscribe", wholeTickers[g], commandParams{SIDs: whole[g]})
+		unsubs = append(unsubs, keyedCmd{key: g.key, data: data})
+	}
+	return append(unsubs, cmds...)
+}
+
+// dropCmdTickers removes tickers from registered subscribe commands,
+// dropping commands left with none. Caller holds mu.
+func (ka *KalshiAdapter) dropCmdTickers(tickers []string) {
+	gone := make(map[string]bool, len(tickers))
+	for _, t := range tickers {
+		gone[t] = true
+	}
+	for id, c := range ka.cmds {
+		if c.cmd != "subscribe" || len(c.tickers) == 0 {
+			continue
+		}
+		kept := c.tickers[:0]
+		for _, t := range c.tickers {
+			if !gone[t] {
+				kept = append(kept, t)
+			}
+		}
+		c.tickers = kept
+		if len(kept) == 0 {
+			delete(ka.cmds, id)
+		}
+	}
 }
 
-// Run reads from the WSClient fan-out, processes snapshots and deltas, and
-// emits BookUpdate values. It blocks until ctx is cancelled.
+// Run reads from the feed's fan-out, processes snapshots and deltas, and
+// emits BookUpdate values. It blocks until ctx is cancelled or the feed
+// closes.
 func (ka *KalshiAdapter) Run(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case raw, ok := <-ka.raw:
+		case f, ok := <-ka.frames:
 			if !ok {
 				return
 			}
-			ka.handleMessage(raw)
+			ka.handleMessage(f)
 		}
 	}
 }
 
-func (ka *KalshiAdapter) handleMessage(raw []byte) {
+func (ka *KalshiAdapter) handleMessage(f adapter.Frame) {
+	raw := f.Data
 	var env rawEnvelope
 	if err := json.Unmarshal(raw, &env); err != nil {
 		log.Printf("kalshi: invalid JSON: %v", err)
@@ -187,19 +668,27 @@ func (ka *KalshiAdapter) handleMessage(raw []byte) {
 
 	switch env.Type {
 	case "orderbook_snapshot":
-		ka.handleSnapshot(raw)
+		ka.handleSnapshot(f)
 	case "orderbook_delta":
-		ka.handleDelta(raw)
+		ka.handleDelta(f)
+	case "trade":
+		ka.handleTrade(f)
+	case "subscribed":
+		ka.handleSubscribed(f)
+	case "ok", "unsubscribed":
+		ka.handleAck(f)
+	case "market_lifecycle_v2", "market_lifecycle":
+		ka.handleLifecycle(f)
 	case "error":
-		log.Printf("kalshi: exchange error: %s", raw)
+		ka.handleError(f)
 	default:
 		// Other message types ignored.
 	}
 }
 
-func (ka *KalshiAdapter) handleSnapshot(raw []byte) {
+func (ka *KalshiAdapter) handleSnapshot(f adapter.Frame) {
 	var snap rawSnapshot
-	if err := json.Unmarshal(raw, &snap); err != nil {
+	if err := json.Unmarshal(f.Data, &snap); err != nil {
 		log.Printf("kalshi: failed to parse snapshot: %v", err)
 		return
 	}
@@ -209,6 +698,7 @@ func (ka *KalshiAdapter) handleSnapshot(raw []byte) {
 		MarketID:     snap.Msg.MarketID,
 		Yes:          make(map[int]int, len(snap.Msg.Yes)),
 		No:           make(map[int]int, len(snap.Msg.No)),
+		SID:          subID{conn: f.ConnID, sid: snap.SID},
 	}
 	for _, level := range snap.Msg.Yes {
 		book.Yes[level[0]] = level[1]
@@ -218,22 +708,95 @@ func (ka *KalshiAdapter) handleSnapshot(raw []byte) {
 	}
 
 	ka.mu.Lock()
+	ka.seqs[book.SID] = snap.Seq
+	if ka.removed[snap.Msg.MarketTicker] {
+		ka.mu.Unlock()
+		return
+	}
 	ka.books[snap.Msg.MarketTicker] = book
+	delete(ka.resyncing, snap.Msg.MarketTicker)
+	_, known := ka.marketIDs[snap.Msg.MarketTicker]
+	ka.marketIDs[snap.Msg.MarketTicker] = snap.Msg.MarketID
+	reason, halted := ka.halts[snap.Msg.MarketTicker]
+	halter := ka.halter
 	ka.mu.Unlock()
 
-	ka.emitUpdate(book)
+	// A halt that arrived before the market ID was known.
+	if halted && !known && halter != nil {
+		halter.HaltMarket(adapter.ExchangeKalshi, snap.Msg.MarketID, reason)
+	}
+
+	// Snapshots carry no exchange timestamp.
+	ka.emitUpdate(book, f.Received, time.Time{})
 }
 
-func (ka *KalshiAdapter) handleDelta(raw []byte) {
+func (ka *KalshiAdapter) handleDelta(f adapter.Frame) {
 	var delta rawDelta
-	if err := json.Unmarshal(raw, &delta); err != nil {
+	if err := json.Unmarshal(f.Data, &delta); err != nil {
 		log.Printf("kalshi: failed to parse delta: %v", err)
 		return
 	}
 
+	ticker := delta.Msg.MarketTicker
+	id := subID{conn: f.ConnID, sid: delta.SID}
+
 	ka.mu.Lock()
-	book, ok := ka.books[delta.Msg.MarketTicker]
-	if !ok {
+	// Sequence numbers run per subscription, which a batch subscription
+	// shares between tickers, so every delta advances its subscription's
+	// sequence whichever ticker it carries. A gap may have hit any of them.
+	// Subscriptions are told apart by connection as well as sid.
+	if last, tracked := ka.seqs[id]; tracked && delta.Seq != last+1 {
+		reason := fmt.Sprintf("sequence gap: sid %d expected seq %d, got %d", delta.SID, last+1, delta.Seq)
+		if delta.Seq <= last {
+			reason = fmt.Sprintf("duplicate: sid %d seq %d already applied", delta.SID, delta.Seq)
+		} else {
+			ka.seqs[id] = delta.Seq
+		}
+		affected := map[string]string{ticker: delta.Msg.MarketID}
+		for t := range ka.sidTickers[id] {
+			affected[t] = ""
+		}
+		for t := range affected {
+			if _, busy := ka.resyncing[t]; busy || ka.removed[t] {
+				delete(affected, t)
+			} else if book, ok := ka.books[t]; ok {
+				affected[t] = book.MarketID
+			}
+		}
+		ka.mu.Unlock()
+		tickers := make([]string, 0, len(affected))
+		for t := range affected {
+			tickers = append(tickers, t)
+		}
+		sort.Strings(tickers)
+		for _, t := range tickers {
+			ka.resync(t, affected[t], reason)
+		}
+		return
+	}
+	ka.seqs[id] = delta.Seq
+	if ka.removed[ticker] {
+		// In flight when the ticker was unsubscribed.
+		ka.mu.Unlock()
+		return
+	}
+	if at, ok := ka.resyncing[ticker]; ok {
+		// Deltas are meaningless until the fresh snapshot arrives.
+		retry := time.Since(at) > resyncTimeout
+		ka.mu.Unlock()
+		if retry {
+			ka.resync(ticker, delta.Msg.MarketID, "resync timed out")
+		}
+		return
+	}
+	book, ok := ka.books[ticker]
+	switch {
+	case !ok:
+		ka.mu.Unlock()
+		ka.resync(ticker, delta.Msg.MarketID, "delta before snapshot")
+		return
+	case id != book.SID:
+		// Leftover from a subscription replaced by a resync.
 		ka.mu.Unlock()
 		return
 	}
@@ -251,26 +814,306 @@ func (ka *KalshiAdapter) handleDelta(raw []byte) {
 	}
 	ka.mu.Unlock()
 
-	ka.emitUpdate(book)
+	ka.emitUpdate(book, f.Received, parseTimestamp(delta.Msg.Ts))
+}
+
+// handleSubscribed records the subscription ID the venue assigned to one
+// channel of a subscribe command.
+func (ka *KalshiAdapter) handleSubscribed(f adapter.Frame) {
+	var ack rawSubscribed
+	if err := json.Unmarshal(f.Data, &ack); err != nil {
+		log.Printf("kalshi: failed to parse subscribed ack: %v", err)
+		return
+	}
+
+	ka.mu.Lock()
+	defer ka.mu.Unlock()
+	c, ok := ka.cmds[ack.ID]
+	if !ok {
+		return
+	}
+	c.acked = true
+	id := subID{conn: f.ConnID, sid: ack.Msg.SID}
+	for _, ticker := range c.tickers {
+		if ka.sids[ticker] == nil {
+			ka.sids[ticker] = make(map[string]subID)
+		}
+		// A replayed subscription replaces the ticker's old ID, which may
+		// belong to a connection that is gone.
+		if old, ok := ka.sids[ticker][ack.Msg.Channel]; ok && old != id {
+			delete(ka.sidTickers[old], ticker)
+			if len(ka.sidTickers[old]) == 0 {
+				delete(ka.sidTickers, old)
+				delete(ka.seqs, old)
+			}
+		}
+		ka.sids[ticker][ack.Msg.Channel] = id
+		if ka.sidTickers[id] == nil {
+			ka.sidTickers[id] = make(map[string]bool)
+		}
+		ka.sidTickers[id][ticker] = true
+	}
+}
+
+// handleAck retires an acknowledged unsubscribe or update_subscription
+// command.
+func (ka *KalshiAdapter) handleAck(f adapter.Frame) {
+	var ack rawAck
+	if err := json.Unmarshal(f.Data, &ack); err != nil {
+		log.Printf("kalshi: failed to parse ack: %v", err)
+		return
+	}
+	ka.mu.Lock()
+	if c, ok := ka.cmds[ack.ID]; ok && c.cmd != "subscribe" {
+		delete(ka.cmds, ack.ID)
+	}
+	id := subID{conn: f.ConnID, sid: ack.SID}
+	if last, tracked := ka.seqs[id]; tracked && ack.Seq > last {
+		ka.seqs[id] = ack.Seq
+	}
+	ka.mu.Unlock()
+}
+
+// handleError logs a rejected command with the tickers it concerned. The
+// command is retired unless it is a subscribe, which a reconnect replays.
+func (ka *KalshiAdapter) handleError(f adapter.Frame) {
+	var e rawError
+	if err := json.Unmarshal(f.Data, &e); err != nil {
+		log.Printf("kalshi: exchange error: %s", f.Data)
+		return
+	}
+	ka.cmdErrors.Add(1)
+
+	ka.mu.Lock()
+	c, ok := ka.cmds[e.ID]
+	if ok {
+		c.acked = true
+		if c.cmd != "subscribe" {
+			delete(ka.cmds, e.ID)
+		}
+	}
+	ka.mu.Unlock()
+
+	if !ok {
+		log.Printf("kalshi: exchange error %d: %s", e.Msg.Code, e.Msg.Msg)
+		return
+	}
+	log.Printf("kalshi: %s %v rejected (id %d): error %d: %s", c.cmd, c.tickers, e.ID, e.Msg.Code, e.Msg.Msg)
+}
+
+// handleLifecycle maps a lifecycle event of a subscribed market to a halt
+// or resume on the Halter. Determination and settlement halt the market for
+// good; a pause halts it until the event is reversed. A close time still
+// ahead, from creation, activation or a close date update, halts the
+// market when it passes.
+func (ka *KalshiAdapter) handleLifecycle(f adapter.Frame) {
+	var lc rawLifecycle
+	if err := json.Unmarshal(f.Data, &lc); err != nil {
+		log.Printf("kalshi: failed to parse lifecycle event: %v", err)
+		return
+	}
+	ticker := lc.Msg.MarketTicker
+
+	var reason string
+	halt := true
+	switch lc.Msg.EventType {
+	case "created":
+		ka.scheduleClose(ticker, lc.Msg.CloseTs, f.Received)
+		return
+	case "activated":
+		halt = false
+		ka.scheduleClose(ticker, lc.Msg.CloseTs, f.Received)
+	case "deactivated":
+		halt = lc.Msg.IsDeactivated
+		reason = "paused by exchange"
+	case "close_date_updated":
+		if lc.Msg.CloseTs == 0 || ka.scheduleClose(ticker, lc.Msg.CloseTs, f.Received) {
+			return // no date, or the close is still ahead
+		}
+		reason = "closed"
+	case "determined":
+		reason = "closed for settlement"
+		if lc.Msg.Result != "" {
+			reason += ": determined " + lc.Msg.Result
+		}
+	case "settled":
+		reason = "settled"
+	default:
+		return
+	}
+	ka.setHalt(ticker, halt, reason)
+}
+
+// scheduleClose halts ticker as closed at closeTs, in Unix seconds,
+// replacing any earlier schedule. It reports whether the close is still
+// ahead of now; a past or zero closeTs schedules nothing.
+func (ka *KalshiAdapter) scheduleClose(ticker string, closeTs int64, now time.Time) bool {
+	closeAt := time.Unix(closeTs, 0)
+
+	ka.mu.Lock()
+	defer ka.mu.Unlock()
+	if timer := ka.closeTimers[ticker]; timer != nil {
+		timer.Stop()
+		delete(ka.closeTimers, ticker)
+	}
+	if closeTs == 0 || !closeAt.After(now) {
+		return false
+	}
+	if ka.halter == nil || !ka.subscribed[ticker] {
+		return true
+	}
+
+	// The timer cannot fire before mu is released, so timer is set by then.
+	var timer *time.Timer
+	timer = time.AfterFunc(closeAt.Sub(now), func() {
+		ka.mu.Lock()
+		current := ka.closeTimers[ticker] == timer
+		if current {
+			delete(ka.closeTimers, ticker)
+		}
+		ka.mu.Unlock()
+		if current {
+			ka.setHalt(ticker, true, "closed")
+		}
+	})
+	ka.closeTimers[ticker] = timer
+	return true
+}
+
+// setHalt records a subscribed ticker's halt or resume and, once its market
+// ID is known, tells the Halter.
+func (ka *KalshiAdapter) setHalt(ticker string, halt bool, reason string) {
+	ka.mu.Lock()
+	if ka.halter == nil || !ka.subscribed[ticker] {
+		ka.mu.Unlock()
+		return
+	}
+	_, wasHalted := ka.halts[ticker]
+	if halt {
+		ka.halts[ticker] = reason
+	} else {
+		delete(ka.halts, ticker)
+	}
+	marketID, known := ka.marketIDs[ticker]
+	halter := ka.halter
+	ka.mu.Unlock()
+
+	if !known {
+		return // applied by handleSnapshot
+	}
+	switch {
+	case halt:
+		log.Printf("kalshi: %s halted: %s", ticker, reason)
+		halter.HaltMarket(adapter.ExchangeKalshi, marketID, reason)
+	case wasHalted:
+		log.Printf("kalshi: %s resumed", ticker)
+		halter.ResumeMarket(adapter.ExchangeKalshi, marketID)
+	}
 }
 
-// emitUpdate converts the internal book state into a BookUpdate and sends it.
-// YES bids → BookUpdate.Bids, NO bids → BookUpdate.Asks.
-// Prices are normalised from cents (0-99) to a 0.0-1.0 scale.
-func (ka *KalshiAdapter) emitUpdate(book *orderBook) {
+// resync discards ticker's book, marks the market stale and resubscribes
+// so the venue sends a fresh snapshot. The old subscriptions are
+// unsubscribed first so the ticker's channels are not doubled. Deltas are
+// ignored until the snapshot arrives.
+func (ka *KalshiAdapter) resync(ticker, marketID, reason string) {
+	log.Printf("kalshi: %s: %s, resubscribing", ticker, reason)
+	ka.resyncs.Add(1)
+
+	ka.mu.Lock()
+	delete(ka.books, ticker)
+	ka.resyncing[ticker] = time.Now()
+	cmds := ka.removalCmds([]string{ticker})
+	ka.dropCmdTickers([]string{ticker})
+	sub := ka.subscribeCmd([]string{ticker})
+	ka.mu.Unlock()
+
+	if ka.stale != nil && marketID != "" {
+		ka.stale.MarkStale(adapter.ExchangeKalshi, marketID)
+	}
+	for _, c := range cmds {
+		ka.feed.SendCommand(c.key, c.data)
+	}
+	ka.feed.SendSubscription(ticker, sub)
+}
+
+// handleTrade converts a trade message into a Trade priced in YES terms: a
+// taker buying NO is selling YES. Trades carry only the ticker, so they are
+// keyed by the market ID learnt from its snapshots; a trade arriving before
+// the first one is dropped and counted.
+func (ka *KalshiAdapter) handleTrade(f adapter.Frame) {
+	var tr rawTrade
+	if err := json.Unmarshal(f.Data, &tr); err != nil {
+		log.Printf("kalshi: failed to parse trade: %v", err)
+		return
+	}
+
 	ka.mu.RLock()
-	bids := ka.centsToLevels(book.Yes)
-	asks := ka.centsToLevels(book.No)
+	removed := ka.removed[tr.Msg.MarketTicker]
+	marketID, known := ka.marketIDs[tr.Msg.MarketTicker]
 	ka.mu.RUnlock()
+	if removed {
+		return
+	}
+	if !known {
+		if n := ka.unkeyedTrades.Add(1); n == 1 || n%1000 == 0 {
+			log.Printf("kalshi: trade for %s before its snapshot, %d dropped", tr.Msg.MarketTicker, n)
+		}
+		return
+	}
 
-	update := adapter.BookUpdate{
+	trade := adapter.Trade{
 		Exchange:  adapter.ExchangeKalshi,
-		MarketID:  book.MarketID,
-		AssetID:   book.MarketTicker,
-		Bids:      bids,
-		Asks:      asks,
-		Timestamp: time.Now(),
+		MarketID:  marketID,
+		AssetID:   tr.Msg.MarketTicker,
+		Price:     float64(tr.Msg.YesPrice) / 100.0,
+		Size:      float64(tr.Msg.Count),
+		Timestamp: f.Received,
+	}
+	switch tr.Msg.TakerSide {
+	case "yes":
+		trade.Side = adapter.SideBuy
+	case "no":
+		trade.Side = adapter.SideSell
+	}
+	if tr.Msg.Ts != 0 {
+		trade.Timestamp = time.Unix(tr.Msg.Ts, 0)
+	}
+
+	select {
+	case ka.trades <- trade:
+	default:
+		log.Printf("kalshi: trades channel full, dropping trade for %s", tr.Msg.MarketTicker)
 	}
+}
+
+// emitUpdate converts the internal book state into a YES-centric BookUpdate
+// and sends it. Kalshi books hold only bids on each side; a NO bid at c
+// cents is an offer to sell YES at 100−c, so YES bids → BookUpdate.Bids
+// and complemented NO bids → BookUpdate.Asks. Prices are normalised from
+// cents to a 0.0-1.0 scale; BookUpdate.Complement recovers the NO-centric
+// view with the raw NO bids. Timestamp is the exchange time if known, else
+// the frame's receive time.
+func (ka *KalshiAdapter) emitUpdate(book *orderBook, received, exchangeTime time.Time) {
+	ka.mu.RLock()
+	bids := ka.centsToLevels(book.Yes, false)
+	asks := ka.centsToLevels(book.No, true)
+	ka.mu.RUnlock()
+
+	update := adapter.BookUpdate{
+		Exchange:     adapter.ExchangeKalshi,
+		MarketID:     book.MarketID,
+		AssetID:      book.MarketTicker,
+		Bids:         bids,
+		Asks:         asks,
+		Timestamp:    exchangeTime,
+		ExchangeTime: exchangeTime,
+	}
+	if exchangeTime.IsZero() {
+		update.Timestamp = received
+	}
+	update.Normalize(ka.maxDepth)
+	update.Trace.MarkAt(adapter.StageReceived, received)
+	update.Trace.Mark(adapter.StageParsed)
 
 	select {
 	case ka.updates <- update:
@@ -279,13 +1122,19 @@ func (ka *KalshiAdapter) emitUpdate(book *orderBook) {
 	}
 }
 
-// centsToLevels converts a map of cents→quantity into a PriceLevel slice,
-// normalising prices from cents (0-99) to a 0.0-1.0 scale.
-func (ka *KalshiAdapter) centsToLevels(m map[int]int) []adapter.PriceLevel {
+// centsToLevels converts one side's bids, a map of cents→quantity, into a
+// PriceLevel slice on a 0.0-1.0 scale. With complement set, each level is
+// converted to the opposite contract's ask at 100−c. Levels are unordered
+// until BookUpdate.Normalize.
+func (ka *KalshiAdapter) centsToLevels(m map[int]int, complement bool) []adapter.PriceLevel {
 	pooled := ka.levelPool.Get().(*[]adapter.PriceLevel)
 	levels := (*pooled)[:0]
 
-	for price, qty := range m {
+	for cents, qty := range m {
+		price := cents
+		if complement {
+			price = 100 - cents
+		}
 		levels = append(levels, adapter.PriceLevel{
 			Price: float64(price) / 100.0,
 			Size:  float64(qty),
@@ -298,3 +1147,16 @@ func (ka *KalshiAdapter) centsToLevels(m map[int]int) []adapter.PriceLevel {
 
 	return out
 }
+
+// parseTimestamp parses Kalshi's RFC 3339 message time, returning the zero
+// time if it is absent or malformed.
+func parseTimestamp(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	ts, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return ts
+}
@@ -2,16 +2,20 @@ package kalshi
 
 import (
 	"context"
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
+	"fmt"
 	"math"
 	"net/http"
 	"net/http/httptest"
-	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -106,8 +110,8 @@ func TestKalshiAdapter_SubscriptionMessage(t *testing.T) {
 		if cmd.Cmd != "subscribe" {
 			t.Fatalf("expected cmd 'subscribe', got %q", cmd.Cmd)
 		}
-		if len(cmd.Params.Channels) != 1 || cmd.Params.Channels[0] != "orderbook_delta" {
-			t.Fatalf("expected channels ['orderbook_delta'], got %v", cmd.Params.Channels)
+		if len(cmd.Params.Channels) != 2 || cmd.Params.Channels[0] != "orderbook_delta" || cmd.Params.Channels[1] != "trade" {
+			t.Fatalf("expected channels ['orderbook_delta' 'trade'], got %v", cmd.Params.Channels)
 		}
 		if cmd.Params.MarketTicker != "FED-23DEC-T3.00" {
 			t.Fatalf("expected ticker 'FED-23DEC-T3.00', got %q", cmd.Params.MarketTicker)
@@ -175,25 +179,25 @@ func TestKalshiAdapter_ParseSnapshot(t *testing.T) {
 			t.Fatalf("wrong asset ID: %s", update.AssetID)
 		}
 
-		// Verify bids (YES side), normalised from cents to 0-1.
-		sort.Slice(update.Bids, func(i, j int) bool {
-			return update.Bids[i].Price < update.Bids[j].Price
-		})
+		// Verify bids (YES side), normalised from cents to 0-1, best first.
 		if len(update.Bids) != 2 {
 			t.Fatalf("expected 2 bids, got %d", len(update.Bids))
 		}
-		assertLevel(t, "bid[0]", update.Bids[0], 0.48, 300)
-		assertLevel(t, "bid[1]", update.Bids[1], 0.52, 150)
+		assertLevel(t, "bid[0]", update.Bids[0], 0.52, 150)
+		assertLevel(t, "bid[1]", update.Bids[1], 0.48, 300)
 
-		// Verify asks (NO side), normalised from cents to 0-1.
-		sort.Slice(update.Asks, func(i, j int) bool {
-			return update.Asks[i].Price < update.Asks[j].Price
-		})
+		// Verify asks: NO bids complemented to YES asks, best first.
 		if len(update.Asks) != 2 {
 			t.Fatalf("expected 2 asks, got %d", len(update.Asks))
 		}
-		assertLevel(t, "ask[0]", update.Asks[0], 0.54, 200)
-		assertLevel(t, "ask[1]", update.Asks[1], 0.60, 100)
+		assertLevel(t, "ask[0]", update.Asks[0], 0.40, 100)
+		assertLevel(t, "ask[1]", update.Asks[1], 0.46, 200)
+
+		// The NO-centric view recovers the raw NO bids.
+		no := update.Complement()
+		assertLevel(t, "no bid[0]", no.Bids[0], 0.60, 100)
+		assertLevel(t, "no bid[1]", no.Bids[1], 0.54, 200)
+		assertLevel(t, "no ask[0]", no.Asks[0], 0.48, 150)
 
 	case <-time.After(2 * time.Second):
 		t.Fatal("timed out waiting for BookUpdate")
@@ -267,17 +271,82 @@ func TestKalshiAdapter_ParseDelta(t *testing.T) {
 		// 300 - 100 = 200 remaining at 48 cents.
 		assertLevel(t, "bid[0]", update.Bids[0], 0.48, 200)
 
-		// Asks (NO side) unchanged.
+		// Asks (complemented NO side) unchanged.
 		if len(update.Asks) != 1 {
 			t.Fatalf("expected 1 ask, got %d", len(update.Asks))
 		}
-		assertLevel(t, "ask[0]", update.Asks[0], 0.54, 200)
+		assertLevel(t, "ask[0]", update.Asks[0], 0.46, 200)
+
+		// The delta's exchange time becomes the update timestamp.
+		want := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+		if !update.ExchangeTime.Equal(want) || !update.Timestamp.Equal(want) {
+			t.Fatalf("expected exchange time %v, got %v / %v", want, update.ExchangeTime, update.Timestamp)
+		}
+		if update.Trace.Since(adapter.StageReceived, adapter.StageParsed) <= 0 {
+			t.Fatal("expected received and parsed stages to be marked")
+		}
 
 	case <-time.After(2 * time.Second):
 		t.Fatal("timed out waiting for delta BookUpdate")
 	}
 }
 
+// nopFeed satisfies adapter.Feed for tests that call handleMessage directly.
+type nopFeed struct{}
+
+func (nopFeed) Subscribe() <-chan []byte              { return nil }
+func (nopFeed) SubscribeFrames() <-chan adapter.Frame { return nil }
+func (nopFeed) SendSubscription(string, []byte)       {}
+func (nopFeed) SendCommand(string, []byte)            {}
+func (nopFeed) ForgetSubscription(string)             {}
+
+func (nopFeed) SendSubscriptions([]string, adapter.SubscribeFunc) {}
+func (nopFeed) SendCommands([]string, func([]string) []byte)      {}
+
+func TestKalshiAdapter_ParseTrade(t *testing.T) {
+	ka := New(nopFeed{})
+
+	// Before the first snapshot the market ID is unknown, so the trade is
+	// dropped rather than published unkeyed.
+	ka.handleMessage(adapter.Frame{Received: time.Now(), Data: []byte(`{"type":"trade","sid":3,
+		"msg":{"trade_id":"d91bc705","market_ticker":"FED-23DEC-T3.00","yes_price":36,"no_price":64,
+		"count":1,"taker_side":"yes","ts":1669149840}}`)})
+	select {
+	case tr := <-ka.Trades():
+		t.Fatalf("unexpected trade before snapshot: %+v", tr)
+	default:
+	}
+	if n := ka.UnkeyedTrades(); n != 1 {
+		t.Fatalf("expected 1 unkeyed trade, got %d", n)
+	}
+
+	ka.handleMessage(adapter.Frame{Received: time.Now(), Data: []byte(`{"type":"orderbook_snapshot","sid":2,"seq":1,
+		"msg":{"market_ticker":"FED-23DEC-T3.00","market_id":"9b0f6b43","yes":[[48,300]],"no":[]}}`)})
+	<-ka.Updates()
+
+	ka.handleMessage(adapter.Frame{Received: time.Now(), Data: []byte(`{"type":"trade","sid":3,
+		"msg":{"trade_id":"d91bc706","market_ticker":"FED-23DEC-T3.00","yes_price":36,"no_price":64,
+		"count":136,"taker_side":"no","ts":1669149841}}`)})
+
+	select {
+	case tr := <-ka.Trades():
+		if tr.Exchange != adapter.ExchangeKalshi || tr.AssetID != "FED-23DEC-T3.00" || tr.MarketID != "9b0f6b43" {
+			t.Fatalf("wrong identity: %+v", tr)
+		}
+		if math.Abs(tr.Price-0.36) > 1e-9 || tr.Size != 136 {
+			t.Fatalf("expected 136 @ 0.36, got %v @ %v", tr.Size, tr.Price)
+		}
+		if tr.Side != adapter.SideSell {
+			t.Fatalf("taker buying NO should sell YES, got %q", tr.Side)
+		}
+		if !tr.Timestamp.Equal(time.Unix(1669149841, 0)) {
+			t.Fatalf("wrong timestamp: %v", tr.Timestamp)
+		}
+	default:
+		t.Fatal("expected a Trade")
+	}
+}
+
 func assertLevel(t *testing.T, name string, got adapter.PriceLevel, wantPrice, wantSize float64) {
 	t.Helper()
 	if math.Abs(got.Price-wantPrice) > 1e-9 {
@@ -287,3 +356,667 @@ func assertLevel(t *testing.T, name string, got adapter.PriceLevel, wantPrice, w
 		t.Errorf("%s size: want %f, got %f", name, wantSize, got.Size)
 	}
 }
+
+func TestSigner_ResignsOnEveryDial(t *testing.T) {
+	pemKey, pub := generateTestKey(t)
+	signer, err := NewSigner("test-api-key", pemKey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	clock := time.UnixMilli(1700000000000)
+	signer.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+
+	// The server verifies every handshake and drops the first connection
+	// to force a reconnect.
+	handshakes := make(chan string, 4)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts := r.Header.Get("KALSHI-ACCESS-TIMESTAMP")
+		sig, _ := base64.StdEncoding.DecodeString(r.Header.Get("KALSHI-ACCESS-SIGNATURE"))
+		h := sha256.Sum256([]byte(ts + "GET" + wsPath))
+		if err := rsa.VerifyPSS(pub, crypto.SHA256, h[:], sig, &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+		}); err != nil {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		handshakes <- ts
+		if len(handshakes) == 1 {
+			return
+		}
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	ws := adapter.NewWSClient(DefaultWSConfig(wsURL(srv), signer))
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer ws.Close()
+
+	var stamps []string
+	for len(stamps) < 2 {
+		select {
+		case ts := <-handshakes:
+			stamps = append(stamps, ts)
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for reconnect handshake, got %v", stamps)
+		}
+	}
+	if stamps[0] == stamps[1] {
+		t.Fatalf("reconnect reused timestamp %s", stamps[0])
+	}
+}
+
+// kalshiServer answers every subscribe command with an orderbook_delta
+// ack, a snapshot and one delta for its ticker, numbering subscription IDs
+// per connection from firstSID. drop closes its connections and refuses
+// new ones.
+func kalshiServer(t *testing.T, firstSID int) (srv *httptest.Server, drop func()) {
+	t.Helper()
+	var mu sync.Mutex
+	var conns []*websocket.Conn
+	dead := false
+	upgrader := websocket.Upgrader{}
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if dead {
+			mu.Unlock()
+			http.Error(w, "gone", http.StatusServiceUnavailable)
+			return
+		}
+		mu.Unlock()
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		conns = append(conns, c)
+		mu.Unlock()
+		defer c.Close()
+
+		sid := firstSID
+		for {
+			var cmd command
+			if err := c.ReadJSON(&cmd); err != nil {
+				return
+			}
+			if cmd.Cmd != "subscribe" || cmd.Params.MarketTicker == "" {
+				continue
+			}
+			ticker := cmd.Params.MarketTicker
+			for _, msg := range []string{
+				fmt.Sprintf(`{"id":%d,"type":"subscribed","msg":{"channel":"orderbook_delta","sid":%d}}`, cmd.ID, sid),
+				tickerSnapshot(ticker, sid, 1),
+				tickerDelta(ticker, sid, 2),
+			} {
+				if err := c.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+					return
+				}
+			}
+			sid++
+		}
+	}))
+	drop = func() {
+		mu.Lock()
+		defer mu.Unlock()
+		dead = true
+		for _, c := range conns {
+			c.Close()
+		}
+	}
+	return srv, drop
+}
+
+// TestKalshiAdapter_RedundantFailover runs the adapter over a Failover
+// RedundantFeed whose legs number subscriptions differently. Only the
+// active leg's stream reaches the book, and after it drops the standby
+// replays its subscription and the book follows the new stream without a
+// resync.
+func TestKalshiAdapter_RedundantFailover(t *testing.T) {
+	a, dropA := kalshiServer(t, 1)
+	defer a.Close()
+	b, _ := kalshiServer(t, 5)
+	defer b.Close()
+
+	cfg := adapter.RedundantFeedConfig{Failover: true}
+	for _, srv := range []*httptest.Server{a, b} {
+		leg := adapter.DefaultWSConfig(wsURL(srv))
+		leg.HeartbeatTimeout = 300 * time.Millisecond
+		leg.PingInterval = 50 * time.Millisecond
+		leg.BackoffInitial = 20 * time.Millisecond
+		leg.BackoffMax = 50 * time.Millisecond
+		cfg.Legs = append(cfg.Legs, leg)
+	}
+	rf, err := adapter.NewRedundantFeed(cfg)
+	if err != nil {
+		t.Fatalf("NewRedundantFeed: %v", err)
+	}
+	ka := New(rf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rf.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer rf.Close()
+	go ka.Run(ctx)
+
+	// Snapshot (48 x 300) then delta (-10), once per active stream.
+	expectBook := func(stage string) {
+		t.Helper()
+		for i, want := range []float64{300, 290} {
+			select {
+			case u := <-ka.Updates():
+				if len(u.Bids) == 0 || u.Bids[0].Price != 0.48 || u.Bids[0].Size != want {
+					t.Fatalf("%s: update %d: unexpected bids %+v", stage, i, u.Bids)
+				}
+			case <-ctx.Done():
+				t.Fatalf("%s: timed out waiting for update %d", stage, i)
+			}
+		}
+		select {
+		case u := <-ka.Updates():
+			t.Fatalf("%s: standby leg leaked an update: %+v", stage, u.Bids)
+		case <-time.After(150 * time.Millisecond):
+		}
+	}
+
+	ka.Subscribe("T")
+	expectBook("initial")
+
+	dropA()
+	expectBook("after failover")
+
+	if n := ka.Resyncs(); n != 0 {
+		t.Fatalf("expected no resyncs, got %d", n)
+	}
+	if stats := rf.Stats(); stats[1].Wins == 0 {
+		t.Fatalf("expected the standby leg to deliver after failover, got %+v", stats)
+	}
+}
+
+// captureFeed records what an adapter sends, in order.
+type captureFeed struct {
+	nopFeed
+	mu   sync.Mutex
+	sent []command
+}
+
+func (c *captureFeed) record(data []byte) {
+	var cmd command
+	json.Unmarshal(data, &cmd)
+	c.mu.Lock()
+	c.sent = append(c.sent, cmd)
+	c.mu.Unlock()
+}
+
+func (c *captureFeed) SendSubscription(_ string, data []byte) { c.record(data) }
+func (c *captureFeed) SendCommand(_ string, data []byte)      { c.record(data) }
+
+func (c *captureFeed) SendSubscriptions(keys []string, build adapter.SubscribeFunc) {
+	c.record(build(keys, false))
+}
+
+func (c *captureFeed) SendCommands(keys []string, batch func([]string) []byte) {
+	c.record(batch(keys))
+}
+
+func (c *captureFeed) commands() []command {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]command(nil), c.sent...)
+}
+
+type staleRecorder struct{ markets []string }
+
+func (s *staleRecorder) MarkStale(_ adapter.Exchange, marketID string) {
+	s.markets = append(s.markets, marketID)
+}
+
+func sendKalshi(ka *KalshiAdapter, msg string) {
+	ka.handleMessage(adapter.Frame{Received: time.Now(), Data: []byte(msg)})
+}
+
+func kalshiSnapshot(sid, seq int) string {
+	return fmt.Sprintf(`{"type":"orderbook_snapshot","sid":%d,"seq":%d,
+		"msg":{"market_ticker":"T","market_id":"m1","yes":[[48,300]],"no":[[54,200]]}}`, sid, seq)
+}
+
+func kalshiDelta(sid, seq int) string {
+	return fmt.Sprintf(`{"type":"orderbook_delta","sid":%d,"seq":%d,
+		"msg":{"market_ticker":"T","market_id":"m1","price":48,"delta":-10,"side":"yes"}}`, sid, seq)
+}
+
+func drainUpdates(ka *KalshiAdapter) int {
+	n := 0
+	for {
+		select {
+		case <-ka.Updates():
+			n++
+		default:
+			return n
+		}
+	}
+}
+
+func TestKalshiAdapter_SequenceGapResyncs(t *testing.T) {
+	feed := &captureFeed{}
+	stale := &staleRecorder{}
+	ka := New(feed)
+	ka.SetStaleMarker(stale)
+
+	ka.Subscribe("T")
+	sendKalshi(ka, `{"id":1,"type":"subscribed","msg":{"channel":"orderbook_delta","sid":2}}`)
+	sendKalshi(ka, `{"id":1,"type":"subscribed","msg":{"channel":"trade","sid":3}}`)
+	sendKalshi(ka, kalshiSnapshot(2, 1))
+	sendKalshi(ka, kalshiDelta(2, 2))
+	if n := drainUpdates(ka); n != 2 {
+		t.Fatalf("expected snapshot and delta updates, got %d", n)
+	}
+
+	// seq 3 is lost.
+	sendKalshi(ka, kalshiDelta(2, 4))
+	if n := drainUpdates(ka); n != 0 {
+		t.Fatalf("gap must not emit, got %d updates", n)
+	}
+	if ka.Resyncs() != 1 {
+		t.Fatalf("expected 1 resync, got %d", ka.Resyncs())
+	}
+	if len(stale.markets) != 1 || stale.markets[0] != "m1" {
+		t.Fatalf("expected m1 marked stale, got %v", stale.markets)
+	}
+
+	cmds := feed.commands()
+	if len(cmds) != 3 {
+		t.Fatalf("expected subscribe, unsubscribe, subscribe; got %+v", cmds)
+	}
+	unsub, resub := cmds[1], cmds[2]
+	if unsub.Cmd != "unsubscribe" || len(unsub.Params.SIDs) != 2 || unsub.Params.SIDs[0] != 2 || unsub.Params.SIDs[1] != 3 {
+		t.Fatalf("expected unsubscribe of sids [2 3], got %+v", unsub)
+	}
+	if resub.Cmd != "subscribe" || resub.Params.MarketTicker != "T" || resub.ID <= unsub.ID {
+		t.Fatalf("expected fresh subscribe for T, got %+v", resub)
+	}
+
+	// In-flight deltas are ignored without further resyncs.
+	sendKalshi(ka, kalshiDelta(2, 5))
+	if n := drainUpdates(ka); n != 0 || ka.Resyncs() != 1 {
+		t.Fatalf("expected deltas ignored while resyncing, got %d updates / %d resyncs", n, ka.Resyncs())
+	}
+
+	// The fresh snapshot restores emission on the new sid.
+	sendKalshi(ka, kalshiSnapshot(4, 1))
+	sendKalshi(ka, kalshiDelta(2, 6)) // old sid
+	sendKalshi(ka, kalshiDelta(4, 2))
+	if n := drainUpdates(ka); n != 2 {
+		t.Fatalf("expected snapshot and new-sid delta, got %d updates", n)
+	}
+	if ka.Resyncs() != 1 {
+		t.Fatalf("expected no further resyncs, got %d", ka.Resyncs())
+	}
+}
+
+func TestKalshiAdapter_DuplicateAndEarlyDeltaResync(t *testing.T) {
+	ka := New(&captureFeed{})
+
+	sendKalshi(ka, kalshiDelta(2, 2))
+	if ka.Resyncs() != 1 {
+		t.Fatalf("delta before snapshot should resync, got %d", ka.Resyncs())
+	}
+
+	sendKalshi(ka, kalshiSnapshot(2, 1))
+	sendKalshi(ka, kalshiDelta(2, 2))
+	sendKalshi(ka, kalshiDelta(2, 2))
+	if ka.Resyncs() != 2 {
+		t.Fatalf("duplicate should resync, got %d", ka.Resyncs())
+	}
+	if n := drainUpdates(ka); n != 2 {
+		t.Fatalf("expected only snapshot and first delta, got %d updates", n)
+	}
+}
+
+// haltRecorder implements Halter, recording calls as "halt m1: reason",
+// "resume m1", "halt-exchange: reason" and "resume-exchange".
+type haltRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (h *haltRecorder) record(s string) {
+	h.mu.Lock()
+	h.calls = append(h.calls, s)
+	h.mu.Unlock()
+}
+
+func (h *haltRecorder) HaltMarket(_ adapter.Exchange, marketID, reason string) {
+	h.record("halt " + marketID + ": " + reason)
+}
+
+func (h *haltRecorder) ResumeMarket(_ adapter.Exchange, marketID string) {
+	h.record("resume " + marketID)
+}
+
+func (h *haltRecorder) HaltExchange(_ adapter.Exchange, reason string) {
+	h.record("halt-exchange: " + reason)
+}
+
+func (h *haltRecorder) ResumeExchange(adapter.Exchange) {
+	h.record("resume-exchange")
+}
+
+func (h *haltRecorder) get() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.calls...)
+}
+
+func kalshiLifecycle(ticker, event string, extra string) string {
+	return fmt.Sprintf(`{"type":"market_lifecycle_v2","sid":9,
+		"msg":{"market_ticker":%q,"event_type":%q%s}}`, ticker, event, extra)
+}
+
+func TestKalshiAdapter_LifecycleHalts(t *testing.T) {
+	feed := &captureFeed{}
+	halts := &haltRecorder{}
+	ka := New(feed)
+	ka.SetHalter(halts)
+
+	ka.Subscribe("T")
+	ka.Subscribe("U")
+	cmds := feed.commands()
+	if len(cmds) != 3 || len(cmds[1].Params.Channels) != 1 || cmds[1].Params.Channels[0] != lifecycleChannel ||
+		cmds[1].Params.MarketTicker != "" {
+		t.Fatalf("expected one global lifecycle subscription after the first ticker, got %+v", cmds)
+	}
+
+	// A pause before the first snapshot is applied once the market ID is known.
+	sendKalshi(ka, kalshiLifecycle("T", "deactivated", `,"is_deactivated":true`))
+	if got := halts.get(); len(got) != 0 {
+		t.Fatalf("expected no halt before market ID known, got %v", got)
+	}
+	sendKalshi(ka, kalshiSnapshot(2, 1))
+	sendKalshi(ka, kalshiLifecycle("T", "deactivated", `,"is_deactivated":false`))
+	sendKalshi(ka, kalshiLifecycle("T", "activated", ""))
+	sendKalshi(ka, kalshiLifecycle("T", "determined", `,"result":"yes"`))
+
+	// Unsubscribed tickers are ignored and a future close does not halt yet.
+	sendKalshi(ka, kalshiLifecycle("OTHER", "settled", ""))
+	sendKalshi(ka, kalshiLifecycle("T", "close_date_updated", fmt.Sprintf(`,"close_ts":%d`, time.Now().Add(time.Hour).Unix())))
+
+	want := []string{
+		"halt m1: paused by exchange",
+		"resume m1",
+		"halt m1: closed for settlement: determined yes",
+	}
+	got := halts.get()
+	if len(got) != len(want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("call %d: want %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestKalshiAdapter_LifecycleCloseTime(t *testing.T) {
+	halts := &haltRecorder{}
+	ka := New(nopFeed{})
+	ka.SetHalter(halts)
+	ka.Subscribe("T")
+	sendKalshi(ka, kalshiSnapshot(2, 1))
+
+	// A close announced for later halts the market once it passes, with no
+	// further event from the venue. The frames are received just before
+	// the close so the test need not wait for it.
+	closeTs := time.Now().Add(time.Hour).Unix()
+	closeAt := time.Unix(closeTs, 0)
+	lifecycleAt := func(event string, received time.Time) {
+		ka.handleMessage(adapter.Frame{Received: received,
+			Data: []byte(kalshiLifecycle("T", event, fmt.Sprintf(`,"close_ts":%d`, closeTs)))})
+	}
+	lifecycleAt("created", closeAt.Add(-time.Hour))
+	lifecycleAt("close_date_updated", closeAt.Add(-20*time.Millisecond))
+	if got := halts.get(); len(got) != 0 {
+		t.Fatalf("expected no halt before the close, got %v", got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(halts.get()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := halts.get(); len(got) != 1 || got[0] != "halt m1: closed" {
+		t.Fatalf("expected one close halt, got %v", got)
+	}
+
+	// Reactivation resumes; a close that has already passed halts at once.
+	lifecycleAt("activated", closeAt.Add(-time.Hour))
+	lifecycleAt("close_date_updated", closeAt.Add(time.Second))
+	time.Sleep(20 * time.Millisecond)
+	want := []string{"halt m1: closed", "resume m1", "halt m1: closed"}
+	got := halts.get()
+	if len(got) != len(want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("call %d: want %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+type removeRecorder struct {
+	mu      sync.Mutex
+	markets []string
+}
+
+func (r *removeRecorder) RemoveMarket(_ adapter.Exchange, marketID string) {
+	r.mu.Lock()
+	r.markets = append(r.markets, marketID)
+	r.mu.Unlock()
+}
+
+func (r *removeRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.markets...)
+}
+
+func tickerSnapshot(ticker string, sid, seq int) string {
+	return fmt.Sprintf(`{"type":"orderbook_snapshot","sid":%d,"seq":%d,
+		"msg":{"market_ticker":%q,"market_id":"m-%s","yes":[[48,300]],"no":[[54,200]]}}`, sid, seq, ticker, ticker)
+}
+
+func tickerDelta(ticker string, sid, seq int) string {
+	return fmt.Sprintf(`{"type":"orderbook_delta","sid":%d,"seq":%d,
+		"msg":{"market_ticker":%q,"market_id":"m-%s","price":48,"delta":-10,"side":"yes"}}`, sid, seq, ticker, ticker)
+}
+
+// subscribeBatch subscribes tickers in one command acknowledged with
+// orderbook sid 7 and trade sid 8, and seeds each book.
+func subscribeBatch(t *testing.T, ka *KalshiAdapter, feed *captureFeed, tickers ...string) {
+	t.Helper()
+	ka.SubscribeMany(tickers)
+	cmds := feed.commands()
+	sub := cmds[len(cmds)-1]
+	if sub.Cmd != "subscribe" || strings.Join(sub.Params.MarketTickers, ",") != strings.Join(tickers, ",") {
+		t.Fatalf("expected one multi-market subscribe, got %+v", sub)
+	}
+	sendKalshi(ka, fmt.Sprintf(`{"id":%d,"type":"subscribed","msg":{"channel":"orderbook_delta","sid":7}}`, sub.ID))
+	sendKalshi(ka, fmt.Sprintf(`{"id":%d,"type":"subscribed","msg":{"channel":"trade","sid":8}}`, sub.ID))
+	for i, ticker := range tickers {
+		sendKalshi(ka, tickerSnapshot(ticker, 7, i+1))
+	}
+	drainUpdates(ka)
+}
+
+func TestKalshiAdapter_SubscribeManyAndUnsubscribe(t *testing.T) {
+	feed := &captureFeed{}
+	removed := &removeRecorder{}
+	ka := New(feed)
+	ka.SetMarketRemovers(removed)
+
+	subscribeBatch(t, ka, feed, "A", "B", "C")
+
+	// Deltas for different tickers share the subscription's sequence.
+	sendKalshi(ka, tickerDelta("A", 7, 4))
+	sendKalshi(ka, tickerDelta("B", 7, 5))
+	if n := drainUpdates(ka); n != 2 || ka.Resyncs() != 0 {
+		t.Fatalf("expected 2 updates and no resync, got %d / %d", n, ka.Resyncs())
+	}
+
+	// Removing one ticker of a shared subscription narrows it.
+	ka.Unsubscribe("A")
+	cmds := feed.commands()[1:]
+	if len(cmds) != 2 {
+		t.Fatalf("expected two update_subscription commands, got %+v", cmds)
+	}
+	for i, sid := range []int{7, 8} {
+		c := cmds[i]
+		if c.Cmd != "update_subscription" || c.Params.Action != "delete_markets" ||
+			len(c.Params.SIDs) != 1 || c.Params.SIDs[0] != sid || len(c.Params.MarketTickers) != 1 || c.Params.MarketTickers[0] != "A" {
+			t.Fatalf("command %d: expected delete_markets A on sid %d, got %+v", i, sid, c)
+		}
+	}
+	if got := removed.get(); len(got) != 1 || got[0] != "m-A" {
+		t.Fatalf("expected m-A removed, got %v", got)
+	}
+
+	// In-flight deltas for A are ignored; B carries on.
+	sendKalshi(ka, tickerDelta("A", 7, 6))
+	sendKalshi(ka, tickerDelta("B", 7, 7))
+	if n := drainUpdates(ka); n != 1 || ka.Resyncs() != 0 {
+		t.Fatalf("expected only B's update, got %d / %d resyncs", n, ka.Resyncs())
+	}
+
+	// Acks retire commands.
+	sendKalshi(ka, fmt.Sprintf(`{"id":%d,"sid":7,"seq":8,"type":"ok","msg":{"market_tickers":["B","C"]}}`, cmds[0].ID))
+	sendKalshi(ka, fmt.Sprintf(`{"id":%d,"sid":8,"type":"ok","msg":{"market_tickers":["B","C"]}}`, cmds[1].ID))
+	ka.mu.RLock()
+	_, pending := ka.cmds[cmds[0].ID]
+	ka.mu.RUnlock()
+	if pending {
+		t.Fatal("acknowledged update_subscription still pending")
+	}
+	sendKalshi(ka, tickerDelta("B", 7, 9))
+	if n := drainUpdates(ka); n != 1 || ka.Resyncs() != 0 {
+		t.Fatalf("ok ack should advance the sequence, got %d updates / %d resyncs", n, ka.Resyncs())
+	}
+
+	// Removing the rest unsubscribes the subscription IDs outright.
+	ka.UnsubscribeMany([]string{"B", "C"})
+	cmds = feed.commands()[3:]
+	if len(cmds) != 1 || cmds[0].Cmd != "unsubscribe" || len(cmds[0].Params.SIDs) != 2 {
+		t.Fatalf("expected one unsubscribe of sids [7 8], got %+v", cmds)
+	}
+	if got := removed.get(); len(got) != 3 {
+		t.Fatalf("expected 3 markets removed, got %v", got)
+	}
+
+	// Error replies are counted and retire the command.
+	sendKalshi(ka, fmt.Sprintf(`{"id":%d,"type":"error","msg":{"code":8,"msg":"Unknown sid"}}`, cmds[0].ID))
+	if ka.CommandErrors() != 1 {
+		t.Fatalf("expected 1 command error, got %d", ka.CommandErrors())
+	}
+	ka.mu.RLock()
+	defer ka.mu.RUnlock()
+	if len(ka.books) != 0 || len(ka.sids) != 0 || len(ka.sidTickers) != 0 || len(ka.cmds) != 0 {
+		t.Fatalf("state not purged: books %d sids %d sidTickers %d cmds %d",
+			len(ka.books), len(ka.sids), len(ka.sidTickers), len(ka.cmds))
+	}
+}
+
+func TestKalshiAdapter_SharedSubscriptionGap(t *testing.T) {
+	feed := &captureFeed{}
+	ka := New(feed)
+
+	subscribeBatch(t, ka, feed, "A", "B")
+
+	// seq 3 is lost; it may have belonged to either ticker.
+	sendKalshi(ka, tickerDelta("A", 7, 4))
+	if ka.Resyncs() != 2 {
+		t.Fatalf("expected both tickers resynced, got %d", ka.Resyncs())
+	}
+	var subs []string
+	for _, c := range feed.commands()[1:] {
+		if c.Cmd == "subscribe" {
+			subs = append(subs, c.Params.MarketTicker)
+		}
+	}
+	if strings.Join(subs, ",") != "A,B" {
+		t.Fatalf("expected fresh subscriptions for A and B, got %v", subs)
+	}
+}
+
+func sendKalshiOn(ka *KalshiAdapter, conn uint64, msg string) {
+	ka.handleMessage(adapter.Frame{Received: time.Now(), ConnID: conn, Data: []byte(msg)})
+}
+
+// TestKalshiAdapter_ShardsWithOverlappingSIDs feeds two tickers from two
+// connections that both number their subscriptions from 1, as the shards
+// of a ShardedFeed do. Their sequences must not be mixed up.
+func TestKalshiAdapter_ShardsWithOverlappingSIDs(t *testing.T) {
+	feed := &captureFeed{}
+	ka := New(feed)
+
+	ka.Subscribe("A")
+	ka.Subscribe("B")
+	cmds := feed.commands()
+	sendKalshiOn(ka, 1, fmt.Sprintf(`{"id":%d,"type":"subscribed","msg":{"channel":"orderbook_delta","sid":1}}`, cmds[0].ID))
+	sendKalshiOn(ka, 2, fmt.Sprintf(`{"id":%d,"type":"subscribed","msg":{"channel":"orderbook_delta","sid":1}}`, cmds[1].ID))
+	sendKalshiOn(ka, 1, tickerSnapshot("A", 1, 1))
+	sendKalshiOn(ka, 2, tickerSnapshot("B", 1, 1))
+
+	// Interleaved, each connection's sid 1 runs its own sequence.
+	for seq := 2; seq <= 4; seq++ {
+		sendKalshiOn(ka, 1, tickerDelta("A", 1, seq))
+		sendKalshiOn(ka, 2, tickerDelta("B", 1, seq))
+	}
+	if n := drainUpdates(ka); n != 8 || ka.Resyncs() != 0 {
+		t.Fatalf("expected 8 updates and no resyncs, got %d / %d", n, ka.Resyncs())
+	}
+
+	// A gap on one connection resyncs only the ticker on it.
+	sendKalshiOn(ka, 2, tickerDelta("B", 1, 6))
+	if ka.Resyncs() != 1 {
+		t.Fatalf("expected one resync, got %d", ka.Resyncs())
+	}
+	ka.mu.RLock()
+	_, resyncingA := ka.resyncing["A"]
+	_, resyncingB := ka.resyncing["B"]
+	ka.mu.RUnlock()
+	if resyncingA || !resyncingB {
+		t.Fatalf("expected only B resyncing, got A=%v B=%v", resyncingA, resyncingB)
+	}
+
+	// Unsubscribing A names its sid and leaves B's subscription tracked.
+	ka.Unsubscribe("A")
+	cmds = feed.commands()
+	unsub := cmds[len(cmds)-1]
+	if unsub.Cmd != "unsubscribe" || len(unsub.Params.SIDs) != 1 || unsub.Params.SIDs[0] != 1 {
+		t.Fatalf("expected unsubscribe of sid 1, got %+v", unsub)
+	}
+	ka.mu.RLock()
+	defer ka.mu.RUnlock()
+	if _, ok := ka.sidTickers[subID{conn: 1, sid: 1}]; ok {
+		t.Fatal("A's subscription still tracked")
+	}
+	if ka.sidTickers[subID{conn: 2, sid: 1}]["B"] {
+		t.Fatal("B's old subscription should have been dropped by its resync")
+	}
+}
@@ -0,0 +1,592 @@
+package kalshi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caesar-terminal/caesar/internal/adapter"
+)
+
+// DefaultRESTURL is the production Kalshi trade API base URL.
+const DefaultRESTURL = "https://api.elections.kalshi.com/trade-api/v2"
+
+// Errors returned by RESTClient. An *APIError unwraps to the sentinel that
+// matches its status or error code, so callers can use errors.Is.
+var (
+	ErrNoCredentials       = errors.New("kalshi: signer required")
+	ErrUnauthorized        = errors.New("kalshi: unauthorized")
+	ErrNotFound            = errors.New("kalshi: not found")
+	ErrRateLimited         = errors.New("kalshi: rate limited")
+	ErrInvalidRequest      = errors.New("kalshi: invalid request")
+	ErrInsufficientBalance = errors.New("kalshi: insufficient balance")
+	ErrMarketClosed        = errors.New("kalshi: market closed")
+)
+
+// APIError is a non-2xx response from the trade API.
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("kalshi: HTTP %d %s: %s", e.Status, e.Code, e.Message)
+	}
+	return fmt.Sprintf("kalshi: HTTP %d: %s", e.Status, e.Message)
+}
+
+// Unwrap maps the error to one of the package's sentinel errors, or nil.
+func (e *APIError) Unwrap() error {
+	switch e.Code {
+	case "insufficient_balance":
+		return ErrInsufficientBalance
+	case "market_closed", "market_not_open", "trading_is_paused":
+		return ErrMarketClosed
+	}
+	switch {
+	case e.Status == http.StatusUnauthorized, e.Status == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.Status == http.StatusNotFound:
+		return ErrNotFound
+	case e.Status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.Status == http.StatusBadRequest, e.Status == http.StatusConflict:
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+// RESTConfig holds parameters for a RESTClient.
+type RESTConfig struct {
+	// BaseURL defaults to DefaultRESTURL. Its path is part of every signed
+	// request path.
+	BaseURL string
+
+	// Signer authenticates portfolio requests. Market data works without it.
+	Signer *Signer
+
+	// HTTPClient defaults to a client with a 10s timeout.
+	HTTPClient *http.Client
+
+	// Constraints, if set, receives the tick size of every market fetched
+	// whose market ID MarketIDs knows.
+	Constraints *adapter.ConstraintsRegistry
+
+	// MarketIDs maps tickers to the market IDs KalshiAdapter emits. The
+	// trade API identifies markets only by ticker, while updates, halts and
+	// the orders built from them carry the market ID, so Orderbook and
+	// Constraints key markets by it once known. Normally the KalshiAdapter
+	// itself.
+	MarketIDs MarketIDResolver
+}
+
+// MarketIDResolver maps a ticker to its market ID. Satisfied by
+// KalshiAdapter once the market's first snapshot has arrived.
+type MarketIDResolver interface {
+	MarketID(ticker string) (string, bool)
+}
+
+// RESTClient calls the Kalshi trade API: market and event lookup, order
+// book snapshots, exchange status, order entry, fills, positions and
+// balance. Prices are in cents, as on the wire, except in BookUpdate
+// values.
+type RESTClient struct {
+	cfg      RESTConfig
+	http     *http.Client
+	basePath string
+}
+
+// NewRESTClient creates a RESTClient.
+func NewRESTClient(cfg RESTConfig) (*RESTClient, error) {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultRESTURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	u, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("kalshi: base URL: %w", err)
+	}
+	hc := cfg.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &RESTClient{cfg: cfg, http: hc, basePath: u.Path}, nil
+}
+
+// --- Markets and events ---
+
+// Market is a market's metadata and top of book. Prices are in cents.
+type Market struct {
+	Ticker       string    `json:"ticker"`
+	EventTicker  string    `json:"event_ticker"`
+	Title        string    `json:"title"`
+	Status       string    `json:"status"` // initialized, active, closed, settled, ...
+	YesBid       int       `json:"yes_bid"`
+	YesAsk       int       `json:"yes_ask"`
+	NoBid        int       `json:"no_bid"`
+	NoAsk        int       `json:"no_ask"`
+	LastPrice    int       `json:"last_price"`
+	Volume       int64     `json:"volume"`
+	OpenInterest int64     `json:"open_interest"`
+	TickSize     int       `json:"tick_size"`
+	OpenTime     time.Time `json:"open_time"`
+	CloseTime    time.Time `json:"close_time"`
+}
+
+// Event groups related markets.
+type Event struct {
+	EventTicker       string   `json:"event_ticker"`
+	SeriesTicker      string   `json:"series_ticker"`
+	Title             string   `json:"title"`
+	Category          string   `json:"category"`
+	MutuallyExclusive bool     `json:"mutually_exclusive"`
+	Markets           []Market `json:"-"`
+}
+
+// MarketsFilter narrows a Markets listing. Zero fields are ignored.
+type MarketsFilter struct {
+	EventTicker  string
+	SeriesTicker string
+	Status       string
+	Tickers      []string
+	Limit        int
+	Cursor       string
+}
+
+// Markets lists markets matching f. The returned cursor fetches the next
+// page and is empty on the last one.
+func (c *RESTClient) Markets(ctx context.Context, f MarketsFilter) ([]Market, string, error) {
+	q := url.Values{}
+	setQuery(q, "event_ticker", f.EventTicker)
+	setQuery(q, "series_ticker", f.SeriesTicker)
+	setQuery(q, "status", f.Status)
+	setQuery(q, "tickers", strings.Join(f.Tickers, ","))
+	setQueryInt(q, "limit", int64(f.Limit))
+	setQuery(q, "cursor", f.Cursor)
+
+	var res struct {
+		Markets []Market `json:"markets"`
+		Cursor  string   `json:"cursor"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/markets", q, nil, false, &res); err != nil {
+		return nil, "", err
+	}
+	c.recordConstraints(res.Markets...)
+	return res.Markets, res.Cursor, nil
+}
+
+// Market fetches one market by ticker.
+func (c *RESTClient) Market(ctx context.Context, ticker string) (Market, error) {
+	var res struct {
+		Market Market `json:"market"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/markets/"+url.PathEscape(ticker), nil, nil, false, &res); err != nil {
+		return Market{}, err
+	}
+	c.recordConstraints(res.Market)
+	return res.Market, nil
+}
+
+// Event fetches one event with its markets.
+func (c *RESTClient) Event(ctx context.Context, eventTicker string) (Event, error) {
+	var res struct {
+		Event   Event    `json:"event"`
+		Markets []Market `json:"markets"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/events/"+url.PathEscape(eventTicker), nil, nil, false, &res); err != nil {
+		return Event{}, err
+	}
+	res.Event.Markets = res.Markets
+	c.recordConstraints(res.Markets...)
+	return res.Event, nil
+}
+
+// marketID resolves ticker through cfg.MarketIDs.
+func (c *RESTClient) marketID(ticker string) (string, bool) {
+	if c.cfg.MarketIDs == nil {
+		return "", false
+	}
+	return c.cfg.MarketIDs.MarketID(ticker)
+}
+
+// recordConstraints stores each market's tick size under its market ID;
+// Kalshi orders are whole contracts, so the minimum order size is always
+// one. Markets whose ID is not known yet are skipped and recorded when
+// fetched again after the adapter's first snapshot.
+func (c *RESTClient) recordConstraints(markets ...Market) {
+	for _, m := range markets {
+		id, ok := c.marketID(m.Ticker)
+		if !ok {
+			continue
+		}
+		if m.TickSize > 0 {
+			c.cfg.Constraints.SetTickSize(adapter.ExchangeKalshi, id, float64(m.TickSize)/100)
+		}
+		c.cfg.Constraints.SetMinOrderSize(adapter.ExchangeKalshi, id, 1)
+	}
+}
+
+// Orderbook fetches a market's book as a normalised, YES-centric
+// BookUpdate keyed like KalshiAdapter's: YES bids are Bids and NO bids,
+// complemented, are Asks; MarketID is the market ID from MarketIDs and
+// AssetID the ticker. depth limits levels per side; 0 keeps all. A market
+// the adapter has not snapshotted yet, as when bootstrapping, has no known
+// market ID, so its book's MarketID is the ticker.
+func (c *RESTClient) Orderbook(ctx context.Context, ticker string, depth int) (adapter.BookUpdate, error) {
+	q := url.Values{}
+	setQueryInt(q, "depth", int64(depth))
+
+	var res struct {
+		Orderbook struct {
+			Yes [][2]int `json:"yes"`
+			No  [][2]int `json:"no"`
+		} `json:"orderbook"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/markets/"+url.PathEscape(ticker)+"/orderbook", q, nil, false, &res); err != nil {
+		return adapter.BookUpdate{}, err
+	}
+
+	marketID, ok := c.marketID(ticker)
+	if !ok {
+		marketID = ticker
+	}
+	received := time.Now()
+	update := adapter.BookUpdate{
+		Exchange:  adapter.ExchangeKalshi,
+		MarketID:  marketID,
+		AssetID:   ticker,
+		Bids:      make([]adapter.PriceLevel, 0, len(res.Orderbook.Yes)),
+		Asks:      make([]adapter.PriceLevel, 0, len(res.Orderbook.No)),
+		Timestamp: received,
+	}
+	for _, l := range res.Orderbook.Yes {
+		update.Bids = append(update.Bids, adapter.PriceLevel{Price: float64(l[0]) / 100, Size: float64(l[1])})
+	}
+	for _, l := range res.Orderbook.No {
+		update.Asks = append(update.Asks, adapter.PriceLevel{Price: float64(100-l[0]) / 100, Size: float64(l[1])})
+	}
+	update.Normalize(depth)
+	update.Trace.MarkAt(adapter.StageReceived, received)
+	update.Trace.Mark(adapter.StageParsed)
+	return update, nil
+}
+
+// --- Exchange ---
+
+// ExchangeStatus reports whether the exchange and its trading are up.
+// EstimatedResume is set while trading is down, if known.
+type ExchangeStatus struct {
+	ExchangeActive  bool      `json:"exchange_active"`
+	TradingActive   bool      `json:"trading_active"`
+	EstimatedResume time.Time `json:"-"`
+}
+
+// ExchangeStatus fetches the exchange-wide status.
+func (c *RESTClient) ExchangeStatus(ctx context.Context) (ExchangeStatus, error) {
+	var res struct {
+		ExchangeStatus
+		EstimatedResume string `json:"exchange_estimated_resume_time"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/exchange/status", nil, nil, false, &res); err != nil {
+		return ExchangeStatus{}, err
+	}
+	res.ExchangeStatus.EstimatedResume = parseTimestamp(res.EstimatedResume)
+	return res.ExchangeStatus, nil
+}
+
+// MaintenanceWindow is a scheduled period without trading.
+type MaintenanceWindow struct {
+	Start time.Time `json:"start_datetime"`
+	End   time.Time `json:"end_datetime"`
+}
+
+// MaintenanceWindows fetches the exchange's scheduled maintenance windows.
+func (c *RESTClient) MaintenanceWindows(ctx context.Context) ([]MaintenanceWindow, error) {
+	var res struct {
+		Schedule struct {
+			MaintenanceWindows []MaintenanceWindow `json:"maintenance_windows"`
+		} `json:"schedule"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/exchange/schedule", nil, nil, false, &res); err != nil {
+		return nil, err
+	}
+	return res.Schedule.MaintenanceWindows, nil
+}
+
+// --- Orders ---
+
+// CreateOrderRequest places an order. Side is "yes" or "no", Action "buy"
+// or "sell" and Type "limit" or "market". Set YesPrice or NoPrice (cents)
+// for the side traded.
+type CreateOrderRequest struct {
+	Ticker        string `json:"ticker"`
+	ClientOrderID string `json:"client_order_id,omitempty"`
+	Side          string `json:"side"`
+	Action        string `json:"action"`
+	Count         int    `json:"count"`
+	Type          string `json:"type"`
+	YesPrice      int    `json:"yes_price,omitempty"`
+	NoPrice       int    `json:"no_price,omitempty"`
+	ExpirationTs  int64  `json:"expiration_ts,omitempty"`
+	TimeInForce   string `json:"time_in_force,omitempty"`
+	PostOnly      bool   `json:"post_only,omitempty"`
+	ReduceOnly    bool   `json:"reduce_only,omitempty"`
+}
+
+// AmendOrderRequest changes the price and/or total count of a resting
+// order. Ticker, Side and Action must match the order.
+type AmendOrderRequest struct {
+	Ticker               string `json:"ticker"`
+	Side                 string `json:"side"`
+	Action               string `json:"action"`
+	ClientOrderID        string `json:"client_order_id,omitempty"`
+	UpdatedClientOrderID string `json:"updated_client_order_id,omitempty"`
+	YesPrice             int    `json:"yes_price,omitempty"`
+	NoPrice              int    `json:"no_price,omitempty"`
+	Count                int    `json:"count,omitempty"`
+}
+
+// Order is an order's state as reported by the venue.
+type Order struct {
+	OrderID        string    `json:"order_id"`
+	ClientOrderID  string    `json:"client_order_id"`
+	Ticker         string    `json:"ticker"`
+	Side           string    `json:"side"`
+	Action         string    `json:"action"`
+	Type           string    `json:"type"`
+	Status         string    `json:"status"` // resting, canceled, executed, pending
+	YesPrice       int       `json:"yes_price"`
+	NoPrice        int       `json:"no_price"`
+	FillCount      int       `json:"fill_count"`
+	RemainingCount int       `json:"remaining_count"`
+	CreatedTime    time.Time `json:"created_time"`
+}
+
+type orderResponse struct {
+	Order Order `json:"order"`
+}
+
+// CreateOrder places an order.
+func (c *RESTClient) CreateOrder(ctx context.Context, req CreateOrderRequest) (Order, error) {
+	var res orderResponse
+	if err := c.do(ctx, http.MethodPost, "/portfolio/orders", nil, req, true, &res); err != nil {
+		return Order{}, err
+	}
+	return res.Order, nil
+}
+
+// AmendOrder amends a resting order and returns it as amended.
+func (c *RESTClient) AmendOrder(ctx context.Context, orderID string, req AmendOrderRequest) (Order, error) {
+	var res orderResponse
+	path := "/portfolio/orders/" + url.PathEscape(orderID) + "/amend"
+	if err := c.do(ctx, http.MethodPost, path, nil, req, true, &res); err != nil {
+		return Order{}, err
+	}
+	return res.Order, nil
+}
+
+// CancelOrder cancels a resting order and returns its final state.
+func (c *RESTClient) CancelOrder(ctx context.Context, orderID string) (Order, error) {
+	var res orderResponse
+	if err := c.do(ctx, http.MethodDelete, "/portfolio/orders/"+url.PathEscape(orderID), nil, nil, true, &res); err != nil {
+		return Order{}, err
+	}
+	return res.Order, nil
+}
+
+// --- Portfolio ---
+
+// Fill is one execution of one of the account's orders.
+type Fill struct {
+	TradeID     string    `json:"trade_id"`
+	OrderID     string    `json:"order_id"`
+	Ticker      string    `json:"ticker"`
+	Side        string    `json:"side"`
+	Action      string    `json:"action"`
+	Count       int       `json:"count"`
+	YesPrice    int       `json:"yes_price"`
+	NoPrice     int       `json:"no_price"`
+	IsTaker     bool      `json:"is_taker"`
+	CreatedTime time.Time `json:"created_time"`
+}
+
+// FillsFilter narrows a Fills listing. MinTs and MaxTs are Unix seconds.
+// Zero fields are ignored.
+type FillsFilter struct {
+	Ticker  string
+	OrderID string
+	MinTs   int64
+	MaxTs   int64
+	Limit   int
+	Cursor  string
+}
+
+// Fills lists the account's fills, newest first, with a next-page cursor.
+func (c *RESTClient) Fills(ctx context.Context, f FillsFilter) ([]Fill, string, error) {
+	q := url.Values{}
+	setQuery(q, "ticker", f.Ticker)
+	setQuery(q, "order_id", f.OrderID)
+	setQueryInt(q, "min_ts", f.MinTs)
+	setQueryInt(q, "max_ts", f.MaxTs)
+	setQueryInt(q, "limit", int64(f.Limit))
+	setQuery(q, "cursor", f.Cursor)
+
+	var res struct {
+		Fills  []Fill `json:"fills"`
+		Cursor string `json:"cursor"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/portfolio/fills", q, nil, true, &res); err != nil {
+		return nil, "", err
+	}
+	return res.Fills, res.Cursor, nil
+}
+
+// Position is the account's holding in one market. Position is positive
+// for YES contracts and negative for NO; money fields are in cents.
+type Position struct {
+	Ticker             string `json:"ticker"`
+	Position           int    `json:"position"`
+	MarketExposure     int64  `json:"market_exposure"`
+	RealizedPnL        int64  `json:"realized_pnl"`
+	FeesPaid           int64  `json:"fees_paid"`
+	TotalTraded        int64  `json:"total_traded"`
+	RestingOrdersCount int    `json:"resting_orders_count"`
+}
+
+// PositionsFilter narrows a Positions listing. Zero fields are ignored.
+type PositionsFilter struct {
+	Ticker      string
+	EventTicker string
+	Limit       int
+	Cursor      string
+}
+
+// Positions lists the account's market positions with a next-page cursor.
+func (c *RESTClient) Positions(ctx context.Context, f PositionsFilter) ([]Position, string, error) {
+	q := url.Values{}
+	setQuery(q, "ticker", f.Ticker)
+	setQuery(q, "event_ticker", f.EventTicker)
+	setQueryInt(q, "limit", int64(f.Limit))
+	setQuery(q, "cursor", f.Cursor)
+
+	var res struct {
+		MarketPositions []Position `json:"market_positions"`
+		Cursor          string     `json:"cursor"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/portfolio/positions", q, nil, true, &res); err != nil {
+		return nil, "", err
+	}
+	return res.MarketPositions, res.Cursor, nil
+}
+
+// Balance is the account's cash and portfolio value in cents.
+type Balance struct {
+	Balance        int64 `json:"balance"`
+	PortfolioValue int64 `json:"portfolio_value"`
+}
+
+// Balance fetches the account's balance.
+func (c *RESTClient) Balance(ctx context.Context) (Balance, error) {
+	var res Balance
+	if err := c.do(ctx, http.MethodGet, "/portfolio/balance", nil, nil, true, &res); err != nil {
+		return Balance{}, err
+	}
+	return res, nil
+}
+
+// --- Transport ---
+
+// do sends a request to path, relative to BaseURL, and decodes a JSON
+// response into out. in, if non-nil, is sent as a JSON body. Authenticated
+// requests are signed over the full URL path.
+func (c *RESTClient) do(ctx context.Context, method, path string, q url.Values, in any, auth bool, out any) error {
+	if auth && c.cfg.Signer == nil {
+		return ErrNoCredentials
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("kalshi: encode %s body: %w", path, err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	target := c.cfg.BaseURL + path
+	if len(q) > 0 {
+		target += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if auth {
+		h, err := c.cfg.Signer.Sign(method, c.basePath+path)
+		if err != nil {
+			return err
+		}
+		for k, v := range h {
+			req.Header[k] = v
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("kalshi: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("kalshi: read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return parseAPIError(resp.StatusCode, data)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("kalshi: decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// parseAPIError decodes Kalshi's {"error": {"code", "message"}} body,
+// falling back to the raw body.
+func parseAPIError(status int, data []byte) *APIError {
+	var e struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &e) == nil && (e.Error.Code != "" || e.Error.Message != "") {
+		return &APIError{Status: status, Code: e.Error.Code, Message: e.Error.Message}
+	}
+	return &APIError{Status: status, Message: strings.TrimSpace(string(data))}
+}
+
+func setQuery(q url.Values, key, v string) {
+	if v != "" {
+		q.Set(key, v)
+	}
+}
+
+func setQueryInt(q url.Values, key string, v int64) {
+	if v > 0 {
+		q.Set(key, strconv.FormatInt(v, 10))
+	}
+}
@@ -0,0 +1,258 @@
+package kalshi
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caesar-terminal/caesar/internal/adapter"
+)
+
+// newTestRESTClient serves h under /trade-api/v2 and verifies the RSA-PSS
+// signature of every request that carries one.
+func newTestRESTClient(t *testing.T, h http.HandlerFunc, signed bool, reg *adapter.ConstraintsRegistry) *RESTClient {
+	t.Helper()
+	pemKey, pub := generateTestKey(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sigHeader := r.Header.Get("KALSHI-ACCESS-SIGNATURE"); sigHeader != "" {
+			sig, _ := base64.StdEncoding.DecodeString(sigHeader)
+			digest := sha256.Sum256([]byte(r.Header.Get("KALSHI-ACCESS-TIMESTAMP") + r.Method + r.URL.Path))
+			if err := rsa.VerifyPSS(pub, crypto.SHA256, digest[:], sig, &rsa.PSSOptions{
+				SaltLength: rsa.PSSSaltLengthEqualsHash,
+			}); err != nil {
+				t.Errorf("%s %s: bad signature: %v", r.Method, r.URL.Path, err)
+			}
+		}
+		h(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := RESTConfig{BaseURL: srv.URL + "/trade-api/v2", Constraints: reg}
+	if signed {
+		signer, err := NewSigner("test-api-key", pemKey)
+		if err != nil {
+			t.Fatalf("NewSigner: %v", err)
+		}
+		cfg.Signer = signer
+	}
+	c, err := NewRESTClient(cfg)
+	if err != nil {
+		t.Fatalf("NewRESTClient: %v", err)
+	}
+	return c
+}
+
+func TestSigner_SignAnyPath(t *testing.T) {
+	pemKey, pub := generateTestKey(t)
+	signer, err := NewSigner("test-api-key", pemKey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+
+	h, err := signer.Sign(http.MethodPost, "/trade-api/v2/portfolio/orders?ignored=1")
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	sig, _ := base64.StdEncoding.DecodeString(h.Get("KALSHI-ACCESS-SIGNATURE"))
+	digest := sha256.Sum256([]byte(h.Get("KALSHI-ACCESS-TIMESTAMP") + "POST/trade-api/v2/portfolio/orders"))
+	if err := rsa.VerifyPSS(pub, crypto.SHA256, digest[:], sig, &rsa.PSSOptions{
+		SaltLength: rsa.PSSSaltLengthEqualsHash,
+	}); err != nil {
+		t.Fatalf("signature does not cover method and path: %v", err)
+	}
+}
+
+func TestRESTClient_MarketAndEvent(t *testing.T) {
+	reg := adapter.NewConstraintsRegistry()
+	c := newTestRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/trade-api/v2/markets/KXBTC-25":
+			w.Write([]byte(`{"market":{"ticker":"KXBTC-25","event_ticker":"KXBTC","status":"active",
+				"yes_bid":42,"yes_ask":45,"tick_size":1,"close_time":"2025-12-31T00:00:00Z"}}`))
+		case "/trade-api/v2/events/KXBTC":
+			w.Write([]byte(`{"event":{"event_ticker":"KXBTC","series_ticker":"BTC","mutually_exclusive":true},
+				"markets":[{"ticker":"KXBTC-25","tick_size":1},{"ticker":"KXBTC-26","tick_size":5}]}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}, false, reg)
+	ctx := context.Background()
+
+	m, err := c.Market(ctx, "KXBTC-25")
+	if err != nil {
+		t.Fatalf("Market: %v", err)
+	}
+	if m.YesBid != 42 || m.YesAsk != 45 || m.CloseTime.Year() != 2025 {
+		t.Fatalf("wrong market: %+v", m)
+	}
+
+	ev, err := c.Event(ctx, "KXBTC")
+	if err != nil {
+		t.Fatalf("Event: %v", err)
+	}
+	if !ev.MutuallyExclusive || len(ev.Markets) != 2 {
+		t.Fatalf("wrong event: %+v", ev)
+	}
+	if _, ok := reg.Lookup(adapter.ExchangeKalshi, "KXBTC-26"); ok {
+		t.Fatal("constraints recorded without a market ID")
+	}
+
+	// Once the adapter has seen a snapshot, constraints are keyed by the
+	// market ID its updates and orders carry.
+	ka := New(nopFeed{})
+	sendKalshi(ka, `{"type":"orderbook_snapshot","sid":1,"seq":1,
+		"msg":{"market_ticker":"KXBTC-26","market_id":"m26","yes":[],"no":[]}}`)
+	c.cfg.MarketIDs = ka
+	if _, err := c.Event(ctx, "KXBTC"); err != nil {
+		t.Fatalf("Event: %v", err)
+	}
+	mc, ok := reg.Lookup(adapter.ExchangeKalshi, "m26")
+	if !ok || mc.TickSize != 0.05 || mc.MinOrderSize != 1 {
+		t.Fatalf("constraints not recorded: %+v %v", mc, ok)
+	}
+	if _, ok := reg.Lookup(adapter.ExchangeKalshi, "KXBTC-25"); ok {
+		t.Fatal("constraints recorded by ticker")
+	}
+}
+
+func TestRESTClient_Orderbook(t *testing.T) {
+	c := newTestRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("depth") != "2" {
+			t.Errorf("depth = %q", r.URL.Query().Get("depth"))
+		}
+		w.Write([]byte(`{"orderbook":{"yes":[[40,10],[42,5],[41,3]],"no":[[54,7],[55,2]]}}`))
+	}, false, nil)
+
+	// Before the adapter has seen the market, the book is keyed by ticker.
+	u, err := c.Orderbook(context.Background(), "KXBTC-25", 2)
+	if err != nil {
+		t.Fatalf("Orderbook: %v", err)
+	}
+	if u.MarketID != "KXBTC-25" || u.AssetID != "KXBTC-25" {
+		t.Fatalf("book keyed %s/%s, want the ticker", u.MarketID, u.AssetID)
+	}
+
+	ka := New(nopFeed{})
+	sendKalshi(ka, `{"type":"orderbook_snapshot","sid":1,"seq":1,
+		"msg":{"market_ticker":"KXBTC-25","market_id":"m25","yes":[],"no":[]}}`)
+	c.cfg.MarketIDs = ka
+	u, err = c.Orderbook(context.Background(), "KXBTC-25", 2)
+	if err != nil {
+		t.Fatalf("Orderbook: %v", err)
+	}
+	if u.MarketID != "m25" || u.AssetID != "KXBTC-25" {
+		t.Fatalf("book keyed %s/%s, want the adapter's m25/KXBTC-25", u.MarketID, u.AssetID)
+	}
+	if len(u.Bids) != 2 {
+		t.Fatalf("expected depth 2, got %d bids", len(u.Bids))
+	}
+	assertLevel(t, "best bid", u.Bids[0], 0.42, 5)
+	assertLevel(t, "best ask", u.Asks[0], 0.45, 2)
+	assertLevel(t, "second ask", u.Asks[1], 0.46, 7)
+}
+
+func TestRESTClient_OrderLifecycle(t *testing.T) {
+	c := newTestRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("KALSHI-ACCESS-KEY") != "test-api-key" {
+			t.Errorf("%s %s: unsigned", r.Method, r.URL.Path)
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /trade-api/v2/portfolio/orders":
+			var req CreateOrderRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Ticker != "KXBTC-25" || req.Side != "yes" || req.YesPrice != 42 || req.Count != 10 {
+				t.Errorf("wrong create body: %+v", req)
+			}
+			w.Write([]byte(`{"order":{"order_id":"o-1","status":"resting","yes_price":42,"remaining_count":10}}`))
+		case "POST /trade-api/v2/portfolio/orders/o-1/amend":
+			w.Write([]byte(`{"old_order":{"order_id":"o-1"},"order":{"order_id":"o-1","status":"resting","yes_price":43}}`))
+		case "DELETE /trade-api/v2/portfolio/orders/o-1":
+			w.Write([]byte(`{"order":{"order_id":"o-1","status":"canceled"},"reduced_by":10}`))
+		case "GET /trade-api/v2/portfolio/balance":
+			w.Write([]byte(`{"balance":123456,"portfolio_value":2000}`))
+		case "GET /trade-api/v2/portfolio/positions":
+			w.Write([]byte(`{"market_positions":[{"ticker":"KXBTC-25","position":-3}],"cursor":"next"}`))
+		case "GET /trade-api/v2/portfolio/fills":
+			if r.URL.Query().Get("order_id") != "o-1" {
+				t.Errorf("fills query = %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"fills":[{"trade_id":"t-1","order_id":"o-1","count":2,"yes_price":42,"is_taker":true}]}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}, true, nil)
+	ctx := context.Background()
+
+	o, err := c.CreateOrder(ctx, CreateOrderRequest{
+		Ticker: "KXBTC-25", Side: "yes", Action: "buy", Count: 10, Type: "limit", YesPrice: 42,
+	})
+	if err != nil || o.OrderID != "o-1" || o.Status != "resting" {
+		t.Fatalf("CreateOrder = %+v, %v", o, err)
+	}
+	if o, err = c.AmendOrder(ctx, "o-1", AmendOrderRequest{Ticker: "KXBTC-25", Side: "yes", Action: "buy", YesPrice: 43}); err != nil || o.YesPrice != 43 {
+		t.Fatalf("AmendOrder = %+v, %v", o, err)
+	}
+	if o, err = c.CancelOrder(ctx, "o-1"); err != nil || o.Status != "canceled" {
+		t.Fatalf("CancelOrder = %+v, %v", o, err)
+	}
+
+	fills, _, err := c.Fills(ctx, FillsFilter{OrderID: "o-1"})
+	if err != nil || len(fills) != 1 || !fills[0].IsTaker {
+		t.Fatalf("Fills = %+v, %v", fills, err)
+	}
+	pos, cursor, err := c.Positions(ctx, PositionsFilter{})
+	if err != nil || len(pos) != 1 || pos[0].Position != -3 || cursor != "next" {
+		t.Fatalf("Positions = %+v, %q, %v", pos, cursor, err)
+	}
+	bal, err := c.Balance(ctx)
+	if err != nil || bal.Balance != 123456 {
+		t.Fatalf("Balance = %+v, %v", bal, err)
+	}
+}
+
+func TestRESTClient_TypedErrors(t *testing.T) {
+	c := newTestRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/trade-api/v2/portfolio/orders":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"code":"insufficient_balance","message":"insufficient balance"}}`))
+		case "/trade-api/v2/portfolio/balance":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"code":"authentication_error","message":"bad key"}}`))
+		case "/trade-api/v2/markets/NOPE":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`not found`))
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}, true, nil)
+	ctx := context.Background()
+
+	_, err := c.CreateOrder(ctx, CreateOrderRequest{Ticker: "KXBTC-25"})
+	var apiErr *APIError
+	if !errors.Is(err, ErrInsufficientBalance) || !errors.As(err, &apiErr) || apiErr.Status != 400 {
+		t.Fatalf("CreateOrder error = %v", err)
+	}
+	if _, err := c.Balance(ctx); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Balance error = %v", err)
+	}
+	if _, err := c.Market(ctx, "NOPE"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Market error = %v", err)
+	}
+	if _, _, err := c.Markets(ctx, MarketsFilter{}); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Markets error = %v", err)
+	}
+
+	public, _ := NewRESTClient(RESTConfig{BaseURL: "http://unused"})
+	if _, err := public.Balance(ctx); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("expected ErrNoCredentials, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package kalshi
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/caesar-terminal/caesar/internal/adapter"
+)
+
+// StatusMonitorConfig holds tunable parameters for a StatusMonitor.
+type StatusMonitorConfig struct {
+	// PollInterval is how often exchange status and the maintenance
+	// schedule are fetched. Default: 30s.
+	PollInterval time.Duration
+
+	// MaintenanceLead halts trading this long before a maintenance window
+	// starts, so no order is left resting into it. Default: 1m.
+	MaintenanceLead time.Duration
+}
+
+// StatusMonitor polls Kalshi's exchange status and maintenance schedule and
+// halts the whole exchange on a Halter while trading is down or a window
+// is in progress. A failed poll keeps the previous state.
+type StatusMonitor struct {
+	cfg    StatusMonitorConfig
+	client *RESTClient
+	halter Halter
+
+	reason string // current halt reason; empty while trading. Run-owned.
+
+	now func() time.Time
+}
+
+// NewStatusMonitor creates a StatusMonitor. Call Run to start polling.
+func NewStatusMonitor(cfg StatusMonitorConfig, client *RESTClient, halter Halter) *StatusMonitor {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 30 * time.Second
+	}
+	if cfg.MaintenanceLead <= 0 {
+		cfg.MaintenanceLead = time.Minute
+	}
+	return &StatusMonitor{cfg: cfg, client: client, halter: halter, now: time.Now}
+}
+
+// Run polls immediately and then every PollInterval until ctx is
+// cancelled.
+func (m *StatusMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		m.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the current state and halts or resumes the exchange when
+// the halt reason changes.
+func (m *StatusMonitor) poll(ctx context.Context) {
+	status, err := m.client.ExchangeStatus(ctx)
+	if err != nil {
+		log.Printf("kalshi: exchange status: %v", err)
+		return
+	}
+	// Without the schedule a halt for a window still in force would look
+	// lifted, so a failed fetch keeps the previous state too.
+	windows, err := m.client.MaintenanceWindows(ctx)
+	if err != nil {
+		log.Printf("kalshi: exchange schedule: %v", err)
+		return
+	}
+
+	reason := m.haltReason(status, windows)
+	if reason == m.reason {
+		return
+	}
+	m.reason = reason
+	if reason == "" {
+		log.Printf("kalshi: exchange trading resumed")
+		m.halter.ResumeExchange(adapter.ExchangeKalshi)
+		return
+	}
+	log.Printf("kalshi: exchange halted: %s", reason)
+	m.halter.HaltExchange(adapter.ExchangeKalshi, reason)
+}
+
+// haltReason returns why trading should be halted, or "" if it should not.
+func (m *StatusMonitor) haltReason(status ExchangeStatus, windows []MaintenanceWindow) string {
+	var reason string
+	switch {
+	case !status.ExchangeActive:
+		reason = "exchange inactive"
+	case !status.TradingActive:
+		reason = "trading inactive"
+	}
+	if reason != "" {
+		if !status.EstimatedResume.IsZero() {
+			reason += ", estimated resume " + status.EstimatedResume.UTC().Format(time.RFC3339)
+		}
+		return reason
+	}
+
+	now := m.now()
+	for _, w := range windows {
+		if !now.Before(w.Start.Add(-m.cfg.MaintenanceLead)) && now.Before(w.End) {
+			return "maintenance until " + w.End.UTC().Format(time.RFC3339)
+		}
+	}
+	return ""
+}
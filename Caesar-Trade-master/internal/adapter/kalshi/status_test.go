@@ -0,0 +1,123 @@
+package kalshi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStatusMonitor_HaltsAndResumes(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	var mu sync.Mutex
+	status := `{"exchange_active":true,"trading_active":true}`
+	schedule := `{"schedule":{"maintenance_windows":[]}}`
+	set := func(st, sch string) {
+		mu.Lock()
+		status, schedule = st, sch
+		mu.Unlock()
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/trade-api/v2/exchange/status":
+			w.Write([]byte(status))
+		case "/trade-api/v2/exchange/schedule":
+			w.Write([]byte(schedule))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewRESTClient(RESTConfig{BaseURL: srv.URL + "/trade-api/v2"})
+	if err != nil {
+		t.Fatalf("NewRESTClient: %v", err)
+	}
+	halts := &haltRecorder{}
+	m := NewStatusMonitor(StatusMonitorConfig{MaintenanceLead: 5 * time.Minute}, client, halts)
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	m.poll(ctx)
+	if got := halts.get(); len(got) != 0 {
+		t.Fatalf("expected no calls while trading, got %v", got)
+	}
+
+	// A window starting within the lead time halts; a repeat poll does not
+	// halt again.
+	set(`{"exchange_active":true,"trading_active":true}`,
+		`{"schedule":{"maintenance_windows":[{"start_datetime":"2025-06-01T12:03:00Z","end_datetime":"2025-06-01T13:00:00Z"}]}}`)
+	m.poll(ctx)
+	m.poll(ctx)
+
+	// Trading going down changes the reason.
+	set(`{"exchange_active":true,"trading_active":false,"exchange_estimated_resume_time":"2025-06-01T14:00:00Z"}`,
+		`{"schedule":{"maintenance_windows":[]}}`)
+	m.poll(ctx)
+
+	set(`{"exchange_active":true,"trading_active":true}`, `{"schedule":{"maintenance_windows":[]}}`)
+	m.poll(ctx)
+
+	want := []string{
+		"halt-exchange: maintenance until 2025-06-01T13:00:00Z",
+		"halt-exchange: trading inactive, estimated resume 2025-06-01T14:00:00Z",
+		"resume-exchange",
+	}
+	got := halts.get()
+	if len(got) != len(want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("call %d: want %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestStatusMonitor_ScheduleFailureKeepsHalt(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	var mu sync.Mutex
+	scheduleDown := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/trade-api/v2/exchange/status":
+			w.Write([]byte(`{"exchange_active":true,"trading_active":true}`))
+		case "/trade-api/v2/exchange/schedule":
+			if scheduleDown {
+				http.Error(w, `{"error":{"code":"internal","message":"down"}}`, http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`{"schedule":{"maintenance_windows":[{"start_datetime":"2025-06-01T11:00:00Z","end_datetime":"2025-06-01T13:00:00Z"}]}}`))
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewRESTClient(RESTConfig{BaseURL: srv.URL + "/trade-api/v2"})
+	if err != nil {
+		t.Fatalf("NewRESTClient: %v", err)
+	}
+	halts := &haltRecorder{}
+	m := NewStatusMonitor(StatusMonitorConfig{}, client, halts)
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	m.poll(ctx)
+	mu.Lock()
+	scheduleDown = true
+	mu.Unlock()
+	m.poll(ctx)
+
+	got := halts.get()
+	if len(got) != 1 || got[0] != "halt-exchange: maintenance until 2025-06-01T13:00:00Z" {
+		t.Fatalf("expected the maintenance halt to stand, got %v", got)
+	}
+}
@@ -0,0 +1,185 @@
+package adapter
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Histogram buckets are logarithmic with histSubBuckets per power of two,
+// bounding the quantile error to about 9% from 1µs up to ~18 minutes.
+const (
+	histSubBuckets = 8
+	histMinNanos   = 1000
+	histBuckets    = 30 * histSubBuckets
+)
+
+// histogram is a fixed-size log-bucketed latency histogram.
+type histogram struct {
+	counts [histBuckets]uint64
+	total  uint64
+	max    time.Duration
+}
+
+func histBucket(d time.Duration) int {
+	if d < histMinNanos {
+		return 0
+	}
+	i := int(math.Log2(float64(d)/histMinNanos)*histSubBuckets) + 1
+	if i >= histBuckets {
+		i = histBuckets - 1
+	}
+	return i
+}
+
+// histUpper returns the upper bound of bucket i.
+func histUpper(i int) time.Duration {
+	if i == 0 {
+		return histMinNanos
+	}
+	return time.Duration(histMinNanos * math.Exp2(float64(i)/histSubBuckets))
+}
+
+func (h *histogram) observe(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	h.counts[histBucket(d)]++
+	h.total++
+	if d > h.max {
+		h.max = d
+	}
+}
+
+// quantile returns the upper bound of the bucket holding quantile q,
+// capped at the largest observation.
+func (h *histogram) quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.total)))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			return min(histUpper(i), h.max)
+		}
+	}
+	return h.max
+}
+
+// LatencySummary reports the latency distribution of one stage on one
+// exchange.
+type LatencySummary struct {
+	Exchange Exchange
+	Stage    Stage
+	Count    uint64
+	P50      time.Duration
+	P99      time.Duration
+	Max      time.Duration
+}
+
+type latencyKey struct {
+	Exchange Exchange
+	Stage    Stage
+}
+
+// LatencyTracker keeps p50/p99 histograms per pipeline stage and exchange.
+// Each stage measures the time from the frame being received to that stage,
+// so StageEmitted is the full detection latency. StageReceived instead
+// measures exchange time to receipt where the venue reports one; it
+// compares clocks on two machines and is only as accurate as their sync.
+//
+// All methods are safe on a nil *LatencyTracker, so components can record
+// unconditionally.
+type LatencyTracker struct {
+	mu    sync.Mutex
+	hists map[latencyKey]*histogram
+}
+
+// NewLatencyTracker creates an empty LatencyTracker.
+func NewLatencyTracker() *LatencyTracker {
+	return &LatencyTracker{hists: make(map[latencyKey]*histogram)}
+}
+
+// Observe records one latency sample.
+func (lt *LatencyTracker) Observe(exchange Exchange, stage Stage, d time.Duration) {
+	if lt == nil {
+		return
+	}
+	key := latencyKey{Exchange: exchange, Stage: stage}
+
+	lt.mu.Lock()
+	h, ok := lt.hists[key]
+	if !ok {
+		h = &histogram{}
+		lt.hists[key] = h
+	}
+	h.observe(d)
+	lt.mu.Unlock()
+}
+
+// ObserveStage records the time from receipt to stage for update, if both
+// were marked. For StageReceived it records exchange time to receipt.
+func (lt *LatencyTracker) ObserveStage(update BookUpdate, stage Stage) {
+	if lt == nil {
+		return
+	}
+	from := update.Trace.At(StageReceived)
+	to := update.Trace.At(stage)
+	if stage == StageReceived {
+		from = update.ExchangeTime
+	}
+	if from.IsZero() || to.IsZero() {
+		return
+	}
+	lt.Observe(update.Exchange, stage, to.Sub(from))
+}
+
+// Quantile returns the q-quantile (0–1) latency of stage on exchange, or
+// zero if nothing was observed.
+func (lt *LatencyTracker) Quantile(exchange Exchange, stage Stage, q float64) time.Duration {
+	if lt == nil {
+		return 0
+	}
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	h, ok := lt.hists[latencyKey{Exchange: exchange, Stage: stage}]
+	if !ok {
+		return 0
+	}
+	return h.quantile(q)
+}
+
+// Summary returns p50/p99/max for every observed (exchange, stage), sorted
+// by exchange then pipeline order.
+func (lt *LatencyTracker) Summary() []LatencySummary {
+	if lt == nil {
+		return nil
+	}
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	out := make([]LatencySummary, 0, len(lt.hists))
+	for key, h := range lt.hists {
+		out = append(out, LatencySummary{
+			Exchange: key.Exchange,
+			Stage:    key.Stage,
+			Count:    h.total,
+			P50:      h.quantile(0.50),
+			P99:      h.quantile(0.99),
+			Max:      h.max,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Exchange != out[j].Exchange {
+			return out[i].Exchange < out[j].Exchange
+		}
+		return out[i].Stage < out[j].Stage
+	})
+	return out
+}
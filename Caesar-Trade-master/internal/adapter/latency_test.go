@@ -0,0 +1,100 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLatencyTracker_Quantiles(t *testing.T) {
+	lt := NewLatencyTracker()
+	for i := 1; i <= 100; i++ {
+		lt.Observe(ExchangeKalshi, StageEmitted, time.Duration(i)*time.Millisecond)
+	}
+
+	within := func(name string, got, want time.Duration) {
+		t.Helper()
+		if got < want || float64(got) > float64(want)*1.1 {
+			t.Fatalf("%s: want ~%v (≤10%% over), got %v", name, want, got)
+		}
+	}
+	within("p50", lt.Quantile(ExchangeKalshi, StageEmitted, 0.50), 50*time.Millisecond)
+	within("p99", lt.Quantile(ExchangeKalshi, StageEmitted, 0.99), 99*time.Millisecond)
+
+	sum := lt.Summary()
+	if len(sum) != 1 || sum[0].Count != 100 || sum[0].Max != 100*time.Millisecond {
+		t.Fatalf("unexpected summary: %+v", sum)
+	}
+	if lt.Quantile(ExchangePolymarket, StageEmitted, 0.5) != 0 {
+		t.Fatal("unobserved series should report zero")
+	}
+
+	var nilTracker *LatencyTracker
+	nilTracker.Observe(ExchangeKalshi, StageParsed, time.Millisecond) // must not panic
+}
+
+func TestLatency_PipelineStages(t *testing.T) {
+	lt := NewLatencyTracker()
+
+	poly := newMockProvider()
+	kalshi := newMockProvider()
+	bc := NewBroadcaster()
+	bc.Register(poly)
+	bc.Register(kalshi)
+	bc.SetLatencyTracker(lt)
+
+	ub := NewUnifiedBook(bc, 0)
+	ub.SetLatencyTracker(lt)
+	ub.AddPair(MarketPair{Name: "pair", PolyMarketID: "pm", KalshiMarketID: "km"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go ub.Run(ctx)
+	time.Sleep(20 * time.Millisecond) // let UnifiedBook subscribe
+	go bc.Run(ctx)
+
+	stamp := func(u BookUpdate) BookUpdate {
+		u.Trace.MarkAt(StageReceived, time.Now())
+		u.Trace.Mark(StageParsed)
+		return u
+	}
+	exchTime := time.Now().Add(-30 * time.Millisecond)
+	kalshi.send(stamp(BookUpdate{Exchange: ExchangeKalshi, MarketID: "km",
+		Bids: []PriceLevel{{Price: 0.40}}, Asks: []PriceLevel{{Price: 0.45}}}))
+	time.Sleep(20 * time.Millisecond)
+	poly.send(stamp(BookUpdate{Exchange: ExchangePolymarket, MarketID: "pm", ExchangeTime: exchTime,
+		Bids: []PriceLevel{{Price: 0.50}}, Asks: []PriceLevel{{Price: 0.55}}}))
+
+	var ev ArbitrageEvent
+	select {
+	case ev = <-ub.Events():
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for ArbitrageEvent")
+	}
+
+	// Every stage is marked, in pipeline order.
+	prev := ev.Trace.At(StageReceived)
+	for _, s := range []Stage{StageParsed, StageDistributed, StageApplied, StageEmitted} {
+		at := ev.Trace.At(s)
+		if at.IsZero() || at.Before(prev) {
+			t.Fatalf("stage %s not marked in order: %v after %v", s, at, prev)
+		}
+		prev = at
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	recorded := make(map[Stage]uint64)
+	for _, sum := range lt.Summary() {
+		if sum.Exchange == ExchangePolymarket {
+			recorded[sum.Stage] = sum.Count
+		}
+	}
+	for _, s := range []Stage{StageReceived, StageParsed, StageDistributed, StageApplied, StageEmitted} {
+		if recorded[s] == 0 {
+			t.Fatalf("no latency recorded for polymarket %s: %v", s, recorded)
+		}
+	}
+	if d := lt.Quantile(ExchangePolymarket, StageReceived, 0.5); d < 30*time.Millisecond {
+		t.Fatalf("expected exchange-to-receive latency ≥30ms, got %v", d)
+	}
+}
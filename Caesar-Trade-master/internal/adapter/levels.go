@@ -0,0 +1,103 @@
+package adapter
+
+import (
+	"math"
+	"sort"
+)
+
+// Every BookUpdate leaving an adapter is normalised: bids sorted by price
+// descending, asks ascending, no levels with non-positive size, and at most
+// the adapter's depth cap per side. Consumers may therefore read the best
+// level as Bids[0] / Asks[0] and render ladders without sorting.
+
+// priceEpsilon is the tolerance for matching prices that went through
+// float arithmetic, well below any venue tick.
+const priceEpsilon = 1e-9
+
+// Normalize enforces the BookUpdate level invariant in place: zero-size
+// levels are removed, bids are sorted best (highest) first and asks best
+// (lowest) first, and each side is truncated to depth levels if depth > 0.
+func (u *BookUpdate) Normalize(depth int) {
+	u.Bids = normalizeSide(u.Bids, depth, func(a, b float64) bool { return a > b })
+	u.Asks = normalizeSide(u.Asks, depth, func(a, b float64) bool { return a < b })
+}
+
+func normalizeSide(levels []PriceLevel, depth int, better func(a, b float64) bool) []PriceLevel {
+	out := levels[:0]
+	for _, l := range levels {
+		if l.Size > 0 {
+			out = append(out, l)
+		}
+	}
+	if !sort.SliceIsSorted(out, func(i, j int) bool { return better(out[i].Price, out[j].Price) }) {
+		sort.Slice(out, func(i, j int) bool { return better(out[i].Price, out[j].Price) })
+	}
+	if depth > 0 && len(out) > depth {
+		out = out[:depth]
+	}
+	return out
+}
+
+// BestBid returns the highest bid, or false if there are no bids.
+func (u BookUpdate) BestBid() (PriceLevel, bool) {
+	if len(u.Bids) == 0 {
+		return PriceLevel{}, false
+	}
+	return u.Bids[0], true
+}
+
+// BestAsk returns the lowest ask, or false if there are no asks.
+func (u BookUpdate) BestAsk() (PriceLevel, bool) {
+	if len(u.Asks) == 0 {
+		return PriceLevel{}, false
+	}
+	return u.Asks[0], true
+}
+
+// SizeAt returns the size resting at exactly price in levels, or 0.
+func SizeAt(levels []PriceLevel, price float64) float64 {
+	for _, l := range levels {
+		if math.Abs(l.Price-price) < priceEpsilon {
+			return l.Size
+		}
+	}
+	return 0
+}
+
+// BidSizeThrough returns the total bid size priced at or above price: what
+// a sell order limited at price could fill against.
+func (u BookUpdate) BidSizeThrough(price float64) float64 {
+	var total float64
+	for _, l := range u.Bids {
+		if l.Price < price-priceEpsilon {
+			break
+		}
+		total += l.Size
+	}
+	return total
+}
+
+// AskSizeThrough returns the total ask size priced at or below price: what
+// a buy order limited at price could fill against.
+func (u BookUpdate) AskSizeThrough(price float64) float64 {
+	var total float64
+	for _, l := range u.Asks {
+		if l.Price > price+priceEpsilon {
+			break
+		}
+		total += l.Size
+	}
+	return total
+}
+
+// CumulativeSizes returns the running total of size down a normalised side,
+// best level first, as displayed in a depth ladder.
+func CumulativeSizes(levels []PriceLevel) []float64 {
+	out := make([]float64, len(levels))
+	var total float64
+	for i, l := range levels {
+		total += l.Size
+		out[i] = total
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package adapter
+
+import "testing"
+
+func TestBookUpdate_Normalize(t *testing.T) {
+	u := BookUpdate{
+		Bids: []PriceLevel{{0.48, 30}, {0.50, 0}, {0.52, 10}, {0.45, 5}},
+		Asks: []PriceLevel{{0.60, 15}, {0.55, 25}, {0.58, 0}, {0.70, 1}},
+	}
+	u.Normalize(2)
+
+	wantBids := []PriceLevel{{0.52, 10}, {0.48, 30}}
+	wantAsks := []PriceLevel{{0.55, 25}, {0.60, 15}}
+	if len(u.Bids) != 2 || u.Bids[0] != wantBids[0] || u.Bids[1] != wantBids[1] {
+		t.Fatalf("bids: want %v, got %v", wantBids, u.Bids)
+	}
+	if len(u.Asks) != 2 || u.Asks[0] != wantAsks[0] || u.Asks[1] != wantAsks[1] {
+		t.Fatalf("asks: want %v, got %v", wantAsks, u.Asks)
+	}
+
+	var empty BookUpdate
+	empty.Normalize(0)
+	if _, ok := empty.BestBid(); ok {
+		t.Fatal("empty book should have no best bid")
+	}
+}
+
+func TestBookUpdate_DepthHelpers(t *testing.T) {
+	u := BookUpdate{
+		Bids: []PriceLevel{{0.52, 10}, {0.50, 20}, {0.48, 30}},
+		Asks: []PriceLevel{{0.55, 25}, {0.57, 15}},
+	}
+
+	if b, _ := u.BestBid(); b.Price != 0.52 {
+		t.Fatalf("best bid: want 0.52, got %v", b.Price)
+	}
+	if a, _ := u.BestAsk(); a.Price != 0.55 {
+		t.Fatalf("best ask: want 0.55, got %v", a.Price)
+	}
+	if s := SizeAt(u.Bids, 0.50); s != 20 {
+		t.Fatalf("size at 0.50: want 20, got %v", s)
+	}
+	if s := SizeAt(u.Asks, 0.56); s != 0 {
+		t.Fatalf("size at empty price: want 0, got %v", s)
+	}
+	if s := u.BidSizeThrough(0.50); s != 30 {
+		t.Fatalf("bids through 0.50: want 30, got %v", s)
+	}
+	if s := u.AskSizeThrough(0.60); s != 40 {
+		t.Fatalf("asks through 0.60: want 40, got %v", s)
+	}
+	cum := CumulativeSizes(u.Bids)
+	if len(cum) != 3 || cum[0] != 10 || cum[2] != 60 {
+		t.Fatalf("cumulative bids: got %v", cum)
+	}
+}
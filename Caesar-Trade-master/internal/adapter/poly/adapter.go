@@ -1,20 +1,55 @@
 package poly
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"sort"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/caesar-terminal/caesar/internal/adapter"
 )
 
-// Polymarket market-channel subscription message.
+// Polymarket expects a literal "PING" text message roughly every 10 seconds
+// and answers with "PONG"; silence beyond that closes the socket.
+var (
+	appPing = []byte("PING")
+	appPong = []byte("PONG")
+)
+
+// DefaultWSConfig returns adapter.DefaultWSConfig with Polymarket's
+// application-level heartbeat enabled, so quiet markets keep the
+// connection alive and "PONG" replies never reach the JSON parser.
+func DefaultWSConfig(url string) adapter.WSConfig {
+	cfg := adapter.DefaultWSConfig(url)
+	cfg.AppPing = appPing
+	cfg.AppPingInterval = 10 * time.Second
+	cfg.IsHeartbeat = IsHeartbeat
+	return cfg
+}
+
+// IsHeartbeat reports whether msg is Polymarket's "PONG" heartbeat reply.
+// Pass it as ReplayConfig.IsHeartbeat when replaying a recorded session.
+func IsHeartbeat(msg []byte) bool {
+	return bytes.Equal(msg, appPong)
+}
+
+// resyncTimeout is how long a resync may await its snapshot before it is
+// retried.
+const resyncTimeout = 5 * time.Second
+
+// Polymarket market-channel subscription message. The initial form carries
+// Type "market"; changes to a live subscription carry Operation
+// "subscribe" or "unsubscribe" instead.
 type subscribeMsg struct {
-	Type      string   `json:"type"`
+	Type      string   `json:"type,omitempty"`
 	AssetsIDs []string `json:"assets_ids"`
+	Operation string   `json:"operation,omitempty"`
 }
 
 // Raw Polymarket book event as received over the wire.
@@ -28,6 +63,28 @@ type rawBookEvent struct {
 	Hash      string          `json:"hash"`
 }
 
+// Raw Polymarket last_trade_price event. Side is the taker's side.
+type rawTradeEvent struct {
+	EventType string `json:"event_type"`
+	AssetID   string `json:"asset_id"`
+	Market    string `json:"market"`
+	Price     string `json:"price"`
+	Size      string `json:"size"`
+	Side      string `json:"side"`
+	Timestamp string `json:"timestamp"`
+}
+
+// Raw Polymarket tick_size_change event, sent when a market's price
+// approaches 0 or 1 and the venue refines its tick.
+type rawTickSizeEvent struct {
+	EventType   string `json:"event_type"`
+	AssetID     string `json:"asset_id"`
+	Market      string `json:"market"`
+	OldTickSize string `json:"old_tick_size"`
+	NewTickSize string `json:"new_tick_size"`
+	Timestamp   string `json:"timestamp"`
+}
+
 type rawPriceLevel struct {
 	Price string `json:"price"`
 	Size  string `json:"size"`
@@ -38,30 +95,85 @@ type rawEnvelope struct {
 	EventType string `json:"event_type"`
 }
 
+// dedupFields are the fields that identify an event across connections.
+type dedupFields struct {
+	EventType string `json:"event_type"`
+	AssetID   string `json:"asset_id"`
+	Hash      string `json:"hash"`
+	Timestamp string `json:"timestamp"`
+}
+
+// DedupKey identifies a Polymarket event for adapter.RedundantFeed by its
+// type, asset, book hash and timestamp. Events without a top-level hash
+// (current-format price_change, trades, heartbeats) have no key and are
+// deduplicated by their exact bytes.
+func DedupKey(msg []byte) (string, bool) {
+	var f dedupFields
+	if err := json.Unmarshal(msg, &f); err != nil || f.EventType == "" || f.Hash == "" {
+		return "", false
+	}
+	return f.EventType + "|" + f.AssetID + "|" + f.Hash + "|" + f.Timestamp, true
+}
+
 // PolyAdapter connects to the Polymarket CLOB WebSocket and normalises
-// incoming book snapshots into unified BookUpdate values.
+// incoming book events into unified BookUpdate values. It keeps a local
+// book per asset, seeded by book snapshots and updated by price_change
+// deltas, and emits a fresh BookUpdate after every change.
 type PolyAdapter struct {
-	ws *adapter.WSClient
+	feed adapter.Feed
 
-	// raw is the fan-out channel from WSClient, registered at construction
-	// so no messages are missed between Connect and Run.
-	raw <-chan []byte
+	// frames is the fan-out channel from the feed, registered at
+	// construction so no messages are missed between Connect and Run.
+	frames <-chan adapter.Frame
 
 	// updates receives normalised book updates for downstream consumers.
 	updates chan adapter.BookUpdate
 
+	// trades receives normalised trade prints from last_trade_price events.
+	trades chan adapter.Trade
+
 	// pool reduces GC pressure from high-frequency PriceLevel allocations.
 	levelPool sync.Pool
+
+	// books holds the local book per asset ID. removed holds assets
+	// unsubscribed since their last Subscribe, whose in-flight events are
+	// ignored. resyncing holds assets whose diverged book was dropped,
+	// awaiting a fresh snapshot since the time recorded. All are guarded
+	// by mu.
+	mu        sync.Mutex
+	books     map[string]*localBook
+	removed   map[string]bool
+	resyncing map[string]time.Time
+
+	// removers are told when the last asset of a market is unsubscribed.
+	removers []adapter.MarketRemover
+
+	// divergences counts local books found inconsistent with the venue;
+	// resyncs counts the resubscriptions that replaced them.
+	divergences atomic.Uint64
+	resyncs     atomic.Uint64
+
+	stale StaleMarker // nil disables
+
+	// constraints receives tick size changes; nil discards them.
+	constraints *adapter.ConstraintsRegistry
+
+	// maxDepth caps the levels per side of emitted updates; 0 keeps all.
+	maxDepth int
 }
 
-// New creates a PolyAdapter backed by the given WSClient.
-// It immediately subscribes to the WSClient fan-out so messages arriving
-// before Run() are buffered rather than lost.
-func New(ws *adapter.WSClient) *PolyAdapter {
+// New creates a PolyAdapter backed by the given feed, normally a WSClient or,
+// for offline runs, a Replayer. It immediately subscribes to the feed so
+// messages arriving before Run() are buffered rather than lost.
+func New(feed adapter.Feed) *PolyAdapter {
 	return &PolyAdapter{
-		ws:      ws,
-		raw:     ws.Subscribe(),
-		updates: make(chan adapter.BookUpdate, 1024),
+		feed:      feed,
+		frames:    feed.SubscribeFrames(),
+		updates:   make(chan adapter.BookUpdate, 1024),
+		trades:    make(chan adapter.Trade, 1024),
+		books:     make(map[string]*localBook),
+		removed:   make(map[string]bool),
+		resyncing: make(map[string]time.Time),
 		levelPool: sync.Pool{
 			New: func() any {
 				s := make([]adapter.PriceLevel, 0, 32)
@@ -71,58 +183,191 @@ func New(ws *adapter.WSClient) *PolyAdapter {
 	}
 }
 
+// StaleMarker is told when a market's book can no longer be trusted.
+// Satisfied by adapter.CircuitBreaker.
+type StaleMarker interface {
+	MarkStale(exchange adapter.Exchange, marketID string)
+}
+
 // Updates returns the channel of normalised book updates.
 func (pa *PolyAdapter) Updates() <-chan adapter.BookUpdate {
 	return pa.updates
 }
 
+// SetMaxDepth caps each side of emitted updates to the best n levels. The
+// local books keep full depth. Zero, the default, emits every level. Must
+// be called before Run.
+func (pa *PolyAdapter) SetMaxDepth(n int) {
+	pa.maxDepth = n
+}
+
+// SetConstraints makes the adapter record tick size changes in reg, keyed
+// by market. Must be called before Run.
+func (pa *PolyAdapter) SetConstraints(reg *adapter.ConstraintsRegistry) {
+	pa.constraints = reg
+}
+
+// Trades returns the channel of normalised trade prints.
+func (pa *PolyAdapter) Trades() <-chan adapter.Trade {
+	return pa.trades
+}
+
+// SetStaleMarker makes the adapter report markets whose book diverged from
+// the venue, typically to the CircuitBreaker. Must be called before Run.
+func (pa *PolyAdapter) SetStaleMarker(m StaleMarker) {
+	pa.stale = m
+}
+
+// Divergences returns how many times a local book was found inconsistent
+// with the venue: a snapshot whose hash matched the book's latest state but
+// whose levels did not, or a change whose reported best prices disagreed
+// with the book. A diverged snapshot reseeds the book; a diverged change
+// drops it and resyncs the asset.
+func (pa *PolyAdapter) Divergences() uint64 {
+	return pa.divergences.Load()
+}
+
+// Resyncs returns how many times a book was dropped and resubscribed after
+// a divergence, including retries of resyncs left without a snapshot.
+func (pa *PolyAdapter) Resyncs() uint64 {
+	return pa.resyncs.Load()
+}
+
+// SetMarketRemovers registers components, typically the Broadcaster and
+// CircuitBreaker, to tell when a market's last subscribed asset is
+// unsubscribed. Must be called before Run.
+func (pa *PolyAdapter) SetMarketRemovers(rs ...adapter.MarketRemover) {
+	pa.removers = rs
+}
+
 // Subscribe sends a Polymarket market-channel subscription for the given
-// token ID. Multiple token IDs can be subscribed by calling this repeatedly.
+// token ID. The subscription is replayed automatically after every
+// reconnect.
 func (pa *PolyAdapter) Subscribe(tokenID string) {
-	msg, _ := json.Marshal(subscribeMsg{
-		Type:      "market",
-		AssetsIDs: []string{tokenID},
-	})
-	pa.ws.Send(msg)
+	pa.SubscribeMany([]string{tokenID})
+}
+
+// SubscribeMany subscribes several token IDs with one multi-asset message
+// per connection, which is replayed as one message after a reconnect. On
+// a connection already subscribed the message adds to the subscription.
+func (pa *PolyAdapter) SubscribeMany(tokenIDs []string) {
+	if len(tokenIDs) == 0 {
+		return
+	}
+	pa.mu.Lock()
+	for _, id := range tokenIDs {
+		delete(pa.removed, id)
+	}
+	pa.mu.Unlock()
+
+	pa.feed.SendSubscriptions(tokenIDs, marketMsg)
+}
+
+// Unsubscribe removes a token ID; see UnsubscribeMany.
+func (pa *PolyAdapter) Unsubscribe(tokenID string) {
+	pa.UnsubscribeMany([]string{tokenID})
+}
+
+// UnsubscribeMany stops the given token IDs: it sends an unsubscribe
+// operation on each connection carrying them, drops them from replay and
+// discards their local books. Markets left with no subscribed asset are
+// removed from every MarketRemover.
+func (pa *PolyAdapter) UnsubscribeMany(tokenIDs []string) {
+	if len(tokenIDs) == 0 {
+		return
+	}
+	pa.feed.SendCommands(tokenIDs, unsubscribeMsg)
+	for _, id := range tokenIDs {
+		pa.feed.ForgetSubscription(id)
+	}
+
+	pa.mu.Lock()
+	markets := make(map[string]bool)
+	for _, id := range tokenIDs {
+		pa.removed[id] = true
+		delete(pa.resyncing, id)
+		if book, ok := pa.books[id]; ok {
+			markets[book.market] = true
+			delete(pa.books, id)
+		}
+	}
+	for _, book := range pa.books {
+		delete(markets, book.market)
+	}
+	pa.mu.Unlock()
+
+	for market := range markets {
+		if market == "" {
+			continue
+		}
+		for _, r := range pa.removers {
+			r.RemoveMarket(adapter.ExchangePolymarket, market)
+		}
+	}
 }
 
-// Run reads from the WSClient fan-out channel, parses book events, and
+// marketMsg builds the market-channel message for tokenIDs: the opening
+// message of a connection if initial, else a subscribe operation adding
+// them to the connection's subscription.
+func marketMsg(tokenIDs []string, initial bool) []byte {
+	m := subscribeMsg{AssetsIDs: tokenIDs, Operation: "subscribe"}
+	if initial {
+		m = subscribeMsg{Type: "market", AssetsIDs: tokenIDs}
+	}
+	msg, _ := json.Marshal(m)
+	return msg
+}
+
+// unsubscribeMsg builds the operation removing tokenIDs from a live
+// subscription.
+func unsubscribeMsg(tokenIDs []string) []byte {
+	msg, _ := json.Marshal(subscribeMsg{AssetsIDs: tokenIDs, Operation: "unsubscribe"})
+	return msg
+}
+
+// Run reads from the feed's fan-out channel, parses book events, and
 // pushes BookUpdate values to the updates channel. It blocks until ctx
-// is cancelled.
+// is cancelled or the feed closes.
 func (pa *PolyAdapter) Run(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case raw, ok := <-pa.raw:
+		case f, ok := <-pa.frames:
 			if !ok {
 				return
 			}
-			pa.handleMessage(raw)
+			pa.handleMessage(f)
 		}
 	}
 }
 
-func (pa *PolyAdapter) handleMessage(raw []byte) {
+func (pa *PolyAdapter) handleMessage(f adapter.Frame) {
 	var env rawEnvelope
-	if err := json.Unmarshal(raw, &env); err != nil {
+	if err := json.Unmarshal(f.Data, &env); err != nil {
 		log.Printf("poly: invalid JSON: %v", err)
 		return
 	}
 
 	switch env.EventType {
 	case "book":
-		pa.handleBook(raw)
+		pa.handleBook(f)
+	case "price_change":
+		pa.handlePriceChange(f)
+	case "last_trade_price":
+		pa.handleTrade(f)
+	case "tick_size_change":
+		pa.handleTickSize(f)
 	case "error":
-		log.Printf("poly: exchange error: %s", raw)
+		log.Printf("poly: exchange error: %s", f.Data)
 	default:
-		// price_change, tick_size_change, last_trade_price — ignored for now.
+		// Other event types ignored.
 	}
 }
 
-func (pa *PolyAdapter) handleBook(raw []byte) {
+func (pa *PolyAdapter) handleBook(f adapter.Frame) {
 	var ev rawBookEvent
-	if err := json.Unmarshal(raw, &ev); err != nil {
+	if err := json.Unmarshal(f.Data, &ev); err != nil {
 		log.Printf("poly: failed to parse book event: %v", err)
 		return
 	}
@@ -130,23 +375,243 @@ func (pa *PolyAdapter) handleBook(raw []byte) {
 	bids := pa.parseLevels(ev.Bids)
 	asks := pa.parseLevels(ev.Asks)
 
+	pa.mu.Lock()
+	if pa.removed[ev.AssetID] {
+		pa.mu.Unlock()
+		return
+	}
+	// If the book already reached this snapshot's state via deltas, the
+	// levels must agree; either way the snapshot becomes the new seed.
+	if prev, ok := pa.books[ev.AssetID]; ok && ev.Hash != "" && prev.hash == ev.Hash && !prev.matches(bids, asks) {
+		pa.divergences.Add(1)
+		log.Printf("poly: local book for %s diverged from snapshot %s, reseeding", ev.AssetID, ev.Hash)
+	}
+	pa.books[ev.AssetID] = newLocalBook(ev.Market, bids, asks, ev.Hash)
+	delete(pa.resyncing, ev.AssetID)
+	pa.mu.Unlock()
+
 	ts := parseTimestamp(ev.Timestamp)
 
 	update := adapter.BookUpdate{
+		Exchange:     adapter.ExchangePolymarket,
+		MarketID:     ev.Market,
+		AssetID:      ev.AssetID,
+		Bids:         bids,
+		Asks:         asks,
+		Timestamp:    ts,
+		Hash:         ev.Hash,
+		ExchangeTime: ts,
+	}
+	if ts.IsZero() {
+		update.Timestamp = f.Received
+	}
+	update.Normalize(pa.maxDepth)
+	update.Trace.MarkAt(adapter.StageReceived, f.Received)
+	update.Trace.Mark(adapter.StageParsed)
+
+	pa.emit(update)
+}
+
+// handlePriceChange applies a price_change event to the local books of the
+// assets it touches and emits one BookUpdate per asset. Changes for assets
+// without a snapshot yet are skipped: a book cannot be built from deltas.
+// A book that disagrees with the reported best prices is not emitted but
+// resynced.
+func (pa *PolyAdapter) handlePriceChange(f adapter.Frame) {
+	var ev rawPriceChangeEvent
+	if err := json.Unmarshal(f.Data, &ev); err != nil {
+		log.Printf("poly: failed to parse price_change event: %v", err)
+		return
+	}
+
+	changes := ev.PriceChanges
+	if len(changes) == 0 {
+		// Legacy format: one asset per event, hash at the top level.
+		changes = ev.Changes
+		for i := range changes {
+			changes[i].AssetID = ev.AssetID
+		}
+		if n := len(changes); n > 0 {
+			changes[n-1].Hash = ev.Hash
+		}
+	}
+
+	ts := parseTimestamp(ev.Timestamp)
+
+	// Apply every change first: reported best prices and hashes describe
+	// the book after the whole event, so they are checked per asset once
+	// its changes are in.
+	pa.mu.Lock()
+	var touched []string
+	last := make(map[string]rawPriceChange)
+	resync := make(map[string]string) // asset → reason
+	for _, c := range changes {
+		book, ok := pa.books[c.AssetID]
+		if !ok {
+			if at, busy := pa.resyncing[c.AssetID]; busy && time.Since(at) > resyncTimeout {
+				resync[c.AssetID] = "resync timed out"
+			}
+			continue
+		}
+		if !book.apply(c) {
+			log.Printf("poly: malformed price_change for %s: %+v", c.AssetID, c)
+			continue
+		}
+		if _, seen := last[c.AssetID]; !seen {
+			touched = append(touched, c.AssetID)
+		}
+		last[c.AssetID] = c
+	}
+
+	markets := make(map[string]string, len(touched))
+	for _, assetID := range touched {
+		book, c := pa.books[assetID], last[assetID]
+		book.hash = c.Hash
+		markets[assetID] = ev.Market
+		if ev.Market == "" {
+			markets[assetID] = book.market
+		}
+		if !book.bestMatches(c.BestBid, c.BestAsk) {
+			pa.divergences.Add(1)
+			resync[assetID] = fmt.Sprintf("local book disagrees with reported best %s/%s", c.BestBid, c.BestAsk)
+		}
+	}
+
+	updates := make([]adapter.BookUpdate, 0, len(touched))
+	for _, assetID := range touched {
+		if _, diverged := resync[assetID]; diverged {
+			continue
+		}
+		book := pa.books[assetID]
+		bids, asks := book.levels()
+		market := markets[assetID]
+
+		update := adapter.BookUpdate{
+			Exchange:     adapter.ExchangePolymarket,
+			MarketID:     market,
+			AssetID:      assetID,
+			Bids:         bids,
+			Asks:         asks,
+			Timestamp:    ts,
+			Hash:         book.hash,
+			ExchangeTime: ts,
+		}
+		if ts.IsZero() {
+			update.Timestamp = f.Received
+		}
+		updates = append(updates, update)
+	}
+	pa.mu.Unlock()
+
+	assets := make([]string, 0, len(resync))
+	for assetID := range resync {
+		assets = append(assets, assetID)
+	}
+	sort.Strings(assets)
+	for _, assetID := range assets {
+		market := markets[assetID]
+		if market == "" {
+			market = ev.Market
+		}
+		pa.resync(assetID, market, resync[assetID])
+	}
+
+	for _, update := range updates {
+		update.Normalize(pa.maxDepth)
+		update.Trace.MarkAt(adapter.StageReceived, f.Received)
+		update.Trace.Mark(adapter.StageParsed)
+		pa.emit(update)
+	}
+}
+
+// resync discards assetID's book, marks its market stale and resubscribes
+// the asset on its connection so the venue sends a fresh snapshot. It is
+// unsubscribed first, since a subscribe operation for an asset already
+// subscribed sends nothing new. Changes are ignored until the snapshot
+// arrives.
+func (pa *PolyAdapter) resync(assetID, market, reason string) {
+	log.Printf("poly: %s: %s, resubscribing", assetID, reason)
+	pa.resyncs.Add(1)
+
+	pa.mu.Lock()
+	delete(pa.books, assetID)
+	pa.resyncing[assetID] = time.Now()
+	pa.mu.Unlock()
+
+	if pa.stale != nil && market != "" {
+		pa.stale.MarkStale(adapter.ExchangePolymarket, market)
+	}
+	ids := []string{assetID}
+	pa.feed.SendCommands(ids, unsubscribeMsg)
+	pa.feed.SendCommands(ids, func(ids []string) []byte {
+		return marketMsg(ids, false)
+	})
+}
+
+func (pa *PolyAdapter) emit(update adapter.BookUpdate) {
+	select {
+	case pa.updates <- update:
+	default:
+		log.Printf("poly: updates channel full, dropping book update for %s", update.AssetID)
+	}
+}
+
+// handleTrade converts a last_trade_price event into a Trade.
+func (pa *PolyAdapter) handleTrade(f adapter.Frame) {
+	var ev rawTradeEvent
+	if err := json.Unmarshal(f.Data, &ev); err != nil {
+		log.Printf("poly: failed to parse last_trade_price event: %v", err)
+		return
+	}
+	price, err := strconv.ParseFloat(ev.Price, 64)
+	if err != nil {
+		log.Printf("poly: bad trade price %q for %s", ev.Price, ev.AssetID)
+		return
+	}
+	size, err := strconv.ParseFloat(ev.Size, 64)
+	if err != nil {
+		log.Printf("poly: bad trade size %q for %s", ev.Size, ev.AssetID)
+		return
+	}
+
+	trade := adapter.Trade{
 		Exchange:  adapter.ExchangePolymarket,
 		MarketID:  ev.Market,
 		AssetID:   ev.AssetID,
-		Bids:      bids,
-		Asks:      asks,
-		Timestamp: ts,
-		Hash:      ev.Hash,
+		Price:     price,
+		Size:      size,
+		Timestamp: parseTimestamp(ev.Timestamp),
+	}
+	switch ev.Side {
+	case "BUY":
+		trade.Side = adapter.SideBuy
+	case "SELL":
+		trade.Side = adapter.SideSell
+	}
+	if trade.Timestamp.IsZero() {
+		trade.Timestamp = f.Received
 	}
 
 	select {
-	case pa.updates <- update:
+	case pa.trades <- trade:
 	default:
-		log.Printf("poly: updates channel full, dropping book update for %s", ev.AssetID)
+		log.Printf("poly: trades channel full, dropping trade for %s", ev.AssetID)
+	}
+}
+
+// handleTickSize records a market's new tick size.
+func (pa *PolyAdapter) handleTickSize(f adapter.Frame) {
+	var ev rawTickSizeEvent
+	if err := json.Unmarshal(f.Data, &ev); err != nil {
+		log.Printf("poly: failed to parse tick_size_change event: %v", err)
+		return
+	}
+	tick, err := strconv.ParseFloat(ev.NewTickSize, 64)
+	if err != nil || tick <= 0 {
+		log.Printf("poly: bad tick size %q for %s", ev.NewTickSize, ev.Market)
+		return
 	}
+	pa.constraints.SetTickSize(adapter.ExchangePolymarket, ev.Market, tick)
 }
 
 // parseLevels converts raw string price/size pairs into PriceLevel slices.
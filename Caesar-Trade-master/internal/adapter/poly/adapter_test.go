@@ -1,12 +1,15 @@
 package poly
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"math"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -80,6 +83,89 @@ func TestPolyAdapter_SubscriptionMessage(t *testing.T) {
 	}
 }
 
+// sessionServer records every message with the index of the connection
+// it arrived on. drop closes the current connection.
+func sessionServer(t *testing.T) (srv *httptest.Server, msgs <-chan string, drop func()) {
+	t.Helper()
+	out := make(chan string, 16)
+	var mu sync.Mutex
+	var current *websocket.Conn
+	conns := 0
+	upgrader := websocket.Upgrader{}
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		mu.Lock()
+		current = c
+		conns++
+		n := conns
+		mu.Unlock()
+		for {
+			_, msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			out <- fmt.Sprintf("%d %s", n, msg)
+		}
+	}))
+	return srv, out, func() {
+		mu.Lock()
+		current.Close()
+		mu.Unlock()
+	}
+}
+
+func TestPolyAdapter_LiveAdditionsAndReplay(t *testing.T) {
+	srv, msgs, drop := sessionServer(t)
+	defer srv.Close()
+
+	cfg := adapter.DefaultWSConfig(wsURL(srv))
+	cfg.HeartbeatTimeout = 5 * time.Second
+	ws := adapter.NewWSClient(cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer ws.Close()
+
+	expect := func(want ...string) {
+		t.Helper()
+		for _, w := range want {
+			select {
+			case got := <-msgs:
+				if got != w {
+					t.Fatalf("want %s, got %s", w, got)
+				}
+			case <-ctx.Done():
+				t.Fatalf("timed out waiting for %s", w)
+			}
+		}
+	}
+
+	// The first subscription opens the market channel; later ones are
+	// subscribe operations on the open connection.
+	pa := New(ws)
+	pa.Subscribe("a")
+	pa.SubscribeMany([]string{"b", "c"})
+	expect(`1 {"type":"market","assets_ids":["a"]}`,
+		`1 {"assets_ids":["b","c"],"operation":"subscribe"}`)
+
+	// A reconnect replays them the same way.
+	drop()
+	expect(`2 {"type":"market","assets_ids":["a"]}`,
+		`2 {"assets_ids":["b","c"],"operation":"subscribe"}`)
+
+	// With the opening subscription gone, the next one opens the replay.
+	pa.Unsubscribe("a")
+	expect(`2 {"assets_ids":["a"],"operation":"unsubscribe"}`)
+	drop()
+	expect(`3 {"type":"market","assets_ids":["b","c"]}`)
+}
+
 func TestPolyAdapter_ParseBookEvent(t *testing.T) {
 	// Spin up an echo-like server that sends a canned book event.
 	bookJSON := `{
@@ -144,12 +230,12 @@ func TestPolyAdapter_ParseBookEvent(t *testing.T) {
 			t.Fatalf("wrong hash: %s", update.Hash)
 		}
 
-		// Verify bids.
+		// Verify bids, best (highest) first regardless of wire order.
 		if len(update.Bids) != 2 {
 			t.Fatalf("expected 2 bids, got %d", len(update.Bids))
 		}
-		assertLevel(t, "bid[0]", update.Bids[0], 0.48, 30)
-		assertLevel(t, "bid[1]", update.Bids[1], 0.49, 20)
+		assertLevel(t, "bid[0]", update.Bids[0], 0.49, 20)
+		assertLevel(t, "bid[1]", update.Bids[1], 0.48, 30)
 
 		// Verify asks.
 		if len(update.Asks) != 2 {
@@ -178,3 +264,433 @@ func assertLevel(t *testing.T, name string, got adapter.PriceLevel, wantPrice, w
 		t.Errorf("%s size: want %f, got %f", name, wantSize, got.Size)
 	}
 }
+
+func TestPolyAdapter_ReplayRecordedSession(t *testing.T) {
+	bookJSON := `{"event_type":"book","asset_id":"tok-1","market":"0xm",` +
+		`"bids":[{"price":".48","size":"30"}],"asks":[{"price":".52","size":"25"}],` +
+		`"timestamp":"1700000000000","hash":"0x1"}`
+
+	// Record a live session: a book event followed by a heartbeat reply.
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		c.WriteMessage(websocket.TextMessage, []byte(bookJSON))
+		c.WriteMessage(websocket.TextMessage, appPong)
+		select {}
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	rec, err := adapter.NewRecorder(&buf)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	cfg := DefaultWSConfig(wsURL(srv))
+	cfg.Recorder = rec
+	ws := adapter.NewWSClient(cfg)
+	live := New(ws)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	go live.Run(ctx)
+
+	var want adapter.BookUpdate
+	select {
+	case want = <-live.Updates():
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for live BookUpdate")
+	}
+	time.Sleep(50 * time.Millisecond) // let the heartbeat frame be recorded
+	ws.Close()
+	rec.Close()
+
+	// Replay the recording through a fresh adapter at max speed.
+	rp, err := adapter.NewReplayer(&buf, adapter.ReplayConfig{IsHeartbeat: IsHeartbeat})
+	if err != nil {
+		t.Fatalf("NewReplayer: %v", err)
+	}
+	replayed := New(rp)
+	replayed.Subscribe("tok-1")
+	if _, ok := rp.Sent("tok-1"); !ok {
+		t.Fatal("subscription not captured by replayer")
+	}
+
+	go rp.Run(ctx)
+	replayed.Run(ctx) // returns once the recording is exhausted
+
+	select {
+	case got := <-replayed.Updates():
+		if got.AssetID != want.AssetID || got.Hash != want.Hash || !got.Timestamp.Equal(want.Timestamp) {
+			t.Fatalf("replayed update differs: want %+v, got %+v", want, got)
+		}
+		assertLevel(t, "bid[0]", got.Bids[0], 0.48, 30)
+		assertLevel(t, "ask[0]", got.Asks[0], 0.52, 25)
+	default:
+		t.Fatal("replay produced no BookUpdate")
+	}
+	select {
+	case extra := <-replayed.Updates():
+		t.Fatalf("unexpected extra update: %+v", extra)
+	default:
+	}
+}
+
+func TestDedupKey(t *testing.T) {
+	a := []byte(`{"event_type":"book","asset_id":"tok","hash":"0x1","timestamp":"1700000000000","bids":[]}`)
+	b := []byte(`{"asset_id":"tok","event_type":"book","timestamp":"1700000000000","hash":"0x1"}`)
+	c := []byte(`{"event_type":"book","asset_id":"tok","hash":"0x2","timestamp":"1700000000000"}`)
+
+	ka, ok := DedupKey(a)
+	if !ok {
+		t.Fatal("expected key for book event")
+	}
+	if kb, _ := DedupKey(b); kb != ka {
+		t.Fatalf("same event should share a key: %q vs %q", ka, kb)
+	}
+	if kc, _ := DedupKey(c); kc == ka {
+		t.Fatal("different hash should yield a different key")
+	}
+	if _, ok := DedupKey(appPong); ok {
+		t.Fatal("heartbeat should have no key")
+	}
+	pc := []byte(`{"event_type":"price_change","market":"0xm","timestamp":"1700000000000","price_changes":[]}`)
+	if _, ok := DedupKey(pc); ok {
+		t.Fatal("price_change without a top-level hash should have no key")
+	}
+}
+
+// nopFeed satisfies adapter.Feed for tests that call handleMessage directly.
+type nopFeed struct{}
+
+func (nopFeed) Subscribe() <-chan []byte              { return nil }
+func (nopFeed) SubscribeFrames() <-chan adapter.Frame { return nil }
+func (nopFeed) SendSubscription(string, []byte)       {}
+func (nopFeed) SendCommand(string, []byte)            {}
+func (nopFeed) ForgetSubscription(string)             {}
+
+func (nopFeed) SendSubscriptions([]string, adapter.SubscribeFunc) {}
+func (nopFeed) SendCommands([]string, func([]string) []byte)      {}
+
+func feedFrame(pa *PolyAdapter, msg string) {
+	pa.handleMessage(adapter.Frame{Received: time.Now(), Data: []byte(msg)})
+}
+
+func nextUpdate(t *testing.T, pa *PolyAdapter) adapter.BookUpdate {
+	t.Helper()
+	select {
+	case u := <-pa.Updates():
+		return u
+	default:
+		t.Fatal("expected a BookUpdate")
+		return adapter.BookUpdate{}
+	}
+}
+
+const seedBook = `{"event_type":"book","asset_id":"tok","market":"0xm",
+	"bids":[{"price":".48","size":"30"},{"price":".49","size":"20"}],
+	"asks":[{"price":".52","size":"25"},{"price":".53","size":"60"}],
+	"timestamp":"1700000000000","hash":"0x1"}`
+
+func TestPolyAdapter_PriceChangeUpdatesBook(t *testing.T) {
+	pa := New(nopFeed{})
+	feedFrame(pa, seedBook)
+	nextUpdate(t, pa)
+
+	// Current format: new best bid, ask level removed.
+	feedFrame(pa, `{"event_type":"price_change","market":"0xm","timestamp":"1700000000500",
+		"price_changes":[
+			{"asset_id":"tok","price":"0.50","size":"10","side":"BUY","hash":"0x2","best_bid":"0.50","best_ask":"0.53"},
+			{"asset_id":"tok","price":"0.52","size":"0","side":"SELL","hash":"0x3","best_bid":"0.50","best_ask":"0.53"}
+		]}`)
+
+	u := nextUpdate(t, pa)
+	if u.AssetID != "tok" || u.MarketID != "0xm" {
+		t.Fatalf("wrong ids: %s/%s", u.AssetID, u.MarketID)
+	}
+	if u.Hash != "0x3" {
+		t.Fatalf("expected hash of last change, got %q", u.Hash)
+	}
+	if !u.ExchangeTime.Equal(time.UnixMilli(1700000000500)) {
+		t.Fatalf("wrong exchange time: %v", u.ExchangeTime)
+	}
+	if len(u.Bids) != 3 || len(u.Asks) != 1 {
+		t.Fatalf("expected 3 bids / 1 ask, got %d / %d", len(u.Bids), len(u.Asks))
+	}
+	assertLevel(t, "bid[0]", u.Bids[0], 0.50, 10)
+	assertLevel(t, "bid[2]", u.Bids[2], 0.48, 30)
+	assertLevel(t, "ask[0]", u.Asks[0], 0.53, 60)
+	select {
+	case extra := <-pa.Updates():
+		t.Fatalf("expected one update per asset, got extra %+v", extra)
+	default:
+	}
+
+	// Legacy format resizes a level in place.
+	feedFrame(pa, `{"event_type":"price_change","asset_id":"tok","market":"0xm","hash":"0x4",
+		"timestamp":"1700000000600","changes":[{"price":"0.53","size":"5","side":"SELL"}]}`)
+	u = nextUpdate(t, pa)
+	if u.Hash != "0x4" {
+		t.Fatalf("expected legacy hash, got %q", u.Hash)
+	}
+	assertLevel(t, "ask[0]", u.Asks[0], 0.53, 5)
+
+	if n := pa.Divergences(); n != 0 {
+		t.Fatalf("expected no divergences, got %d", n)
+	}
+}
+
+func TestPolyAdapter_PriceChangeWithoutSnapshotIgnored(t *testing.T) {
+	pa := New(nopFeed{})
+	feedFrame(pa, `{"event_type":"price_change","market":"0xm","timestamp":"1700000000500",
+		"price_changes":[{"asset_id":"tok","price":"0.50","size":"10","side":"BUY","hash":"0x2"}]}`)
+	select {
+	case u := <-pa.Updates():
+		t.Fatalf("unexpected update without snapshot: %+v", u)
+	default:
+	}
+}
+
+type staleRecorder []string
+
+func (r *staleRecorder) MarkStale(ex adapter.Exchange, marketID string) {
+	*r = append(*r, string(ex)+"/"+marketID)
+}
+
+func noUpdate(t *testing.T, pa *PolyAdapter, why string) {
+	t.Helper()
+	select {
+	case u := <-pa.Updates():
+		t.Fatalf("unexpected update %s: %+v", why, u)
+	default:
+	}
+}
+
+func TestPolyAdapter_PriceChangeDivergence(t *testing.T) {
+	feed := &batchFeed{}
+	pa := New(feed)
+	var stale staleRecorder
+	pa.SetStaleMarker(&stale)
+	feedFrame(pa, seedBook)
+	nextUpdate(t, pa)
+
+	// Reported best bid disagrees with the book: the book is dropped, not
+	// emitted, and the asset resubscribed for a fresh snapshot.
+	feedFrame(pa, `{"event_type":"price_change","market":"0xm","timestamp":"1700000000500",
+		"price_changes":[{"asset_id":"tok","price":"0.40","size":"10","side":"BUY","hash":"0x2","best_bid":"0.50","best_ask":"0.52"}]}`)
+	noUpdate(t, pa, "from a diverged book")
+	if n := pa.Divergences(); n != 1 {
+		t.Fatalf("expected 1 divergence after best-price mismatch, got %d", n)
+	}
+	if n := pa.Resyncs(); n != 1 {
+		t.Fatalf("expected 1 resync, got %d", n)
+	}
+	want := []string{
+		`{"assets_ids":["tok"],"operation":"unsubscribe"}`,
+		`{"assets_ids":["tok"],"operation":"subscribe"}`,
+	}
+	if len(feed.sent) != len(want) || string(feed.sent[0]) != want[0] || string(feed.sent[1]) != want[1] {
+		t.Fatalf("expected resubscription %q, got %q", want, feed.sent)
+	}
+	if len(stale) != 1 || stale[0] != "polymarket/0xm" {
+		t.Fatalf("expected 0xm marked stale, got %v", stale)
+	}
+
+	// Changes are ignored until the snapshot arrives.
+	feedFrame(pa, `{"event_type":"price_change","market":"0xm","timestamp":"1700000000550",
+		"price_changes":[{"asset_id":"tok","price":"0.49","size":"0","side":"BUY","hash":"0x3"}]}`)
+	noUpdate(t, pa, "while resyncing")
+
+	feedFrame(pa, `{"event_type":"book","asset_id":"tok","market":"0xm",
+		"bids":[{"price":".49","size":"20"}],"asks":[{"price":".52","size":"25"}],
+		"timestamp":"1700000000600","hash":"0x4"}`)
+	u := nextUpdate(t, pa)
+	if len(u.Bids) != 1 {
+		t.Fatalf("snapshot should reseed the book, got %d bids", len(u.Bids))
+	}
+
+	// Deltas now apply to the reseeded book.
+	feedFrame(pa, `{"event_type":"price_change","market":"0xm","timestamp":"1700000000700",
+		"price_changes":[{"asset_id":"tok","price":"0.49","size":"0","side":"BUY","hash":"0x5"}]}`)
+	u = nextUpdate(t, pa)
+	if len(u.Bids) != 0 || len(u.Asks) != 1 {
+		t.Fatalf("expected 0 bids / 1 ask after reseed, got %d / %d", len(u.Bids), len(u.Asks))
+	}
+
+	// A snapshot with the book's current hash but different levels
+	// reseeds it.
+	feedFrame(pa, `{"event_type":"book","asset_id":"tok","market":"0xm",
+		"bids":[{"price":".47","size":"5"}],"asks":[{"price":".52","size":"25"}],
+		"timestamp":"1700000000800","hash":"0x5"}`)
+	u = nextUpdate(t, pa)
+	if n := pa.Divergences(); n != 2 {
+		t.Fatalf("expected 2 divergences after hash mismatch, got %d", n)
+	}
+	assertLevel(t, "bid[0]", u.Bids[0], 0.47, 5)
+}
+
+func TestPolyAdapter_ResyncRetriesWithoutSnapshot(t *testing.T) {
+	feed := &batchFeed{}
+	pa := New(feed)
+	feedFrame(pa, seedBook)
+	nextUpdate(t, pa)
+
+	feedFrame(pa, `{"event_type":"price_change","market":"0xm","timestamp":"1700000000500",
+		"price_changes":[{"asset_id":"tok","price":"0.40","size":"10","side":"BUY","hash":"0x2","best_bid":"0.50","best_ask":"0.52"}]}`)
+	pa.mu.Lock()
+	pa.resyncing["tok"] = time.Now().Add(-resyncTimeout - time.Second)
+	pa.mu.Unlock()
+
+	feedFrame(pa, `{"event_type":"price_change","market":"0xm","timestamp":"1700000000600",
+		"price_changes":[{"asset_id":"tok","price":"0.40","size":"0","side":"BUY","hash":"0x3"}]}`)
+	noUpdate(t, pa, "while resyncing")
+	if n := pa.Resyncs(); n != 2 {
+		t.Fatalf("expected the resync retried, got %d resyncs", n)
+	}
+	if len(feed.sent) != 4 {
+		t.Fatalf("expected two resubscriptions, got %q", feed.sent)
+	}
+}
+
+func TestPolyAdapter_ParseTrade(t *testing.T) {
+	pa := New(nopFeed{})
+	feedFrame(pa, `{"event_type":"last_trade_price","asset_id":"tok","market":"0xm","fee_rate_bps":"0",
+		"price":"0.456","side":"BUY","size":"219.217767","timestamp":"1750428146322"}`)
+
+	select {
+	case tr := <-pa.Trades():
+		if tr.Exchange != adapter.ExchangePolymarket || tr.AssetID != "tok" || tr.MarketID != "0xm" {
+			t.Fatalf("wrong identity: %+v", tr)
+		}
+		if math.Abs(tr.Price-0.456) > 1e-9 || math.Abs(tr.Size-219.217767) > 1e-9 {
+			t.Fatalf("expected 219.217767 @ 0.456, got %v @ %v", tr.Size, tr.Price)
+		}
+		if tr.Side != adapter.SideBuy {
+			t.Fatalf("expected buy, got %q", tr.Side)
+		}
+		if !tr.Timestamp.Equal(time.UnixMilli(1750428146322)) {
+			t.Fatalf("wrong timestamp: %v", tr.Timestamp)
+		}
+	default:
+		t.Fatal("expected a Trade")
+	}
+	select {
+	case u := <-pa.Updates():
+		t.Fatalf("trade should not produce a book update: %+v", u)
+	default:
+	}
+}
+
+func TestPolyAdapter_TickSizeChange(t *testing.T) {
+	reg := adapter.NewConstraintsRegistry()
+	pa := New(nopFeed{})
+	pa.SetConstraints(reg)
+
+	feedFrame(pa, `{"event_type":"tick_size_change","asset_id":"tok","market":"0xm",
+		"old_tick_size":"0.01","new_tick_size":"0.001","timestamp":"1700000000000"}`)
+
+	c, ok := reg.Lookup(adapter.ExchangePolymarket, "0xm")
+	if !ok || c.TickSize != 0.001 {
+		t.Fatalf("expected tick 0.001, got %+v (found %v)", c, ok)
+	}
+}
+
+func TestPolyAdapter_MaxDepth(t *testing.T) {
+	pa := New(nopFeed{})
+	pa.SetMaxDepth(1)
+	feedFrame(pa, seedBook)
+	u := nextUpdate(t, pa)
+	if len(u.Bids) != 1 || len(u.Asks) != 1 {
+		t.Fatalf("expected 1 level per side, got %d / %d", len(u.Bids), len(u.Asks))
+	}
+	assertLevel(t, "bid[0]", u.Bids[0], 0.49, 20)
+	assertLevel(t, "ask[0]", u.Asks[0], 0.52, 25)
+
+	// The local book keeps full depth: removing the best bid exposes 0.48.
+	feedFrame(pa, `{"event_type":"price_change","market":"0xm","timestamp":"1700000000500",
+		"price_changes":[{"asset_id":"tok","price":"0.49","size":"0","side":"BUY","hash":"0x2"}]}`)
+	u = nextUpdate(t, pa)
+	assertLevel(t, "bid[0]", u.Bids[0], 0.48, 30)
+}
+
+// batchFeed records batched subscriptions and commands.
+type batchFeed struct {
+	nopFeed
+	sent      [][]byte
+	forgotten []string
+}
+
+func (f *batchFeed) SendSubscriptions(keys []string, build adapter.SubscribeFunc) {
+	f.sent = append(f.sent, build(keys, len(f.sent) == 0))
+}
+
+func (f *batchFeed) SendCommands(keys []string, batch func([]string) []byte) {
+	f.sent = append(f.sent, batch(keys))
+}
+
+func (f *batchFeed) ForgetSubscription(key string) { f.forgotten = append(f.forgotten, key) }
+
+type removeRecorder []string
+
+func (r *removeRecorder) RemoveMarket(ex adapter.Exchange, marketID string) {
+	*r = append(*r, string(ex)+"/"+marketID)
+}
+
+func TestPolyAdapter_SubscribeManyAndUnsubscribe(t *testing.T) {
+	feed := &batchFeed{}
+	pa := New(feed)
+	var removed removeRecorder
+	pa.SetMarketRemovers(&removed)
+
+	pa.SubscribeMany([]string{"yes", "no"})
+	if len(feed.sent) != 1 || string(feed.sent[0]) != `{"type":"market","assets_ids":["yes","no"]}` {
+		t.Fatalf("unexpected subscribe: %q", feed.sent)
+	}
+
+	for _, id := range []string{"yes", "no"} {
+		feedFrame(pa, `{"event_type":"book","asset_id":"`+id+`","market":"0xm",
+			"bids":[{"price":".48","size":"30"}],"asks":[{"price":".52","size":"25"}],
+			"timestamp":"1700000000000","hash":"0x1"}`)
+		nextUpdate(t, pa)
+	}
+
+	// The market keeps one asset, so nobody is told to remove it.
+	pa.Unsubscribe("yes")
+	if got := string(feed.sent[1]); got != `{"assets_ids":["yes"],"operation":"unsubscribe"}` {
+		t.Fatalf("unexpected unsubscribe: %s", got)
+	}
+	if len(feed.forgotten) != 1 || feed.forgotten[0] != "yes" {
+		t.Fatalf("expected yes dropped from replay, got %v", feed.forgotten)
+	}
+	if len(removed) != 0 {
+		t.Fatalf("expected no removal yet, got %v", removed)
+	}
+
+	// Events already in flight for the removed asset are discarded.
+	feedFrame(pa, `{"event_type":"book","asset_id":"yes","market":"0xm",
+		"bids":[],"asks":[],"timestamp":"1700000000100","hash":"0x2"}`)
+	feedFrame(pa, `{"event_type":"price_change","market":"0xm","timestamp":"1700000000200",
+		"price_changes":[{"asset_id":"yes","price":"0.50","size":"10","side":"BUY","hash":"0x3"}]}`)
+	select {
+	case u := <-pa.Updates():
+		t.Fatalf("unexpected update for removed asset: %+v", u)
+	default:
+	}
+
+	pa.Unsubscribe("no")
+	if len(removed) != 1 || removed[0] != "polymarket/0xm" {
+		t.Fatalf("expected 0xm removed once, got %v", removed)
+	}
+
+	// Subscribing again lifts the filter.
+	pa.Subscribe("yes")
+	feedFrame(pa, `{"event_type":"book","asset_id":"yes","market":"0xm",
+		"bids":[],"asks":[],"timestamp":"1700000000300","hash":"0x4"}`)
+	nextUpdate(t, pa)
+}
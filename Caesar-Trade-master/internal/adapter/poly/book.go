@@ -0,0 +1,164 @@
+package poly
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/caesar-terminal/caesar/internal/adapter"
+)
+
+// Raw Polymarket price_change event. Current feeds send one event per
+// market with a price_changes entry per asset, each carrying the asset's
+// post-change book hash and best prices. Older feeds sent one event per
+// asset with a changes list and a single hash.
+type rawPriceChangeEvent struct {
+	EventType    string           `json:"event_type"`
+	Market       string           `json:"market"`
+	AssetID      string           `json:"asset_id"`
+	Timestamp    string           `json:"timestamp"`
+	Hash         string           `json:"hash"`
+	PriceChanges []rawPriceChange `json:"price_changes"`
+	Changes      []rawPriceChange `json:"changes"`
+}
+
+type rawPriceChange struct {
+	AssetID string `json:"asset_id"`
+	Price   string `json:"price"`
+	Size    string `json:"size"`
+	Side    string `json:"side"` // BUY = bid, SELL = ask
+	Hash    string `json:"hash"`
+	BestBid string `json:"best_bid"`
+	BestAsk string `json:"best_ask"`
+}
+
+// localBook is the adapter's running view of one asset's order book,
+// seeded by a book snapshot and mutated by price_change deltas. Sizes are
+// absolute: a change replaces the size at its price, and zero removes it.
+type localBook struct {
+	market string
+	bids   map[float64]float64 // price → size
+	asks   map[float64]float64
+
+	// hash is the venue hash of the state this book should now match: the
+	// snapshot's, or the latest change's. Empty if a change had none.
+	hash string
+}
+
+func newLocalBook(market string, bids, asks []adapter.PriceLevel, hash string) *localBook {
+	b := &localBook{
+		market: market,
+		bids:   make(map[float64]float64, len(bids)),
+		asks:   make(map[float64]float64, len(asks)),
+		hash:   hash,
+	}
+	for _, l := range bids {
+		if l.Size > 0 {
+			b.bids[l.Price] = l.Size
+		}
+	}
+	for _, l := range asks {
+		if l.Size > 0 {
+			b.asks[l.Price] = l.Size
+		}
+	}
+	return b
+}
+
+// apply sets the size at one price level. It returns false for a change
+// it cannot parse.
+func (b *localBook) apply(c rawPriceChange) bool {
+	price, err := strconv.ParseFloat(c.Price, 64)
+	if err != nil {
+		return false
+	}
+	size, err := strconv.ParseFloat(c.Size, 64)
+	if err != nil {
+		return false
+	}
+
+	var side map[float64]float64
+	switch c.Side {
+	case "BUY":
+		side = b.bids
+	case "SELL":
+		side = b.asks
+	default:
+		return false
+	}
+	if size == 0 {
+		delete(side, price)
+	} else {
+		side[price] = size
+	}
+	return true
+}
+
+// matches reports whether the book holds exactly the given levels, ignoring
+// zero-size ones.
+func (b *localBook) matches(bids, asks []adapter.PriceLevel) bool {
+	return sideMatches(b.bids, bids) && sideMatches(b.asks, asks)
+}
+
+func sideMatches(side map[float64]float64, levels []adapter.PriceLevel) bool {
+	n := 0
+	for _, l := range levels {
+		if l.Size <= 0 {
+			continue
+		}
+		if size, ok := side[l.Price]; !ok || size != l.Size {
+			return false
+		}
+		n++
+	}
+	return n == len(side)
+}
+
+// bestMatches checks the book's top of book against the best prices the
+// venue reported with a change. Empty strings are not checked.
+func (b *localBook) bestMatches(bestBid, bestAsk string) bool {
+	check := func(reported string, side map[float64]float64, better func(a, b float64) bool) bool {
+		if reported == "" {
+			return true
+		}
+		want, err := strconv.ParseFloat(reported, 64)
+		if err != nil {
+			return true
+		}
+		best, ok := bestOf(side, better)
+		if !ok {
+			return want == 0
+		}
+		return best == want
+	}
+	return check(bestBid, b.bids, func(a, b float64) bool { return a > b }) &&
+		check(bestAsk, b.asks, func(a, b float64) bool { return a < b })
+}
+
+func bestOf(side map[float64]float64, better func(a, b float64) bool) (float64, bool) {
+	var best float64
+	found := false
+	for p := range side {
+		if !found || better(p, best) {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+// levels returns the book as PriceLevel slices, bids highest first and
+// asks lowest first.
+func (b *localBook) levels() (bids, asks []adapter.PriceLevel) {
+	bids = toLevels(b.bids)
+	sort.Slice(bids, func(i, j int) bool { return bids[i].Price > bids[j].Price })
+	asks = toLevels(b.asks)
+	sort.Slice(asks, func(i, j int) bool { return asks[i].Price < asks[j].Price })
+	return bids, asks
+}
+
+func toLevels(side map[float64]float64) []adapter.PriceLevel {
+	out := make([]adapter.PriceLevel, 0, len(side))
+	for p, s := range side {
+		out = append(out, adapter.PriceLevel{Price: p, Size: s})
+	}
+	return out
+}
@@ -0,0 +1,360 @@
+package poly
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caesar-terminal/caesar/internal/adapter"
+)
+
+// DefaultRESTURL is the production Polymarket CLOB REST endpoint.
+const DefaultRESTURL = "https://clob.polymarket.com"
+
+// ErrNoCredentials is returned by authenticated calls on a client created
+// without API credentials.
+var ErrNoCredentials = errors.New("poly: API credentials required")
+
+// APIError is a non-2xx response from the CLOB API.
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("poly: HTTP %d: %s", e.Status, e.Message)
+}
+
+// Credentials are the L2 API-key credentials derived for a wallet. Secret
+// is the base64 (URL alphabet) HMAC key returned at key creation.
+type Credentials struct {
+	Address    string // wallet address the key belongs to
+	APIKey     string
+	Secret     string
+	Passphrase string
+}
+
+// RESTConfig holds parameters for a RESTClient.
+type RESTConfig struct {
+	// BaseURL defaults to DefaultRESTURL.
+	BaseURL string
+
+	// Credentials enable order entry. Public endpoints work without them.
+	Credentials *Credentials
+
+	// HTTPClient defaults to a client with a 10s timeout.
+	HTTPClient *http.Client
+
+	// Constraints, if set, receives the tick size and minimum order size
+	// of every book and market fetched.
+	Constraints *adapter.ConstraintsRegistry
+}
+
+// RESTClient calls the Polymarket CLOB REST API: book snapshots and market
+// metadata, plus order placement and cancellation with L2 HMAC headers.
+type RESTClient struct {
+	cfg    RESTConfig
+	http   *http.Client
+	secret []byte // decoded Credentials.Secret
+	now    func() time.Time
+}
+
+// NewRESTClient creates a RESTClient. It fails if the credentials' secret
+// is not base64.
+func NewRESTClient(cfg RESTConfig) (*RESTClient, error) {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultRESTURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	hc := cfg.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: 10 * time.Second}
+	}
+	c := &RESTClient{cfg: cfg, http: hc, now: time.Now}
+	if cfg.Credentials != nil {
+		secret, err := base64.URLEncoding.DecodeString(cfg.Credentials.Secret)
+		if err != nil {
+			// Keys are sometimes stored with the standard alphabet.
+			if secret, err = base64.StdEncoding.DecodeString(cfg.Credentials.Secret); err != nil {
+				return nil, errors.New("poly: credentials secret is not base64")
+			}
+		}
+		c.secret = secret
+	}
+	return c, nil
+}
+
+// --- Books and markets ---
+
+// rawRESTBook is the /book response: a book event without event_type, plus
+// the market's order limits.
+type rawRESTBook struct {
+	rawBookEvent
+	TickSize     string `json:"tick_size"`
+	MinOrderSize string `json:"min_order_size"`
+	NegRisk      bool   `json:"neg_risk"`
+}
+
+// Book fetches the current book of one token as a normalised BookUpdate,
+// for bootstrapping or resyncing a market. Hash identifies the snapshot.
+func (c *RESTClient) Book(ctx context.Context, tokenID string) (adapter.BookUpdate, error) {
+	var raw rawRESTBook
+	q := url.Values{"token_id": {tokenID}}
+	if err := c.do(ctx, http.MethodGet, "/book?"+q.Encode(), nil, false, &raw); err != nil {
+		return adapter.BookUpdate{}, err
+	}
+
+	received := c.now()
+	ts := parseTimestamp(raw.Timestamp)
+	update := adapter.BookUpdate{
+		Exchange:     adapter.ExchangePolymarket,
+		MarketID:     raw.Market,
+		AssetID:      raw.AssetID,
+		Bids:         parseRawLevels(raw.Bids),
+		Asks:         parseRawLevels(raw.Asks),
+		Timestamp:    ts,
+		Hash:         raw.Hash,
+		ExchangeTime: ts,
+	}
+	if ts.IsZero() {
+		update.Timestamp = received
+	}
+	update.Normalize(0)
+	update.Trace.MarkAt(adapter.StageReceived, received)
+	update.Trace.Mark(adapter.StageParsed)
+
+	c.recordConstraints(raw.Market, parseFloat(raw.TickSize), parseFloat(raw.MinOrderSize))
+	return update, nil
+}
+
+// Token is one outcome token of a market.
+type Token struct {
+	TokenID string  `json:"token_id"`
+	Outcome string  `json:"outcome"`
+	Price   float64 `json:"price"`
+	Winner  bool    `json:"winner"`
+}
+
+// Market is a market's metadata as needed for subscription and order
+// entry.
+type Market struct {
+	ConditionID  string  `json:"condition_id"`
+	QuestionID   string  `json:"question_id"`
+	Question     string  `json:"question"`
+	Tokens       []Token `json:"tokens"`
+	TickSize     float64 `json:"minimum_tick_size"`
+	MinOrderSize float64 `json:"minimum_order_size"`
+	NegRisk      bool    `json:"neg_risk"`
+	Active       bool    `json:"active"`
+	Closed       bool    `json:"closed"`
+}
+
+// Market fetches the metadata of one market by condition ID.
+func (c *RESTClient) Market(ctx context.Context, conditionID string) (Market, error) {
+	var m Market
+	if err := c.do(ctx, http.MethodGet, "/markets/"+url.PathEscape(conditionID), nil, false, &m); err != nil {
+		return Market{}, err
+	}
+	c.recordConstraints(m.ConditionID, m.TickSize, m.MinOrderSize)
+	return m, nil
+}
+
+func (c *RESTClient) recordConstraints(marketID string, tick, minSize float64) {
+	c.cfg.Constraints.SetTickSize(adapter.ExchangePolymarket, marketID, tick)
+	c.cfg.Constraints.SetMinOrderSize(adapter.ExchangePolymarket, marketID, minSize)
+}
+
+// --- Orders ---
+
+// OrderType is the time-in-force of a posted order.
+type OrderType string
+
+const (
+	OrderGTC OrderType = "GTC" // good till cancelled
+	OrderGTD OrderType = "GTD" // good till the order's expiration
+	OrderFOK OrderType = "FOK" // fill or kill
+	OrderFAK OrderType = "FAK" // fill and kill the remainder
+)
+
+// SignedOrder is a CTF Exchange order with its EIP-712 signature, as
+// produced by the signer service's SignOrder. Amounts and IDs are decimal
+// strings in raw units; Signature is the 0x-prefixed hex signature.
+type SignedOrder struct {
+	Salt          int64  `json:"salt"`
+	Maker         string `json:"maker"`
+	Signer        string `json:"signer"`
+	Taker         string `json:"taker"`
+	TokenID       string `json:"tokenId"`
+	MakerAmount   string `json:"makerAmount"`
+	TakerAmount   string `json:"takerAmount"`
+	Expiration    string `json:"expiration"`
+	Nonce         string `json:"nonce"`
+	FeeRateBps    string `json:"feeRateBps"`
+	Side          string `json:"side"`          // "BUY" or "SELL"
+	SignatureType int    `json:"signatureType"` // 0 EOA, 1 POLY_PROXY, 2 POLY_GNOSIS_SAFE
+	Signature     string `json:"signature"`
+}
+
+type postOrderRequest struct {
+	Order     SignedOrder `json:"order"`
+	Owner     string      `json:"owner"`
+	OrderType OrderType   `json:"orderType"`
+}
+
+// OrderResult is the venue's response to a posted order.
+type OrderResult struct {
+	Success     bool     `json:"success"`
+	ErrorMsg    string   `json:"errorMsg"`
+	OrderID     string   `json:"orderID"`
+	Status      string   `json:"status"` // live, matched, delayed, unmatched
+	OrderHashes []string `json:"orderHashes"`
+}
+
+// PostOrder submits a signed order. A response the venue marks as
+// unsuccessful is returned as an *APIError.
+func (c *RESTClient) PostOrder(ctx context.Context, order SignedOrder, typ OrderType) (OrderResult, error) {
+	if c.cfg.Credentials == nil {
+		return OrderResult{}, ErrNoCredentials
+	}
+	body, err := json.Marshal(postOrderRequest{Order: order, Owner: c.cfg.Credentials.APIKey, OrderType: typ})
+	if err != nil {
+		return OrderResult{}, fmt.Errorf("poly: encode order: %w", err)
+	}
+
+	var res OrderResult
+	if err := c.do(ctx, http.MethodPost, "/order", body, true, &res); err != nil {
+		return OrderResult{}, err
+	}
+	if !res.Success {
+		return res, &APIError{Status: http.StatusOK, Message: res.ErrorMsg}
+	}
+	return res, nil
+}
+
+type cancelResponse struct {
+	Canceled    []string          `json:"canceled"`
+	NotCanceled map[string]string `json:"not_canceled"`
+}
+
+// CancelOrder cancels one open order. An order the venue refuses to cancel
+// is reported as an *APIError carrying its reason.
+func (c *RESTClient) CancelOrder(ctx context.Context, orderID string) error {
+	if c.cfg.Credentials == nil {
+		return ErrNoCredentials
+	}
+	body, _ := json.Marshal(map[string]string{"orderID": orderID})
+
+	var res cancelResponse
+	if err := c.do(ctx, http.MethodDelete, "/order", body, true, &res); err != nil {
+		return err
+	}
+	if reason, ok := res.NotCanceled[orderID]; ok {
+		return &APIError{Status: http.StatusOK, Message: reason}
+	}
+	return nil
+}
+
+// --- Transport ---
+
+// do sends a request to path (which may carry a query string) and decodes
+// a JSON response into out. Authenticated requests carry L2 headers.
+func (c *RESTClient) do(ctx context.Context, method, path string, body []byte, auth bool, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if auth {
+		signPath, _, _ := strings.Cut(path, "?")
+		for k, v := range c.l2Headers(method, signPath, body) {
+			req.Header[k] = v
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("poly: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("poly: read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{Status: resp.StatusCode, Message: errorMessage(data)}
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("poly: decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// l2Headers signs a request with the API secret: base64url(HMAC-SHA256(
+// secret, timestamp + method + path + body)), timestamp in Unix seconds.
+func (c *RESTClient) l2Headers(method, path string, body []byte) http.Header {
+	creds := c.cfg.Credentials
+	ts := strconv.FormatInt(c.now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(ts + method + path))
+	mac.Write(body)
+
+	h := http.Header{}
+	h.Set("POLY_ADDRESS", creds.Address)
+	h.Set("POLY_API_KEY", creds.APIKey)
+	h.Set("POLY_PASSPHRASE", creds.Passphrase)
+	h.Set("POLY_TIMESTAMP", ts)
+	h.Set("POLY_SIGNATURE", base64.URLEncoding.EncodeToString(mac.Sum(nil)))
+	return h
+}
+
+// errorMessage extracts the "error" field of an error body, falling back to
+// the raw body.
+func errorMessage(data []byte) string {
+	var e struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &e) == nil && e.Error != "" {
+		return e.Error
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// parseRawLevels converts wire levels without the adapter's pool; REST
+// snapshots are too infrequent to need it.
+func parseRawLevels(raw []rawPriceLevel) []adapter.PriceLevel {
+	out := make([]adapter.PriceLevel, 0, len(raw))
+	for _, r := range raw {
+		p, err := strconv.ParseFloat(r.Price, 64)
+		if err != nil {
+			continue
+		}
+		s, err := strconv.ParseFloat(r.Size, 64)
+		if err != nil {
+			continue
+		}
+		out = append(out, adapter.PriceLevel{Price: p, Size: s})
+	}
+	return out
+}
+
+// parseFloat parses s, returning 0 if it is empty or malformed.
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
@@ -0,0 +1,190 @@
+package poly
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/caesar-terminal/caesar/internal/adapter"
+)
+
+var testCreds = &Credentials{
+	Address:    "0xabc",
+	APIKey:     "key-1",
+	Secret:     base64.URLEncoding.EncodeToString([]byte("super-secret")),
+	Passphrase: "pass",
+}
+
+func newTestRESTClient(t *testing.T, h http.HandlerFunc, creds *Credentials, reg *adapter.ConstraintsRegistry) *RESTClient {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	c, err := NewRESTClient(RESTConfig{BaseURL: srv.URL, Credentials: creds, Constraints: reg})
+	if err != nil {
+		t.Fatalf("NewRESTClient: %v", err)
+	}
+	c.now = func() time.Time { return time.Unix(1700000000, 0) }
+	return c
+}
+
+func TestRESTClient_Book(t *testing.T) {
+	reg := adapter.NewConstraintsRegistry()
+	c := newTestRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/book" || r.URL.Query().Get("token_id") != "tok-1" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Write([]byte(`{"market":"0xcond","asset_id":"tok-1","timestamp":"1700000000123","hash":"0xh",
+			"bids":[{"price":"0.40","size":"10"},{"price":"0.45","size":"5"}],
+			"asks":[{"price":"0.55","size":"7"},{"price":"0.50","size":"0"}],
+			"tick_size":"0.001","min_order_size":"5","neg_risk":false}`))
+	}, nil, reg)
+
+	u, err := c.Book(context.Background(), "tok-1")
+	if err != nil {
+		t.Fatalf("Book: %v", err)
+	}
+	if u.MarketID != "0xcond" || u.AssetID != "tok-1" || u.Hash != "0xh" {
+		t.Fatalf("wrong identity: %+v", u)
+	}
+	if len(u.Bids) != 2 || u.Bids[0].Price != 0.45 {
+		t.Fatalf("bids not best first: %+v", u.Bids)
+	}
+	if len(u.Asks) != 1 || u.Asks[0].Price != 0.55 {
+		t.Fatalf("zero-size ask not dropped: %+v", u.Asks)
+	}
+	if got := u.ExchangeTime.UnixMilli(); got != 1700000000123 {
+		t.Fatalf("exchange time = %d", got)
+	}
+
+	mc, ok := reg.Lookup(adapter.ExchangePolymarket, "0xcond")
+	if !ok || mc.TickSize != 0.001 || mc.MinOrderSize != 5 {
+		t.Fatalf("constraints not recorded: %+v %v", mc, ok)
+	}
+}
+
+func TestRESTClient_Market(t *testing.T) {
+	reg := adapter.NewConstraintsRegistry()
+	c := newTestRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/markets/0xcond" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"condition_id":"0xcond","question":"Will it?","neg_risk":true,
+			"minimum_tick_size":0.01,"minimum_order_size":15,"active":true,
+			"tokens":[{"token_id":"yes-1","outcome":"Yes","price":0.6},{"token_id":"no-1","outcome":"No","price":0.4}]}`))
+	}, nil, reg)
+
+	m, err := c.Market(context.Background(), "0xcond")
+	if err != nil {
+		t.Fatalf("Market: %v", err)
+	}
+	if !m.NegRisk || len(m.Tokens) != 2 || m.Tokens[1].TokenID != "no-1" {
+		t.Fatalf("wrong market: %+v", m)
+	}
+	if mc, _ := reg.Lookup(adapter.ExchangePolymarket, "0xcond"); mc.TickSize != 0.01 || mc.MinOrderSize != 15 {
+		t.Fatalf("constraints not recorded: %+v", mc)
+	}
+}
+
+func TestRESTClient_PostOrderSignsL2(t *testing.T) {
+	order := SignedOrder{
+		Maker: "0xabc", Signer: "0xabc", Taker: "0x0000000000000000000000000000000000000000",
+		TokenID: "yes-1", MakerAmount: "5000000", TakerAmount: "10000000",
+		Expiration: "0", Nonce: "0", FeeRateBps: "0", Side: "BUY",
+		Signature: "0xdeadbeef",
+	}
+
+	c := newTestRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.URL.Path != "/order" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		ts := r.Header.Get("POLY_TIMESTAMP")
+		if ts != "1700000000" {
+			t.Errorf("timestamp = %q", ts)
+		}
+		mac := hmac.New(sha256.New, []byte("super-secret"))
+		mac.Write([]byte(ts + "POST/order"))
+		mac.Write(body)
+		if want := base64.URLEncoding.EncodeToString(mac.Sum(nil)); r.Header.Get("POLY_SIGNATURE") != want {
+			t.Errorf("signature = %q, want %q", r.Header.Get("POLY_SIGNATURE"), want)
+		}
+		if r.Header.Get("POLY_API_KEY") != "key-1" || r.Header.Get("POLY_ADDRESS") != "0xabc" ||
+			r.Header.Get("POLY_PASSPHRASE") != "pass" {
+			t.Errorf("missing L2 headers: %v", r.Header)
+		}
+
+		var req postOrderRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if req.Owner != "key-1" || req.OrderType != OrderGTC || req.Order != order {
+			t.Errorf("wrong body: %+v", req)
+		}
+		w.Write([]byte(`{"success":true,"orderID":"0xorder","status":"live"}`))
+	}, testCreds, nil)
+
+	res, err := c.PostOrder(context.Background(), order, OrderGTC)
+	if err != nil {
+		t.Fatalf("PostOrder: %v", err)
+	}
+	if res.OrderID != "0xorder" || res.Status != "live" {
+		t.Fatalf("wrong result: %+v", res)
+	}
+}
+
+func TestRESTClient_Errors(t *testing.T) {
+	c := newTestRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.Write([]byte(`{"success":false,"errorMsg":"not enough balance"}`))
+		case http.MethodDelete:
+			w.Write([]byte(`{"canceled":[],"not_canceled":{"0xorder":"order already matched"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"market not found"}`))
+		}
+	}, testCreds, nil)
+	ctx := context.Background()
+
+	var apiErr *APIError
+	if _, err := c.Market(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.Status != 404 || apiErr.Message != "market not found" {
+		t.Fatalf("Market error = %v", err)
+	}
+	if _, err := c.PostOrder(ctx, SignedOrder{}, OrderFOK); !errors.As(err, &apiErr) || apiErr.Message != "not enough balance" {
+		t.Fatalf("PostOrder error = %v", err)
+	}
+	if err := c.CancelOrder(ctx, "0xorder"); !errors.As(err, &apiErr) || apiErr.Message != "order already matched" {
+		t.Fatalf("CancelOrder error = %v", err)
+	}
+
+	public, err := NewRESTClient(RESTConfig{BaseURL: "http://unused"})
+	if err != nil {
+		t.Fatalf("NewRESTClient: %v", err)
+	}
+	if err := public.CancelOrder(ctx, "0xorder"); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("expected ErrNoCredentials, got %v", err)
+	}
+}
+
+func TestNewRESTClient_RejectsBadSecret(t *testing.T) {
+	bad := *testCreds
+	bad.Secret = "not base64!"
+	if _, err := NewRESTClient(RESTConfig{Credentials: &bad}); err == nil {
+		t.Fatal("expected a secret that is not base64 rejected")
+	}
+
+	std := *testCreds
+	std.Secret = base64.StdEncoding.EncodeToString([]byte{0xfb, 0xff, 0xbf}) // "+/+/"
+	if _, err := NewRESTClient(RESTConfig{Credentials: &std}); err != nil {
+		t.Fatalf("standard-alphabet secret: %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package adapter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// recordMagic opens every recording file so a reader can reject foreign or
+// truncated-at-start input before parsing frames.
+var recordMagic = []byte("CAESARREC1\n")
+
+// maxFrameSize bounds a single recorded frame so a corrupt length prefix
+// cannot trigger a huge allocation during replay.
+const maxFrameSize = 16 << 20
+
+// Frame is a single raw inbound WebSocket message as captured by a Recorder.
+type Frame struct {
+	// Received is the local wall-clock time the frame was read.
+	Received time.Time
+
+	// ConnID identifies the physical connection the frame arrived on. Every
+	// dial, including reconnects, gets a new process-unique ID, so a change
+	// in ConnID marks a reconnect boundary in the recording.
+	ConnID uint64
+
+	Data []byte
+}
+
+// Recorder appends raw frames to a compact binary log. Each record is
+//
+//	uvarint  received time, Unix nanoseconds
+//	uvarint  connection ID
+//	uvarint  payload length
+//	[]byte   payload
+//
+// preceded once per file by a magic header. Recorder is safe for concurrent
+// use, so several WSClients may share one file. Writes are buffered; call
+// Flush or Close to persist them.
+type Recorder struct {
+	mu      sync.Mutex
+	w       *bufio.Writer
+	closer  io.Closer
+	scratch []byte
+}
+
+// NewRecorder writes a recording to w, starting with the file header.
+func NewRecorder(w io.Writer) (*Recorder, error) {
+	bw := bufio.NewWriterSize(w, 64<<10)
+	if _, err := bw.Write(recordMagic); err != nil {
+		return nil, fmt.Errorf("recorder: write header: %w", err)
+	}
+	return &Recorder{w: bw}, nil
+}
+
+// OpenRecorder opens path for appending, creating it if needed. The header
+// is written only when the file is empty, so a process restart continues
+// the existing recording.
+func OpenRecorder(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("recorder: %w", err)
+	}
+
+	r := &Recorder{w: bufio.NewWriterSize(f, 64<<10), closer: f}
+	if info.Size() == 0 {
+		if _, err := r.w.Write(recordMagic); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("recorder: write header: %w", err)
+		}
+	}
+	return r, nil
+}
+
+// Record appends f to the log.
+func (r *Recorder) Record(f Frame) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b := r.scratch[:0]
+	b = binary.AppendUvarint(b, uint64(f.Received.UnixNano()))
+	b = binary.AppendUvarint(b, f.ConnID)
+	b = binary.AppendUvarint(b, uint64(len(f.Data)))
+	r.scratch = b
+
+	if _, err := r.w.Write(b); err != nil {
+		return err
+	}
+	_, err := r.w.Write(f.Data)
+	return err
+}
+
+// Flush writes any buffered frames to the underlying writer.
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.w.Flush()
+}
+
+// Close flushes buffered frames and, for recorders created by OpenRecorder,
+// closes the file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.w.Flush()
+	if r.closer != nil {
+		if cerr := r.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// FrameReader decodes a recording written by Recorder.
+type FrameReader struct {
+	r *bufio.Reader
+}
+
+// NewFrameReader validates the recording header and returns a reader
+// positioned at the first frame.
+func NewFrameReader(r io.Reader) (*FrameReader, error) {
+	br := bufio.NewReaderSize(r, 64<<10)
+	head := make([]byte, len(recordMagic))
+	if _, err := io.ReadFull(br, head); err != nil {
+		return nil, fmt.Errorf("recording: read header: %w", err)
+	}
+	if !bytes.Equal(head, recordMagic) {
+		return nil, errors.New("recording: bad header")
+	}
+	return &FrameReader{r: br}, nil
+}
+
+// Next returns the next frame, or io.EOF at a clean end of the recording.
+// A frame cut short by a crash mid-write yields io.ErrUnexpectedEOF.
+func (fr *FrameReader) Next() (Frame, error) {
+	ns, err := binary.ReadUvarint(fr.r)
+	if err != nil {
+		return Frame{}, err // io.EOF only if nothing of the frame was read
+	}
+	connID, err := binary.ReadUvarint(fr.r)
+	if err != nil {
+		return Frame{}, unexpected(err)
+	}
+	n, err := binary.ReadUvarint(fr.r)
+	if err != nil {
+		return Frame{}, unexpected(err)
+	}
+	if n > maxFrameSize {
+		return Frame{}, fmt.Errorf("recording: frame of %d bytes exceeds limit", n)
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(fr.r, data); err != nil {
+		return Frame{}, unexpected(err)
+	}
+	return Frame{Received: time.Unix(0, int64(ns)), ConnID: connID, Data: data}, nil
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
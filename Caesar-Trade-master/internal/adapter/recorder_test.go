@@ -0,0 +1,165 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecorder_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	rec, err := NewRecorder(&buf)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+
+	base := time.Unix(1700000000, 123456789)
+	want := []Frame{
+		{Received: base, ConnID: 1, Data: []byte(`{"a":1}`)},
+		{Received: base.Add(5 * time.Millisecond), ConnID: 1, Data: []byte("PONG")},
+		{Received: base.Add(time.Second), ConnID: 2, Data: []byte{}},
+	}
+	for _, f := range want {
+		if err := rec.Record(f); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	fr, err := NewFrameReader(&buf)
+	if err != nil {
+		t.Fatalf("NewFrameReader: %v", err)
+	}
+	for i, w := range want {
+		got, err := fr.Next()
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if !got.Received.Equal(w.Received) || got.ConnID != w.ConnID || !bytes.Equal(got.Data, w.Data) {
+			t.Fatalf("frame %d: want %+v, got %+v", i, w, got)
+		}
+	}
+	if _, err := fr.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF after last frame, got %v", err)
+	}
+}
+
+func TestRecorder_TruncatedFrame(t *testing.T) {
+	var buf bytes.Buffer
+	rec, _ := NewRecorder(&buf)
+	rec.Record(Frame{Received: time.Now(), ConnID: 1, Data: []byte("hello world")})
+	rec.Flush()
+
+	fr, err := NewFrameReader(bytes.NewReader(buf.Bytes()[:buf.Len()-3]))
+	if err != nil {
+		t.Fatalf("NewFrameReader: %v", err)
+	}
+	if _, err := fr.Next(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	if _, err := NewFrameReader(bytes.NewReader([]byte("not a recording"))); err == nil {
+		t.Fatal("expected error for bad header")
+	}
+}
+
+func TestOpenRecorder_Appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.rec")
+
+	for i := 0; i < 2; i++ {
+		rec, err := OpenRecorder(path)
+		if err != nil {
+			t.Fatalf("OpenRecorder: %v", err)
+		}
+		rec.Record(Frame{Received: time.Now(), ConnID: uint64(i + 1), Data: []byte("x")})
+		if err := rec.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	fr, err := NewFrameReader(f)
+	if err != nil {
+		t.Fatalf("NewFrameReader: %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		got, err := fr.Next()
+		if err != nil || got.ConnID != uint64(i) {
+			t.Fatalf("frame %d: got %+v, err %v", i, got, err)
+		}
+	}
+	if _, err := fr.Next(); err != io.EOF {
+		t.Fatalf("expected a single header and two frames, got %v", err)
+	}
+}
+
+func TestWSClient_RecordsFrames(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	rec, _ := NewRecorder(&buf)
+
+	cfg := DefaultWSConfig(wsURL(srv))
+	cfg.Recorder = rec
+	cfg.IsHeartbeat = func(msg []byte) bool { return string(msg) == "PONG" }
+	client := NewWSClient(cfg)
+	sub := client.Subscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	// The heartbeat is filtered from subscribers but still recorded.
+	client.Send([]byte("PONG"))
+	client.Send([]byte("data"))
+	select {
+	case <-sub:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for echo")
+	}
+	client.Close()
+	rec.Close()
+
+	fr, err := NewFrameReader(&buf)
+	if err != nil {
+		t.Fatalf("NewFrameReader: %v", err)
+	}
+	var got []string
+	var connID uint64
+	for {
+		f, err := fr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if connID == 0 {
+			connID = f.ConnID
+		}
+		if f.ConnID != connID || f.Received.IsZero() {
+			t.Fatalf("unexpected frame metadata: %+v", f)
+		}
+		got = append(got, string(f.Data))
+	}
+	if len(got) != 2 || got[0] != "PONG" || got[1] != "data" {
+		t.Fatalf("expected [PONG data], got %v", got)
+	}
+	if connID == 0 {
+		t.Fatal("connection ID not assigned")
+	}
+}
@@ -31,10 +31,16 @@ type bookSnapshot struct {
 type RedisWriter struct {
 	client RedisClient
 	feed   <-chan BookUpdate
-	buf    chan BookUpdate
+	buf    *Subscription[BookUpdate]
+
+	// conflated, when set, replaces feed and buf: the writer reads the
+	// latest update per market from it and never holds a stale price.
+	conflated *ConflatedSubscription
 
 	mu   sync.Mutex
 	last map[string]bookSnapshot // keyed by Redis key
+
+	latency *LatencyTracker // nil disables recording
 }
 
 // NewRedisWriter creates a RedisWriter that reads from the Broadcaster's
@@ -43,15 +49,62 @@ func NewRedisWriter(client RedisClient, feed <-chan BookUpdate) *RedisWriter {
 	return &RedisWriter{
 		client: client,
 		feed:   feed,
-		buf:    make(chan BookUpdate, 1024),
+		buf:    newSubscription[BookUpdate]("redis writer", SubscribeOptions{}, 1024),
 		last:   make(map[string]bookSnapshot),
 	}
 }
 
+// NewConflatedRedisWriter creates a RedisWriter that reads from a
+// conflating Broadcaster subscription (see SubscribeAllConflated). A slow
+// Redis then costs intermediate writes rather than freshness.
+func NewConflatedRedisWriter(client RedisClient, sub *ConflatedSubscription) *RedisWriter {
+	return &RedisWriter{
+		client:    client,
+		conflated: sub,
+		last:      make(map[string]bookSnapshot),
+	}
+}
+
+// SetBackpressure configures the internal buffer between ingestion and the
+// Redis flusher. Must be called before Run. DisconnectSlow stops the writer
+// on the first overflow, so it is only useful when a stalled Redis should
+// be treated as fatal.
+func (rw *RedisWriter) SetBackpressure(opts SubscribeOptions) {
+	rw.buf = newSubscription[BookUpdate]("redis writer", opts, 1024)
+}
+
+// SetLatencyTracker records persist latencies into lt. Must be called
+// before Run.
+func (rw *RedisWriter) SetLatencyTracker(lt *LatencyTracker) {
+	rw.latency = lt
+}
+
+// Stats returns delivery counters for the internal buffer, or for the
+// conflating subscription if the writer reads from one.
+func (rw *RedisWriter) Stats() SubscriberStats {
+	if rw.conflated != nil {
+		return rw.conflated.Stats()
+	}
+	return rw.buf.Stats()
+}
+
 // Run starts two goroutines: one to drain the Broadcaster feed into an
-// internal buffer, and one to flush buffered updates to Redis. It blocks
+// internal buffer, and one to flush buffered updates to Redis. A conflated
+// writer needs no buffer and flushes from a single goroutine. It blocks
 // until ctx is cancelled.
 func (rw *RedisWriter) Run(ctx context.Context) {
+	if rw.conflated != nil {
+		for {
+			updates, err := rw.conflated.Next(ctx)
+			if err != nil {
+				return
+			}
+			for _, update := range updates {
+				rw.write(ctx, update)
+			}
+		}
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(2)
 
@@ -67,10 +120,8 @@ func (rw *RedisWriter) Run(ctx context.Context) {
 				if !ok {
 					return
 				}
-				select {
-				case rw.buf <- update:
-				default:
-					// Buffer full — drop oldest-unsent to keep up.
+				if !rw.buf.deliver(update) {
+					return // evicted under DisconnectSlow
 				}
 			}
 		}
@@ -83,7 +134,7 @@ func (rw *RedisWriter) Run(ctx context.Context) {
 			select {
 			case <-ctx.Done():
 				return
-			case update, ok := <-rw.buf:
+			case update, ok := <-rw.buf.C():
 				if !ok {
 					return
 				}
@@ -97,8 +148,8 @@ func (rw *RedisWriter) Run(ctx context.Context) {
 
 // write extracts best bid/ask, checks for duplicates, and issues an HSET.
 func (rw *RedisWriter) write(ctx context.Context, update BookUpdate) {
-	bestBid := bestPrice(update.Bids, true)
-	bestAsk := bestPrice(update.Asks, false)
+	bestBid := formatBest(update.BestBid())
+	bestAsk := formatBest(update.BestAsk())
 
 	key := fmt.Sprintf("book:%s:%s", update.Exchange, update.MarketID)
 
@@ -113,22 +164,15 @@ func (rw *RedisWriter) write(ctx context.Context, update BookUpdate) {
 
 	ts := strconv.FormatInt(update.Timestamp.UnixMilli(), 10)
 	rw.client.HSet(ctx, key, "bid", bestBid, "ask", bestAsk, "ts", ts)
+
+	update.Trace.Mark(StagePersisted)
+	rw.latency.ObserveStage(update, StagePersisted)
 }
 
-// bestPrice returns the best (highest bid or lowest ask) price as a string.
-// For bids, "best" is the highest price; for asks, the lowest.
-func bestPrice(levels []PriceLevel, isBid bool) string {
-	if len(levels) == 0 {
+// formatBest formats the price of a best level, or "0" if there is none.
+func formatBest(l PriceLevel, ok bool) string {
+	if !ok {
 		return "0"
 	}
-	best := levels[0].Price
-	for _, l := range levels[1:] {
-		if isBid && l.Price > best {
-			best = l.Price
-		}
-		if !isBid && l.Price < best {
-			best = l.Price
-		}
-	}
-	return strconv.FormatFloat(best, 'f', -1, 64)
+	return strconv.FormatFloat(l.Price, 'f', -1, 64)
 }
@@ -55,8 +55,8 @@ func TestRedisWriter_HSetCommand(t *testing.T) {
 		Exchange: ExchangePolymarket,
 		MarketID: "0xabc",
 		Bids: []PriceLevel{
-			{Price: 0.48, Size: 30},
 			{Price: 0.52, Size: 10},
+			{Price: 0.48, Size: 30},
 		},
 		Asks: []PriceLevel{
 			{Price: 0.55, Size: 25},
@@ -149,3 +149,53 @@ func TestRedisWriter_DuplicateSuppression(t *testing.T) {
 		t.Fatalf("expected updated bid '0.5', got %q", calls[1].Fields["bid"])
 	}
 }
+
+func TestRedisWriter_Conflated(t *testing.T) {
+	mock := &mockRedis{}
+	sub := newConflatedSubscription()
+
+	// Three prices arrive before the writer gets to run; only the last
+	// one is worth persisting.
+	for i, bid := range []float64{0.40, 0.41, 0.42} {
+		sub.deliver(BookUpdate{
+			Exchange:  ExchangeKalshi,
+			MarketID:  "FED-DEC",
+			Bids:      []PriceLevel{{Price: bid, Size: 100}},
+			Timestamp: time.UnixMilli(int64(1000 * (i + 1))),
+		})
+	}
+
+	rw := NewConflatedRedisWriter(mock, sub)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		rw.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(time.Second)
+	for len(mock.getCalls()) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for HSET call")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	calls := mock.getCalls()
+	if len(calls) != 1 || calls[0].Fields["bid"] != "0.42" || calls[0].Fields["ts"] != "3000" {
+		t.Fatalf("expected a single write of the latest price, got %+v", calls)
+	}
+	if st := rw.Stats(); st.Delivered != 1 || st.Conflated != 2 {
+		t.Fatalf("stats: want 1 delivered / 2 conflated, got %+v", st)
+	}
+
+	sub.Unsubscribe()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the subscription ended")
+	}
+}
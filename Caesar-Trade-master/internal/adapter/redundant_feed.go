@@ -0,0 +1,512 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+)
+
+// DedupKeyFunc extracts the identity of a venue message, so copies of it
+// arriving on different legs of a RedundantFeed can be recognised. It
+// returns false for messages without a usable identity; those are
+// deduplicated by their exact bytes instead.
+type DedupKeyFunc func(msg []byte) (string, bool)
+
+// RedundantFeedConfig holds tunable parameters for a RedundantFeed.
+type RedundantFeedConfig struct {
+	// Legs configures one connection per entry. Legs normally share a URL
+	// and differ in routing, e.g. a distinct IPPool per leg.
+	Legs []WSConfig
+
+	// DedupKey identifies messages across legs (see poly.DedupKey). Nil
+	// deduplicates by exact bytes.
+	DedupKey DedupKeyFunc
+
+	// Failover delivers every message from one active leg instead of the
+	// first copy from any leg. Set it for venues whose messages are only
+	// meaningful per connection, such as Kalshi, whose subscription IDs and
+	// sequence numbers are assigned per connection: mixing legs would
+	// interleave unrelated streams. When the active leg drops, the next
+	// live leg takes over and is reconnected, so it replays its
+	// subscriptions and consumers receive fresh snapshots on its stream.
+	// That planned reconnect does not count as an outage.
+	Failover bool
+
+	// Window is how many recent message keys are remembered. A copy that
+	// arrives after its key was evicted is delivered again, so Window must
+	// cover the worst lag between legs. Default: 10000.
+	Window int
+}
+
+// LegStats reports how one leg of a RedundantFeed is performing.
+type LegStats struct {
+	Index   int
+	URL     string
+	Circuit CircuitState
+
+	// Wins counts messages this leg delivered first.
+	Wins uint64
+
+	// Late counts copies that arrived after another leg already delivered
+	// the message. MeanLag and MaxLag measure how far behind they were.
+	Late    uint64
+	MeanLag time.Duration
+	MaxLag  time.Duration
+}
+
+// RedundantFeed subscribes to the same venue feed over several connections
+// at once and forwards only the first copy of each message, so a slow or
+// failing leg costs neither latency nor data. In Failover mode it forwards
+// one leg at a time and keeps the others as hot standbys. It implements Feed and
+// ConnectionHealth: its circuit opens only when every leg is down, and its
+// Events report only those feed-wide outages and recoveries.
+type RedundantFeed struct {
+	cfg  RedundantFeedConfig
+	legs []*leg
+
+	// Dedup state: seen maps a key to its first arrival; ring holds keys in
+	// arrival order for eviction.
+	mu   sync.Mutex
+	seen map[string]time.Time
+	ring []string
+	head int
+
+	// active is the leg delivering in Failover mode; nil while every leg
+	// is down.
+	active *leg
+
+	subs *frameFanOut
+
+	evMu   sync.RWMutex
+	evSubs []chan ConnEvent
+	down   bool // every leg is down; guarded by evMu
+
+	forwarders sync.WaitGroup
+	connecting sync.WaitGroup
+	cancel     context.CancelFunc
+}
+
+// leg is one connection of a RedundantFeed and its counters, which are
+// guarded by RedundantFeed.mu.
+type leg struct {
+	index  int
+	ws     *WSClient
+	events <-chan ConnEvent // watched once Connect has picked the active leg
+	wins   uint64
+	late   uint64
+	lagSum time.Duration
+	lagMax time.Duration
+
+	// switching marks a leg promoted in Failover mode and reconnected on
+	// purpose. Until it is back or a redial fails, it counts as live and
+	// its disconnect does not hand the feed on again.
+	switching bool
+}
+
+var (
+	_ Feed             = (*RedundantFeed)(nil)
+	_ ConnectionHealth = (*RedundantFeed)(nil)
+)
+
+// NewRedundantFeed creates a RedundantFeed with one WSClient per leg. Call
+// Connect to start.
+func NewRedundantFeed(cfg RedundantFeedConfig) (*RedundantFeed, error) {
+	if len(cfg.Legs) == 0 {
+		return nil, errors.New("redundant: no legs configured")
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 10000
+	}
+
+	rf := &RedundantFeed{
+		cfg:  cfg,
+		subs: &frameFanOut{name: "redundant " + cfg.Legs[0].URL},
+		seen: make(map[string]time.Time, cfg.Window),
+		ring: make([]string, cfg.Window),
+	}
+	for i, wsCfg := range cfg.Legs {
+		l := &leg{index: i, ws: NewWSClient(wsCfg)}
+		l.events = l.ws.Events()
+		rf.legs = append(rf.legs, l)
+
+		frames := l.ws.SubscribeFrames()
+		rf.forwarders.Add(1)
+		go rf.forwardMessages(l, frames)
+	}
+	return rf, nil
+}
+
+// Subscribe returns a channel carrying the first copy of every message.
+func (rf *RedundantFeed) Subscribe() <-chan []byte {
+	return rf.SubscribeWith(SubscribeOptions{}).C()
+}
+
+// SubscribeWith is like Subscribe but applies the given buffer size and
+// backpressure policy.
+func (rf *RedundantFeed) SubscribeWith(opts SubscribeOptions) *Subscription[[]byte] {
+	return rf.subs.subscribe(opts)
+}
+
+// SubscribeFrames is like Subscribe but keeps the winning leg's receive
+// time and connection ID.
+func (rf *RedundantFeed) SubscribeFrames() <-chan Frame {
+	return rf.subs.subscribeFrames(SubscribeOptions{}).C()
+}
+
+// Events returns feed-wide lifecycle events: Connected once Connect
+// succeeds, Disconnected when the last live leg drops, Reconnected when the
+// first leg recovers, and Closed. Single-leg failovers are not reported;
+// see Stats for per-leg health.
+func (rf *RedundantFeed) Events() <-chan ConnEvent {
+	ch := make(chan ConnEvent, 64)
+	rf.evMu.Lock()
+	rf.evSubs = append(rf.evSubs, ch)
+	rf.evMu.Unlock()
+	return ch
+}
+
+// SendSubscription sends data on every leg, so a standby leg carries the
+// same subscriptions as the one it may replace.
+func (rf *RedundantFeed) SendSubscription(key string, data []byte) {
+	for _, l := range rf.legs {
+		l.ws.SendSubscription(key, data)
+	}
+}
+
+// SendCommand sends data on every leg.
+func (rf *RedundantFeed) SendCommand(key string, data []byte) {
+	for _, l := range rf.legs {
+		l.ws.Send(data)
+	}
+}
+
+// SendSubscriptions sends the batch on every leg, each building its own
+// message since one leg may have subscribed already where another has
+// just reconnected.
+func (rf *RedundantFeed) SendSubscriptions(keys []string, build SubscribeFunc) {
+	if len(keys) == 0 {
+		return
+	}
+	for _, l := range rf.legs {
+		l.ws.sendSubscriptions(keys, build)
+	}
+}
+
+// SendCommands builds the command once and sends it on every leg.
+func (rf *RedundantFeed) SendCommands(keys []string, batch func(keys []string) []byte) {
+	if len(keys) == 0 {
+		return
+	}
+	data := batch(keys)
+	for _, l := range rf.legs {
+		l.ws.Send(data)
+	}
+}
+
+// ForgetSubscription removes key from every leg's replay registry.
+func (rf *RedundantFeed) ForgetSubscription(key string) {
+	for _, l := range rf.legs {
+		l.ws.ForgetSubscription(key)
+	}
+}
+
+// Connect dials every leg. It succeeds if at least one leg connects; the
+// others keep retrying in the background with backoff. In Failover mode the
+// first leg to connect is active from its first frame; leg events are
+// watched only afterwards, so they cannot elect and reconnect another.
+func (rf *RedundantFeed) Connect(ctx context.Context) error {
+	ctx, rf.cancel = context.WithCancel(ctx)
+
+	var errs []error
+	for _, l := range rf.legs {
+		rf.mu.Lock()
+		claimed := rf.active == nil
+		if claimed {
+			rf.active = l
+		}
+		rf.mu.Unlock()
+
+		if err := l.ws.Connect(ctx); err != nil {
+			log.Printf("redundant: leg %d connect failed: %v", l.index, err)
+			errs = append(errs, err)
+			if claimed {
+				rf.mu.Lock()
+				rf.active = nil
+				rf.mu.Unlock()
+			}
+			rf.connecting.Add(1)
+			go func(l *leg) {
+				defer rf.connecting.Done()
+				l.ws.connectRetry(ctx)
+			}(l)
+		}
+	}
+	for _, l := range rf.legs {
+		rf.forwarders.Add(1)
+		go rf.watchLeg(l)
+	}
+	if len(errs) == len(rf.legs) {
+		rf.cancel()
+		rf.connecting.Wait()
+		return errors.Join(errs...)
+	}
+
+	rf.emit(ConnEvent{Type: ConnConnected})
+	return nil
+}
+
+// Close shuts down every leg and then closes all subscriber and event
+// channels.
+func (rf *RedundantFeed) Close() {
+	if rf.cancel != nil {
+		rf.cancel()
+	}
+	rf.connecting.Wait()
+	for _, l := range rf.legs {
+		l.ws.Close()
+	}
+	rf.forwarders.Wait()
+
+	rf.subs.close()
+
+	rf.emit(ConnEvent{Type: ConnClosed})
+	rf.evMu.Lock()
+	for _, ch := range rf.evSubs {
+		close(ch)
+	}
+	rf.evSubs = nil
+	rf.evMu.Unlock()
+}
+
+// Circuit is CircuitClosed while at least one leg is healthy. A leg
+// reconnecting to take over in Failover mode counts as healthy.
+func (rf *RedundantFeed) Circuit() CircuitState {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	for _, l := range rf.legs {
+		if l.switching || l.ws.Circuit() == CircuitClosed {
+			return CircuitClosed
+		}
+	}
+	return CircuitOpen
+}
+
+// CircuitFor returns Circuit: every leg carries every key.
+func (rf *RedundantFeed) CircuitFor(key string) CircuitState {
+	return rf.Circuit()
+}
+
+// Stats returns per-leg win counts, lag and health in leg order. In
+// Failover mode Wins counts the messages each leg delivered while active
+// and Late stays zero.
+func (rf *RedundantFeed) Stats() []LegStats {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	out := make([]LegStats, len(rf.legs))
+	for i, l := range rf.legs {
+		out[i] = LegStats{
+			Index:   l.index,
+			URL:     rf.cfg.Legs[i].URL,
+			Circuit: l.ws.Circuit(),
+			Wins:    l.wins,
+			Late:    l.late,
+			MaxLag:  l.lagMax,
+		}
+		if l.late > 0 {
+			out[i].MeanLag = l.lagSum / time.Duration(l.late)
+		}
+	}
+	return out
+}
+
+// forwardMessages delivers the first copy of each frame from leg l to
+// every subscriber and records wins and lag.
+func (rf *RedundantFeed) forwardMessages(l *leg, frames <-chan Frame) {
+	defer rf.forwarders.Done()
+	for f := range frames {
+		deliver := false
+		if rf.cfg.Failover {
+			deliver = rf.fromActive(l)
+		} else {
+			deliver = rf.first(l, f.Data, f.Received)
+		}
+		if deliver {
+			rf.subs.deliver(f)
+		}
+	}
+}
+
+// fromActive reports whether l is the active leg in Failover mode,
+// counting the delivery as a win.
+func (rf *RedundantFeed) fromActive(l *leg) bool {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.active != l {
+		return false
+	}
+	l.wins++
+	return true
+}
+
+// first reports whether msg is the first copy seen on any leg, updating
+// the dedup window and leg counters.
+func (rf *RedundantFeed) first(l *leg, msg []byte, now time.Time) bool {
+	key, ok := "", false
+	if rf.cfg.DedupKey != nil {
+		key, ok = rf.cfg.DedupKey(msg)
+	}
+	if !ok {
+		h := fnv.New64a()
+		h.Write(msg)
+		key = string(h.Sum(nil))
+	}
+
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if at, dup := rf.seen[key]; dup {
+		lag := now.Sub(at)
+		l.late++
+		l.lagSum += lag
+		if lag > l.lagMax {
+			l.lagMax = lag
+		}
+		return false
+	}
+
+	if old := rf.ring[rf.head]; old != "" {
+		delete(rf.seen, old)
+	}
+	rf.ring[rf.head] = key
+	rf.head = (rf.head + 1) % len(rf.ring)
+	rf.seen[key] = now
+	l.wins++
+	return true
+}
+
+// watchLeg turns leg l's lifecycle events into feed-wide transitions and,
+// in Failover mode, hands the feed over when the active leg drops.
+func (rf *RedundantFeed) watchLeg(l *leg) {
+	defer rf.forwarders.Done()
+	for ev := range l.events {
+		switch ev.Type {
+		case ConnDisconnected:
+			if rf.cfg.Failover {
+				rf.failover(l)
+			}
+			if rf.Circuit() == CircuitOpen {
+				rf.transition(true, ev)
+			}
+		case ConnReconnectAttempt:
+			// A failed redial ends a planned switch: the leg is down
+			// after all.
+			if rf.cfg.Failover && ev.Reason != nil && rf.endSwitch(l) {
+				rf.failover(l)
+				if rf.Circuit() == CircuitOpen {
+					rf.transition(true, ev)
+				}
+			}
+		case ConnConnected, ConnReconnected:
+			if rf.cfg.Failover {
+				rf.endSwitch(l)
+				rf.adopt(l, ev.Type == ConnReconnected)
+			}
+			rf.transition(false, ev)
+		}
+	}
+}
+
+// endSwitch clears l's planned switch, reporting whether one was under way.
+func (rf *RedundantFeed) endSwitch(l *leg) bool {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	was := l.switching
+	l.switching = false
+	return was
+}
+
+// failover promotes the next live leg after l if l was the active leg.
+// The promoted leg is reconnected so it replays its subscriptions: the
+// consumer has seen none of its stream, and commands built from the old
+// leg's replies may not have applied to it.
+func (rf *RedundantFeed) failover(l *leg) {
+	rf.mu.Lock()
+	if rf.active != l || l.switching {
+		rf.mu.Unlock()
+		return
+	}
+	rf.active = nil
+	for i := 1; i < len(rf.legs); i++ {
+		next := rf.legs[(l.index+i)%len(rf.legs)]
+		if next.ws.Circuit() == CircuitClosed {
+			rf.active = next
+			next.switching = true
+			break
+		}
+	}
+	next := rf.active
+	rf.mu.Unlock()
+
+	if next == nil {
+		log.Printf("redundant: leg %d lost, no live leg to fail over to", l.index)
+		return
+	}
+	log.Printf("redundant: leg %d lost, failing over to leg %d", l.index, next.index)
+	next.ws.recycle()
+}
+
+// adopt makes l the active leg if none is. A leg that has just
+// reconnected has replayed its subscriptions; any other is reconnected
+// first, as in failover.
+func (rf *RedundantFeed) adopt(l *leg, replayed bool) {
+	rf.mu.Lock()
+	if rf.active != nil {
+		rf.mu.Unlock()
+		return
+	}
+	rf.active = l
+	l.switching = !replayed
+	rf.mu.Unlock()
+
+	log.Printf("redundant: leg %d active", l.index)
+	if !replayed {
+		l.ws.recycle()
+	}
+}
+
+// transition emits a feed-wide Disconnected or Reconnected event if the
+// all-legs-down state changes.
+func (rf *RedundantFeed) transition(down bool, ev ConnEvent) {
+	rf.evMu.Lock()
+	changed := rf.down != down
+	rf.down = down
+	rf.evMu.Unlock()
+	if !changed {
+		return
+	}
+
+	if down {
+		rf.emit(ConnEvent{Type: ConnDisconnected, Reason: ev.Reason, Keys: ev.Keys})
+	} else {
+		rf.emit(ConnEvent{Type: ConnReconnected, Attempt: ev.Attempt, Keys: ev.Keys})
+	}
+}
+
+// emit stamps ev and delivers it to every Events subscriber.
+func (rf *RedundantFeed) emit(ev ConnEvent) {
+	ev.URL = rf.cfg.Legs[0].URL
+	ev.Time = time.Now()
+
+	rf.evMu.RLock()
+	defer rf.evMu.RUnlock()
+	for _, ch := range rf.evSubs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
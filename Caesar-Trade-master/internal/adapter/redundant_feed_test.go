@@ -0,0 +1,261 @@
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// delayedEchoServer echoes every message after delay. drop closes all
+// connections accepted so far and makes the server refuse new ones.
+func delayedEchoServer(t *testing.T, delay time.Duration) (srv *httptest.Server, drop func()) {
+	t.Helper()
+	var mu sync.Mutex
+	var conns []*websocket.Conn
+	dead := false
+	upgrader := websocket.Upgrader{}
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if dead {
+			mu.Unlock()
+			http.Error(w, "gone", http.StatusServiceUnavailable)
+			return
+		}
+		mu.Unlock()
+
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		conns = append(conns, c)
+		mu.Unlock()
+		defer c.Close()
+		for {
+			mt, msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			time.Sleep(delay)
+			if err := c.WriteMessage(mt, msg); err != nil {
+				return
+			}
+		}
+	}))
+	drop = func() {
+		mu.Lock()
+		defer mu.Unlock()
+		dead = true
+		for _, c := range conns {
+			c.Close()
+		}
+	}
+	return srv, drop
+}
+
+func redundantConfig(urls ...string) RedundantFeedConfig {
+	var cfg RedundantFeedConfig
+	for _, u := range urls {
+		leg := DefaultWSConfig(u)
+		leg.HeartbeatTimeout = 300 * time.Millisecond
+		leg.PingInterval = 50 * time.Millisecond
+		leg.BackoffInitial = 20 * time.Millisecond
+		leg.BackoffMax = 50 * time.Millisecond
+		cfg.Legs = append(cfg.Legs, leg)
+	}
+	return cfg
+}
+
+func TestRedundantFeed_FirstArrivalWins(t *testing.T) {
+	fast, _ := delayedEchoServer(t, 0)
+	defer fast.Close()
+	slow, _ := delayedEchoServer(t, 30*time.Millisecond)
+	defer slow.Close()
+
+	rf, err := NewRedundantFeed(redundantConfig(wsURL(fast), wsURL(slow)))
+	if err != nil {
+		t.Fatalf("NewRedundantFeed: %v", err)
+	}
+	sub := rf.Subscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := rf.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer rf.Close()
+
+	msgs := []string{"m1", "m2", "m3"}
+	for _, m := range msgs {
+		rf.SendSubscription(m, []byte(m))
+	}
+	for _, want := range msgs {
+		select {
+		case got := <-sub:
+			if string(got) != want {
+				t.Fatalf("expected %q, got %q", want, got)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+
+	// Let the slow leg's copies arrive; none may reach the subscriber.
+	time.Sleep(150 * time.Millisecond)
+	select {
+	case dup := <-sub:
+		t.Fatalf("duplicate delivered: %q", dup)
+	default:
+	}
+
+	stats := rf.Stats()
+	if stats[0].Wins != 3 || stats[1].Wins != 0 {
+		t.Fatalf("expected fast leg to win all 3, got %+v", stats)
+	}
+	if stats[1].Late != 3 || stats[1].MeanLag < 20*time.Millisecond {
+		t.Fatalf("expected slow leg 3 late copies lagging ~30ms, got %+v", stats[1])
+	}
+}
+
+func TestRedundantFeed_OpensOnlyWhenAllLegsDown(t *testing.T) {
+	a, dropA := delayedEchoServer(t, 0)
+	defer a.Close()
+	b, dropB := delayedEchoServer(t, 0)
+	defer b.Close()
+
+	rf, err := NewRedundantFeed(redundantConfig(wsURL(a), wsURL(b)))
+	if err != nil {
+		t.Fatalf("NewRedundantFeed: %v", err)
+	}
+	sub := rf.Subscribe()
+	events := rf.Events()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rf.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer rf.Close()
+	if ev := <-events; ev.Type != ConnConnected {
+		t.Fatalf("expected Connected, got %s", ev)
+	}
+
+	// Losing one leg is invisible downstream.
+	dropA()
+	time.Sleep(400 * time.Millisecond)
+	if rf.Circuit() != CircuitClosed {
+		t.Fatal("circuit should stay closed while one leg is up")
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected feed event after single-leg failure: %s", ev)
+	default:
+	}
+	rf.SendSubscription("k", []byte("still-flowing"))
+	select {
+	case msg := <-sub:
+		if string(msg) != "still-flowing" {
+			t.Fatalf("expected 'still-flowing', got %q", msg)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for data via surviving leg")
+	}
+
+	// Losing the last leg opens the circuit.
+	dropB()
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type != ConnDisconnected {
+				continue
+			}
+			if rf.Circuit() != CircuitOpen {
+				t.Fatal("circuit should be open with every leg down")
+			}
+			if len(ev.Keys) != 1 || ev.Keys[0] != "k" {
+				t.Fatalf("expected event keys [k], got %v", ev.Keys)
+			}
+			return
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for feed-wide Disconnected")
+		}
+	}
+}
+
+func TestRedundantFeed_Failover(t *testing.T) {
+	a, dropA := delayedEchoServer(t, 0)
+	defer a.Close()
+	b, _ := delayedEchoServer(t, 20*time.Millisecond)
+	defer b.Close()
+
+	cfg := redundantConfig(wsURL(a), wsURL(b))
+	cfg.Failover = true
+	rf, err := NewRedundantFeed(cfg)
+	if err != nil {
+		t.Fatalf("NewRedundantFeed: %v", err)
+	}
+	sub := rf.SubscribeFrames()
+	events := rf.Events()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rf.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer rf.Close()
+	if ev := <-events; ev.Type != ConnConnected {
+		t.Fatalf("expected Connected, got %s", ev)
+	}
+
+	recv := func(want string) Frame {
+		t.Helper()
+		select {
+		case f := <-sub:
+			if string(f.Data) != want {
+				t.Fatalf("expected %q, got %q", want, f.Data)
+			}
+			return f
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for %q", want)
+			return Frame{}
+		}
+	}
+	quiet := func() {
+		t.Helper()
+		select {
+		case f := <-sub:
+			t.Fatalf("unexpected frame %q", f.Data)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// Only the active leg delivers, even though the standby is slower
+	// rather than absent.
+	rf.SendSubscription("k", []byte("sub-k"))
+	first := recv("sub-k")
+	quiet()
+
+	// The standby takes over and replays its subscriptions on a fresh
+	// connection. The planned reconnect is not a feed-wide outage.
+	dropA()
+	replayed := recv("sub-k")
+	if replayed.ConnID == first.ConnID {
+		t.Fatal("expected the replay on the standby's connection")
+	}
+	quiet()
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected feed event during failover: %s", ev)
+	default:
+	}
+
+	stats := rf.Stats()
+	if stats[0].Wins != 1 || stats[1].Wins != 1 || stats[1].Late != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
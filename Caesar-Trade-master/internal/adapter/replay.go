@@ -0,0 +1,169 @@
+package adapter
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// ReplayConfig controls playback of a recording.
+type ReplayConfig struct {
+	// Speed scales the gaps between frames: 1 reproduces the original
+	// timing, 10 plays ten times faster, and 0 delivers frames back to back
+	// as fast as subscribers consume them.
+	Speed float64
+
+	// IsHeartbeat drops matching frames, mirroring WSConfig.IsHeartbeat so
+	// recorded application heartbeats never reach the adapter.
+	IsHeartbeat func(msg []byte) bool
+}
+
+// Replayer feeds a recording through the same Subscribe channel interface as
+// WSClient. Unlike the live fan-out, delivery blocks until every subscriber
+// accepts the frame, so a replay is deterministic and never drops data.
+// Subscriber channels are closed when the recording ends.
+type Replayer struct {
+	cfg ReplayConfig
+	src *FrameReader
+
+	mu     sync.Mutex
+	subs   []chan []byte
+	frames []chan Frame
+	sent   map[string][]byte
+}
+
+// NewReplayer reads a recording from r. The header is validated immediately.
+func NewReplayer(r io.Reader, cfg ReplayConfig) (*Replayer, error) {
+	fr, err := NewFrameReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Replayer{cfg: cfg, src: fr, sent: make(map[string][]byte)}, nil
+}
+
+// Subscribe returns a channel that receives every recorded frame. It must
+// be called before Run.
+func (rp *Replayer) Subscribe() <-chan []byte {
+	ch := make(chan []byte, 512)
+	rp.mu.Lock()
+	rp.subs = append(rp.subs, ch)
+	rp.mu.Unlock()
+	return ch
+}
+
+// SubscribeFrames is like Subscribe but each frame carries the time it was
+// replayed as Received, so pipeline latencies measured downstream reflect
+// this run rather than the original session. It must be called before Run.
+func (rp *Replayer) SubscribeFrames() <-chan Frame {
+	ch := make(chan Frame, 512)
+	rp.mu.Lock()
+	rp.frames = append(rp.frames, ch)
+	rp.mu.Unlock()
+	return ch
+}
+
+// SendSubscription records the request so tests can assert what an adapter
+// asked for; nothing is sent, since the recording already holds the
+// venue's responses.
+func (rp *Replayer) SendSubscription(key string, data []byte) {
+	rp.mu.Lock()
+	rp.sent[key] = data
+	rp.mu.Unlock()
+}
+
+// SendCommand discards data, since the recording already holds the venue's
+// responses.
+func (rp *Replayer) SendCommand(key string, data []byte) {}
+
+// SendSubscriptions records each key's own initial message, like
+// SendSubscription.
+func (rp *Replayer) SendSubscriptions(keys []string, build SubscribeFunc) {
+	rp.mu.Lock()
+	for _, k := range keys {
+		rp.sent[k] = build([]string{k}, true)
+	}
+	rp.mu.Unlock()
+}
+
+// SendCommands discards the commands, like SendCommand.
+func (rp *Replayer) SendCommands(keys []string, batch func(keys []string) []byte) {}
+
+// ForgetSubscription removes the message recorded under key.
+func (rp *Replayer) ForgetSubscription(key string) {
+	rp.mu.Lock()
+	delete(rp.sent, key)
+	rp.mu.Unlock()
+}
+
+// Sent returns the subscription message last sent under key, if any.
+func (rp *Replayer) Sent(key string) ([]byte, bool) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	data, ok := rp.sent[key]
+	return data, ok
+}
+
+// Run plays the recording and closes all subscriber channels when it ends.
+// It returns nil at the end of the recording, ctx.Err() if cancelled, or
+// the decode error that stopped playback.
+func (rp *Replayer) Run(ctx context.Context) error {
+	rp.mu.Lock()
+	subs, frames := rp.subs, rp.frames
+	rp.mu.Unlock()
+	defer func() {
+		for _, ch := range subs {
+			close(ch)
+		}
+		for _, ch := range frames {
+			close(ch)
+		}
+	}()
+
+	var first time.Time
+	start := time.Now()
+	for {
+		f, err := rp.src.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if first.IsZero() {
+			first = f.Received
+		}
+		if rp.cfg.Speed > 0 {
+			offset := time.Duration(float64(f.Received.Sub(first)) / rp.cfg.Speed)
+			if wait := time.Until(start.Add(offset)); wait > 0 {
+				t := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return ctx.Err()
+				case <-t.C:
+				}
+			}
+		}
+
+		if rp.cfg.IsHeartbeat != nil && rp.cfg.IsHeartbeat(f.Data) {
+			continue
+		}
+		for _, ch := range subs {
+			select {
+			case ch <- f.Data:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		f.Received = time.Now()
+		for _, ch := range frames {
+			select {
+			case ch <- f:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
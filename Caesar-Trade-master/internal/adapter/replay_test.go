@@ -0,0 +1,108 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// recording builds an in-memory recording with the given inter-frame gaps.
+func recording(t *testing.T, gap time.Duration, msgs ...string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	rec, err := NewRecorder(&buf)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	ts := time.Unix(1700000000, 0)
+	for _, m := range msgs {
+		rec.Record(Frame{Received: ts, ConnID: 1, Data: []byte(m)})
+		ts = ts.Add(gap)
+	}
+	rec.Close()
+	return &buf
+}
+
+func TestReplayer_DeliversAllFramesInOrder(t *testing.T) {
+	src := recording(t, time.Hour, "a", "PONG", "b", "c")
+	rp, err := NewReplayer(src, ReplayConfig{
+		IsHeartbeat: func(msg []byte) bool { return string(msg) == "PONG" },
+	})
+	if err != nil {
+		t.Fatalf("NewReplayer: %v", err)
+	}
+
+	// Unbuffered consumption from two subscribers: delivery blocks rather
+	// than drops, so both see every frame even at max speed.
+	s1, s2 := rp.Subscribe(), rp.Subscribe()
+	done := make(chan error, 1)
+	go func() { done <- rp.Run(context.Background()) }()
+
+	for _, sub := range []<-chan []byte{s1, s2} {
+		var got []string
+		for msg := range sub {
+			got = append(got, string(msg))
+		}
+		if len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+			t.Fatalf("expected [a b c], got %v", got)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func TestReplayer_Speed(t *testing.T) {
+	// Three frames 100ms apart span 200ms of recorded time.
+	for _, tc := range []struct {
+		speed    float64
+		min, max time.Duration
+	}{
+		{speed: 1, min: 180 * time.Millisecond, max: time.Second},
+		{speed: 4, min: 40 * time.Millisecond, max: 150 * time.Millisecond},
+	} {
+		rp, err := NewReplayer(recording(t, 100*time.Millisecond, "a", "b", "c"), ReplayConfig{Speed: tc.speed})
+		if err != nil {
+			t.Fatalf("NewReplayer: %v", err)
+		}
+		sub := rp.Subscribe()
+		go func() {
+			for range sub {
+			}
+		}()
+
+		start := time.Now()
+		if err := rp.Run(context.Background()); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if d := time.Since(start); d < tc.min || d > tc.max {
+			t.Fatalf("speed %v: replay took %v, want between %v and %v", tc.speed, d, tc.min, tc.max)
+		}
+	}
+}
+
+func TestReplayer_Cancel(t *testing.T) {
+	rp, _ := NewReplayer(recording(t, time.Hour, "a", "b"), ReplayConfig{Speed: 1})
+	sub := rp.Subscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- rp.Run(ctx) }()
+
+	if msg := <-sub; string(msg) != "a" {
+		t.Fatalf("expected 'a', got %q", msg)
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	if _, ok := <-sub; ok {
+		t.Fatal("subscriber channel should be closed")
+	}
+}
@@ -0,0 +1,459 @@
+package adapter
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// ShardedFeedConfig holds tunable parameters for a ShardedFeed.
+type ShardedFeedConfig struct {
+	// WS is the template for every shard's WSClient.
+	WS WSConfig
+
+	// MaxPerConn caps the subscription keys placed on one connection.
+	// Default: 100.
+	MaxPerConn int
+
+	// InitialShards is the number of connections opened by Connect.
+	// Default: 1.
+	InitialShards int
+
+	// MaxShards caps the number of connections. Once every shard is full,
+	// further keys go to the least-loaded shard. Zero means no cap.
+	MaxShards int
+
+	// Unsubscribe, if set, builds the venue message that removes key from
+	// a connection. Rebalance uses it to move keys without interrupting
+	// the source shard; without it the source shard is reconnected so its
+	// replayed registry no longer includes the moved keys.
+	Unsubscribe func(key string) []byte
+}
+
+// DefaultShardedFeedConfig returns a config spreading up to 100 keys per
+// connection, using DefaultWSConfig for every shard.
+func DefaultShardedFeedConfig(url string) ShardedFeedConfig {
+	return ShardedFeedConfig{
+		WS:            DefaultWSConfig(url),
+		MaxPerConn:    100,
+		InitialShards: 1,
+	}
+}
+
+// ShardStatus reports the load and health of one shard.
+type ShardStatus struct {
+	Index   int
+	Keys    int
+	Circuit CircuitState
+}
+
+// ShardedFeed spreads subscriptions across several WSClients to stay within
+// per-connection subscription and throughput caps. It implements Feed, so
+// adapters use it exactly like a single WSClient: every shard's messages
+// are merged into one Subscribe stream, and each key stays on one shard so
+// per-market ordering is preserved.
+type ShardedFeed struct {
+	cfg ShardedFeedConfig
+
+	mu     sync.Mutex
+	ctx    context.Context // set by Connect; nil until then
+	shards []*shard
+	assign map[string]*shard
+
+	// Merged subscribers, fed by one forwarder goroutine per shard.
+	subs *frameFanOut
+
+	evMu   sync.RWMutex
+	evSubs []chan ConnEvent
+
+	forwarders sync.WaitGroup
+	connecting sync.WaitGroup // background connectShard calls
+	cancel     context.CancelFunc
+}
+
+// shard is one connection and the subscriptions assigned to it.
+type shard struct {
+	index int
+	ws    *WSClient
+	keys  map[string]SubscribeFunc // key → builds its own subscription message
+}
+
+var _ Feed = (*ShardedFeed)(nil)
+
+// NewShardedFeed creates a ShardedFeed. Call Connect to start.
+func NewShardedFeed(cfg ShardedFeedConfig) *ShardedFeed {
+	if cfg.MaxPerConn <= 0 {
+		cfg.MaxPerConn = 100
+	}
+	if cfg.InitialShards <= 0 {
+		cfg.InitialShards = 1
+	}
+	if cfg.MaxShards > 0 && cfg.InitialShards > cfg.MaxShards {
+		cfg.InitialShards = cfg.MaxShards
+	}
+	return &ShardedFeed{
+		cfg:    cfg,
+		assign: make(map[string]*shard),
+		subs:   &frameFanOut{name: "sharded " + cfg.WS.URL},
+	}
+}
+
+// Subscribe returns a channel carrying the merged messages of every shard.
+func (sf *ShardedFeed) Subscribe() <-chan []byte {
+	return sf.SubscribeWith(SubscribeOptions{}).C()
+}
+
+// SubscribeWith is like Subscribe but applies the given buffer size and
+// backpressure policy.
+func (sf *ShardedFeed) SubscribeWith(opts SubscribeOptions) *Subscription[[]byte] {
+	return sf.subs.subscribe(opts)
+}
+
+// SubscribeFrames is like Subscribe but keeps each shard's receive time and
+// connection ID.
+func (sf *ShardedFeed) SubscribeFrames() <-chan Frame {
+	return sf.subs.subscribeFrames(SubscribeOptions{}).C()
+}
+
+// Events returns a channel of lifecycle events from every shard. Each
+// event's Keys identify the markets carried by the shard that emitted it.
+func (sf *ShardedFeed) Events() <-chan ConnEvent {
+	ch := make(chan ConnEvent, 64)
+	sf.evMu.Lock()
+	sf.evSubs = append(sf.evSubs, ch)
+	sf.evMu.Unlock()
+	return ch
+}
+
+// SendSubscription places key on a shard and sends data on it. A key that
+// is already placed is re-sent on its current shard. New shards are opened
+// on demand, up to MaxShards.
+func (sf *ShardedFeed) SendSubscription(key string, data []byte) {
+	sf.mu.Lock()
+	sh, ok := sf.assign[key]
+	if !ok {
+		sh = sf.place()
+		sf.assign[key] = sh
+	}
+	sh.keys[key] = fixedMessage(data)
+	sf.mu.Unlock()
+
+	sh.ws.SendSubscription(key, data)
+}
+
+// fixedMessage is the builder of a key sent by SendSubscription, whose
+// message does not depend on the connection.
+func fixedMessage(data []byte) SubscribeFunc {
+	return func([]string, bool) []byte { return data }
+}
+
+// SendCommand sends data on the shard carrying key. It is dropped if key is
+// not placed on any shard.
+func (sf *ShardedFeed) SendCommand(key string, data []byte) {
+	sf.mu.Lock()
+	sh, ok := sf.assign[key]
+	sf.mu.Unlock()
+	if !ok {
+		log.Printf("sharded: no shard carries %q, dropping command", key)
+		return
+	}
+	sh.ws.Send(data)
+}
+
+// SendSubscriptions places each key on a shard like SendSubscription, then
+// sends one batch per shard covering the keys placed on it, which the
+// shard replays as one message. Rebalance moves keys on their own, with
+// messages from the same builder.
+func (sf *ShardedFeed) SendSubscriptions(keys []string, build SubscribeFunc) {
+	sf.mu.Lock()
+	var order []*shard
+	groups := make(map[*shard][]string)
+	for _, k := range keys {
+		sh, ok := sf.assign[k]
+		if !ok {
+			sh = sf.place()
+			sf.assign[k] = sh
+		}
+		sh.keys[k] = build
+		if groups[sh] == nil {
+			order = append(order, sh)
+		}
+		groups[sh] = append(groups[sh], k)
+	}
+	sf.mu.Unlock()
+
+	for _, sh := range order {
+		sh.ws.sendSubscriptions(groups[sh], build)
+	}
+}
+
+// SendCommands sends one batch per shard carrying any of keys. Keys placed
+// on no shard are dropped with a log line.
+func (sf *ShardedFeed) SendCommands(keys []string, batch func(keys []string) []byte) {
+	sf.mu.Lock()
+	var order []*shard
+	groups := make(map[*shard][]string)
+	for _, k := range keys {
+		sh, ok := sf.assign[k]
+		if !ok {
+			log.Printf("sharded: no shard carries %q, dropping command", k)
+			continue
+		}
+		if groups[sh] == nil {
+			order = append(order, sh)
+		}
+		groups[sh] = append(groups[sh], k)
+	}
+	sf.mu.Unlock()
+
+	for _, sh := range order {
+		sh.ws.Send(batch(groups[sh]))
+	}
+}
+
+// ForgetSubscription removes key from its shard's replay registry, freeing
+// capacity for new keys. Like WSClient.ForgetSubscription it sends nothing
+// to the venue.
+func (sf *ShardedFeed) ForgetSubscription(key string) {
+	sf.mu.Lock()
+	sh, ok := sf.assign[key]
+	if ok {
+		delete(sf.assign, key)
+		delete(sh.keys, key)
+	}
+	sf.mu.Unlock()
+
+	if ok {
+		sh.ws.ForgetSubscription(key)
+	}
+}
+
+// place picks the shard for a new key: the least-loaded shard with spare
+// capacity, else a new shard, else the least-loaded shard. Caller holds mu.
+func (sf *ShardedFeed) place() *shard {
+	if least := sf.leastLoaded(); least != nil && len(least.keys) < sf.cfg.MaxPerConn {
+		return least
+	}
+	if sf.cfg.MaxShards == 0 || len(sf.shards) < sf.cfg.MaxShards {
+		return sf.addShard()
+	}
+	least := sf.leastLoaded()
+	log.Printf("sharded: all %d shards at capacity (%d keys each), overfilling shard %d",
+		len(sf.shards), sf.cfg.MaxPerConn, least.index)
+	return least
+}
+
+// leastLoaded returns the shard with the fewest keys, lowest index first,
+// or nil if there are no shards. Caller holds mu.
+func (sf *ShardedFeed) leastLoaded() *shard {
+	var least *shard
+	for _, sh := range sf.shards {
+		if least == nil || len(sh.keys) < len(least.keys) {
+			least = sh
+		}
+	}
+	return least
+}
+
+// addShard creates a shard and starts forwarding its messages and events.
+// If the feed is already connected, the shard connects in the background;
+// subscriptions sent before then are queued in its outbox. Caller holds mu.
+func (sf *ShardedFeed) addShard() *shard {
+	sh := &shard{
+		index: len(sf.shards),
+		ws:    NewWSClient(sf.cfg.WS),
+		keys:  make(map[string]SubscribeFunc),
+	}
+	sf.shards = append(sf.shards, sh)
+
+	frames := sh.ws.SubscribeFrames()
+	events := sh.ws.Events()
+	sf.forwarders.Add(2)
+	go sf.forwardMessages(frames)
+	go sf.forwardEvents(events)
+
+	if sf.ctx != nil {
+		sf.connecting.Add(1)
+		go sf.connectShard(sf.ctx, sh)
+	}
+	return sh
+}
+
+// Connect opens InitialShards connections, plus any shards created by
+// subscriptions sent before Connect. It fails if any of them cannot
+// connect; shards added later connect in the background with backoff.
+func (sf *ShardedFeed) Connect(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	sf.mu.Lock()
+	sf.cancel = cancel
+	for len(sf.shards) < sf.cfg.InitialShards {
+		sf.addShard()
+	}
+	shards := append([]*shard(nil), sf.shards...)
+	// Shards added from here on connect themselves in the background.
+	sf.ctx = ctx
+	sf.mu.Unlock()
+
+	for _, sh := range shards {
+		if err := sh.ws.Connect(ctx); err != nil {
+			cancel()
+			return err
+		}
+	}
+	return nil
+}
+
+// connectShard retries the initial connection of a shard opened after
+// Connect until it succeeds or ctx is cancelled.
+func (sf *ShardedFeed) connectShard(ctx context.Context, sh *shard) {
+	defer sf.connecting.Done()
+	if err := sh.ws.connectRetry(ctx); err != nil {
+		log.Printf("sharded: shard %d never connected: %v", sh.index, err)
+	}
+}
+
+// Close shuts down every shard and then closes all merged subscriber and
+// event channels.
+func (sf *ShardedFeed) Close() {
+	sf.mu.Lock()
+	if sf.cancel != nil {
+		sf.cancel()
+	}
+	shards := sf.shards
+	sf.mu.Unlock()
+
+	sf.connecting.Wait()
+	for _, sh := range shards {
+		sh.ws.Close()
+	}
+	sf.forwarders.Wait()
+
+	sf.subs.close()
+
+	sf.evMu.Lock()
+	for _, ch := range sf.evSubs {
+		close(ch)
+	}
+	sf.evSubs = nil
+	sf.evMu.Unlock()
+}
+
+// Circuit reports CircuitOpen if any shard is down, since some markets are
+// then without data. Use CircuitFor for per-market health.
+func (sf *ShardedFeed) Circuit() CircuitState {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	for _, sh := range sf.shards {
+		if sh.ws.Circuit() == CircuitOpen {
+			return CircuitOpen
+		}
+	}
+	return CircuitClosed
+}
+
+// CircuitFor returns the circuit state of the shard carrying key, or
+// CircuitOpen if key is not subscribed.
+func (sf *ShardedFeed) CircuitFor(key string) CircuitState {
+	sf.mu.Lock()
+	sh, ok := sf.assign[key]
+	sf.mu.Unlock()
+	if !ok {
+		return CircuitOpen
+	}
+	return sh.ws.Circuit()
+}
+
+// Shards returns the load and health of every shard in index order.
+func (sf *ShardedFeed) Shards() []ShardStatus {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	out := make([]ShardStatus, len(sf.shards))
+	for i, sh := range sf.shards {
+		out[i] = ShardStatus{Index: sh.index, Keys: len(sh.keys), Circuit: sh.ws.Circuit()}
+	}
+	return out
+}
+
+// Rebalance evens out keys across the existing shards, typically after many
+// keys were forgotten or a shard was overfilled. Moved keys are subscribed
+// on their new shard before being removed from the old one, so a market
+// may briefly arrive twice but never goes missing. It returns the number
+// of keys moved.
+func (sf *ShardedFeed) Rebalance() int {
+	type move struct {
+		key      string
+		build    SubscribeFunc
+		from, to *shard
+	}
+
+	sf.mu.Lock()
+	if len(sf.shards) < 2 {
+		sf.mu.Unlock()
+		return 0
+	}
+	target := (len(sf.assign) + len(sf.shards) - 1) / len(sf.shards)
+	if target > sf.cfg.MaxPerConn {
+		target = sf.cfg.MaxPerConn
+	}
+
+	var moves []move
+	for _, from := range sf.shards {
+		for key, build := range from.keys {
+			if len(from.keys) <= target {
+				break
+			}
+			to := sf.leastLoaded()
+			if len(to.keys) >= target || len(to.keys)+1 >= len(from.keys) {
+				break
+			}
+			delete(from.keys, key)
+			to.keys[key] = build
+			sf.assign[key] = to
+			moves = append(moves, move{key: key, build: build, from: from, to: to})
+		}
+	}
+	sf.mu.Unlock()
+
+	recycle := make(map[*shard]bool)
+	for _, m := range moves {
+		m.to.ws.sendSubscriptions([]string{m.key}, m.build)
+		m.from.ws.ForgetSubscription(m.key)
+		if sf.cfg.Unsubscribe != nil {
+			m.from.ws.Send(sf.cfg.Unsubscribe(m.key))
+		} else {
+			recycle[m.from] = true
+		}
+	}
+	for sh := range recycle {
+		sh.ws.recycle()
+	}
+	return len(moves)
+}
+
+// forwardMessages copies one shard's frames into the merged stream.
+func (sf *ShardedFeed) forwardMessages(frames <-chan Frame) {
+	defer sf.forwarders.Done()
+	for f := range frames {
+		sf.subs.deliver(f)
+	}
+}
+
+// forwardEvents copies one shard's lifecycle events to Events subscribers.
+// Closed events are dropped; the merged channels close with the feed.
+func (sf *ShardedFeed) forwardEvents(events <-chan ConnEvent) {
+	defer sf.forwarders.Done()
+	for ev := range events {
+		if ev.Type == ConnClosed {
+			continue
+		}
+		sf.evMu.RLock()
+		for _, ch := range sf.evSubs {
+			select {
+			case ch <- ev:
+			default:
+			}
+		}
+		sf.evMu.RUnlock()
+	}
+}
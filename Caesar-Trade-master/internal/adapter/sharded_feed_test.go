@@ -0,0 +1,282 @@
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// connMsg is a message received by shardServer, tagged with the server-side
+// connection it arrived on.
+type connMsg struct {
+	conn int
+	msg  string
+}
+
+// shardServer echoes every message and reports it tagged with the index of
+// the accepting connection.
+func shardServer(t *testing.T) (*httptest.Server, <-chan connMsg) {
+	t.Helper()
+	ch := make(chan connMsg, 64)
+	var mu sync.Mutex
+	next := 0
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		mu.Lock()
+		idx := next
+		next++
+		mu.Unlock()
+		for {
+			mt, msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			ch <- connMsg{conn: idx, msg: string(msg)}
+			if err := c.WriteMessage(mt, msg); err != nil {
+				return
+			}
+		}
+	}))
+	return srv, ch
+}
+
+func TestShardedFeed_SpreadsAndMerges(t *testing.T) {
+	srv, received := shardServer(t)
+	defer srv.Close()
+
+	cfg := DefaultShardedFeedConfig(wsURL(srv))
+	cfg.MaxPerConn = 2
+	sf := NewShardedFeed(cfg)
+	merged := sf.Subscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := sf.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer sf.Close()
+
+	keys := []string{"k1", "k2", "k3", "k4", "k5"}
+	for _, k := range keys {
+		sf.SendSubscription(k, []byte("sub:"+k))
+	}
+
+	// Every key lands on a connection holding at most two keys.
+	perConn := make(map[int]int)
+	for range keys {
+		select {
+		case m := <-received:
+			perConn[m.conn]++
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for subscriptions")
+		}
+	}
+	if len(perConn) != 3 {
+		t.Fatalf("expected 3 connections, got %v", perConn)
+	}
+	for conn, n := range perConn {
+		if n > 2 {
+			t.Fatalf("connection %d carries %d keys, max is 2", conn, n)
+		}
+	}
+
+	var loads []int
+	for _, s := range sf.Shards() {
+		loads = append(loads, s.Keys)
+	}
+	if len(loads) != 3 || loads[0] != 2 || loads[1] != 2 || loads[2] != 1 {
+		t.Fatalf("unexpected shard loads: %v", loads)
+	}
+
+	// Echoes from every shard arrive on the single merged stream.
+	var got []string
+	for range keys {
+		select {
+		case msg := <-merged:
+			got = append(got, string(msg))
+		case <-ctx.Done():
+			t.Fatalf("timed out on merged stream, got %v", got)
+		}
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != "sub:k1,sub:k2,sub:k3,sub:k4,sub:k5" {
+		t.Fatalf("unexpected merged messages: %v", got)
+	}
+}
+
+func TestShardedFeed_Rebalance(t *testing.T) {
+	srv, received := shardServer(t)
+	defer srv.Close()
+
+	cfg := DefaultShardedFeedConfig(wsURL(srv))
+	cfg.InitialShards = 2
+	cfg.Unsubscribe = func(key string) []byte { return []byte("unsub:" + key) }
+	sf := NewShardedFeed(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := sf.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer sf.Close()
+
+	// Placement alternates: a,c on shard 0 and b,d on shard 1.
+	for _, k := range []string{"a", "b", "c", "d"} {
+		sf.SendSubscription(k, []byte("sub:"+k))
+	}
+	for i := 0; i < 4; i++ {
+		<-received
+	}
+	sf.ForgetSubscription("b")
+	sf.ForgetSubscription("d")
+
+	if n := sf.Rebalance(); n != 1 {
+		t.Fatalf("expected 1 key moved, got %d", n)
+	}
+	shards := sf.Shards()
+	if shards[0].Keys != 1 || shards[1].Keys != 1 {
+		t.Fatalf("expected 1 key per shard, got %+v", shards)
+	}
+
+	// The moved key is subscribed on its new connection and unsubscribed
+	// on the old one.
+	var subConn, unsubConn = -1, -1
+	var moved string
+	for subConn < 0 || unsubConn < 0 {
+		select {
+		case m := <-received:
+			switch {
+			case strings.HasPrefix(m.msg, "sub:"):
+				subConn, moved = m.conn, strings.TrimPrefix(m.msg, "sub:")
+			case strings.HasPrefix(m.msg, "unsub:"):
+				unsubConn = m.conn
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for rebalance messages")
+		}
+	}
+	if subConn == unsubConn {
+		t.Fatalf("key %s re-subscribed on the connection it left", moved)
+	}
+	if sf.CircuitFor(moved) != CircuitClosed {
+		t.Fatalf("moved key %s should be on a healthy shard", moved)
+	}
+}
+
+func TestCircuitBreaker_DeadShardHaltsOnlyItsMarkets(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	cfg := DefaultShardedFeedConfig(wsURL(srv))
+	cfg.MaxPerConn = 1
+	sf := NewShardedFeed(cfg)
+	sf.SendSubscription("asset-a", []byte("sub-a"))
+	sf.SendSubscription("asset-b", []byte("sub-b"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := sf.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer sf.Close()
+
+	clock := newFakeClock(time.Now())
+	cb, feed := newTestBreaker(clock)
+	go cb.Run(ctx)
+	cb.WatchConnection(ExchangePolymarket, sf)
+
+	update := func() {
+		feed <- BookUpdate{Exchange: ExchangePolymarket, MarketID: "mkt-a", AssetID: "asset-a"}
+		feed <- BookUpdate{Exchange: ExchangePolymarket, MarketID: "mkt-b", AssetID: "asset-b"}
+		time.Sleep(20 * time.Millisecond)
+	}
+	update()
+	clock.Advance(3 * time.Second)
+	update()
+	if !cb.CanTrade(ExchangePolymarket, "mkt-a") || !cb.CanTrade(ExchangePolymarket, "mkt-b") {
+		t.Fatal("expected both markets tradable")
+	}
+
+	// Shard 0 (asset-a) goes down.
+	dead := sf.shards[0].ws
+	dead.circuit.Store(int32(CircuitOpen))
+	dead.emit(ConnEvent{Type: ConnDisconnected, Reason: context.DeadlineExceeded})
+	time.Sleep(20 * time.Millisecond)
+
+	if cb.CanTrade(ExchangePolymarket, "mkt-a") {
+		t.Fatal("market on dead shard should be halted")
+	}
+	if !cb.CanTrade(ExchangePolymarket, "mkt-b") {
+		t.Fatal("market on healthy shard should stay tradable")
+	}
+}
+
+func TestShardedFeed_BatchesPerShard(t *testing.T) {
+	srv, received := shardServer(t)
+	defer srv.Close()
+
+	cfg := DefaultShardedFeedConfig(wsURL(srv))
+	cfg.MaxPerConn = 2
+	sf := NewShardedFeed(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := sf.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer sf.Close()
+
+	build := func(keys []string, initial bool) []byte {
+		if !initial {
+			return []byte("add:" + strings.Join(keys, "+"))
+		}
+		return []byte("sub:" + strings.Join(keys, "+"))
+	}
+	batch := func(prefix string) func([]string) []byte {
+		return func(keys []string) []byte { return []byte(prefix + strings.Join(keys, "+")) }
+	}
+	collect := func(n int) map[int]string {
+		out := make(map[int]string)
+		for i := 0; i < n; i++ {
+			select {
+			case m := <-received:
+				out[m.conn] = m.msg
+			case <-ctx.Done():
+				t.Fatalf("timed out, got %v", out)
+			}
+		}
+		return out
+	}
+
+	// One message per shard, covering the keys placed on it.
+	sf.SendSubscriptions([]string{"k1", "k2", "k3"}, build)
+	if got := collect(2); got[0] != "sub:k1+k2" || got[1] != "sub:k3" {
+		t.Fatalf("unexpected batches: %v", got)
+	}
+	if loads := sf.Shards(); loads[0].Keys != 2 || loads[1].Keys != 1 {
+		t.Fatalf("unexpected shard loads: %+v", loads)
+	}
+
+	sf.SendCommands([]string{"k3", "k2", "unknown"}, batch("unsub:"))
+	if got := collect(2); got[0] != "unsub:k2" || got[1] != "unsub:k3" {
+		t.Fatalf("unexpected commands: %v", got)
+	}
+
+	// A shard already subscribed sends additions.
+	sf.SendSubscriptions([]string{"k4"}, build)
+	if got := collect(1); got[1] != "add:k4" {
+		t.Fatalf("unexpected addition: %v", got)
+	}
+}
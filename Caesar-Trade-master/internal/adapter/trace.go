@@ -0,0 +1,70 @@
+package adapter
+
+import "time"
+
+// Stage identifies a point in the market-data pipeline at which an update
+// is timestamped.
+type Stage int
+
+const (
+	StageReceived    Stage = iota // frame read by WSClient
+	StageParsed                   // adapter produced the BookUpdate
+	StageDistributed              // Broadcaster fanned it out
+	StageApplied                  // UnifiedBook merged it into a pair
+	StagePersisted                // RedisWriter wrote it
+	StageEmitted                  // UnifiedBook emitted an ArbitrageEvent
+	numStages
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageReceived:
+		return "received"
+	case StageParsed:
+		return "parsed"
+	case StageDistributed:
+		return "distributed"
+	case StageApplied:
+		return "applied"
+	case StagePersisted:
+		return "persisted"
+	case StageEmitted:
+		return "emitted"
+	default:
+		return "unknown"
+	}
+}
+
+// Trace records when each pipeline stage handled an update. Stamps come
+// from time.Now and keep its monotonic clock reading, so differences
+// between stages are immune to wall-clock adjustments. Trace is a value:
+// every copy of a BookUpdate carries its own, and stages marked by one
+// consumer are not visible to another.
+type Trace struct {
+	stamps [numStages]time.Time
+}
+
+// Mark stamps stage s with the current time.
+func (t *Trace) Mark(s Stage) {
+	t.stamps[s] = time.Now()
+}
+
+// MarkAt stamps stage s with at, e.g. a frame's receive time.
+func (t *Trace) MarkAt(s Stage, at time.Time) {
+	t.stamps[s] = at
+}
+
+// At returns the time stage s was marked, or the zero time.
+func (t Trace) At(s Stage) time.Time {
+	return t.stamps[s]
+}
+
+// Since returns the time between stages from and to, or zero if either
+// was not marked.
+func (t Trace) Since(from, to Stage) time.Duration {
+	a, b := t.stamps[from], t.stamps[to]
+	if a.IsZero() || b.IsZero() {
+		return 0
+	}
+	return b.Sub(a)
+}
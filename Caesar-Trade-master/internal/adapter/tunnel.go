@@ -26,14 +26,10 @@ type TunnelConfig struct {
 	Exchange Exchange
 	URL      string
 	Headers  http.Header // auth headers (RSA-PSS for Kalshi, EIP-712 for Poly)
-}
 
-// IPPool is a placeholder for future IP rotation to avoid exchange
-// rate-limiting. Implementations will provide a rotating set of local
-// addresses or proxy endpoints.
-type IPPool interface {
-	// Next returns the next outbound IP address or proxy URL to use.
-	Next() string
+	// HeaderProvider, if set, re-computes the user's auth headers on every
+	// dial so reconnects present a fresh signature. See WSConfig.
+	HeaderProvider HeaderProvider
 }
 
 // TunnelManager manages private, authenticated WebSocket sessions keyed by
@@ -42,7 +38,7 @@ type IPPool interface {
 type TunnelManager struct {
 	mu      sync.Mutex
 	tunnels map[tunnelKey]*Tunnel
-	pool    IPPool // nil until IP rotation is configured
+	pool    IPPool // nil dials directly
 }
 
 type tunnelKey struct {
@@ -75,10 +71,13 @@ func (tm *TunnelManager) Open(ctx context.Context, cfg TunnelConfig) (*Tunnel, e
 		existing.close()
 		delete(tm.tunnels, key)
 	}
+	pool := tm.pool
 	tm.mu.Unlock()
 
 	wsCfg := DefaultWSConfig(cfg.URL)
 	wsCfg.Headers = cfg.Headers
+	wsCfg.HeaderProvider = cfg.HeaderProvider
+	wsCfg.IPPool = pool
 
 	ws := NewWSClient(wsCfg)
 	msgs := ws.Subscribe()
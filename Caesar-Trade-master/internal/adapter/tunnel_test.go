@@ -2,9 +2,12 @@ package adapter
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -176,3 +179,76 @@ func TestTunnelManager_DataIsolation(t *testing.T) {
 		// Good — isolated.
 	}
 }
+
+func TestTunnelManager_HeaderProviderPerDial(t *testing.T) {
+	seen := make(chan http.Header, 4)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		seen <- r.Header.Clone()
+		c.Close() // force a reconnect after the first handshake
+	}))
+	defer srv.Close()
+
+	var calls atomic.Int32
+	provider := func(ctx context.Context) (http.Header, error) {
+		h := http.Header{}
+		h.Set("X-Signature", fmt.Sprintf("user-1-sig-%d", calls.Add(1)))
+		return h, nil
+	}
+
+	tm := NewTunnelManager()
+	defer tm.CloseAll()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tm.Open(ctx, TunnelConfig{
+		UserID:         "user-1",
+		Exchange:       ExchangeKalshi,
+		URL:            toWS(srv),
+		Headers:        http.Header{"X-Api-Key": {"key-1"}},
+		HeaderProvider: provider,
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		select {
+		case h := <-seen:
+			if got, want := h.Get("X-Signature"), fmt.Sprintf("user-1-sig-%d", i); got != want {
+				t.Fatalf("handshake %d: expected signature %q, got %q", i, want, got)
+			}
+			if h.Get("X-Api-Key") != "key-1" {
+				t.Fatalf("handshake %d: static header lost", i)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for handshake %d", i)
+		}
+	}
+}
+
+func TestTunnelManager_HeaderProviderError(t *testing.T) {
+	srv := echoServer(t)
+	defer srv.Close()
+
+	tm := NewTunnelManager()
+	_, err := tm.Open(context.Background(), TunnelConfig{
+		UserID:   "user-1",
+		Exchange: ExchangeKalshi,
+		URL:      toWS(srv),
+		HeaderProvider: func(context.Context) (http.Header, error) {
+			return nil, errors.New("credentials revoked")
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "credentials revoked") {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+	if tm.Get("user-1", ExchangeKalshi) != nil {
+		t.Fatal("failed tunnel should not be registered")
+	}
+}
@@ -1,6 +1,9 @@
 package adapter
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 // Exchange identifies the source of market data.
 type Exchange string
@@ -27,4 +30,58 @@ type BookUpdate struct {
 	Asks      []PriceLevel
 	Timestamp time.Time
 	Hash      string
+
+	// ExchangeTime is the venue-reported event time, or zero if the venue
+	// did not report one. Timestamp falls back to the receive time then.
+	ExchangeTime time.Time
+
+	// Trace records when each pipeline stage handled this update.
+	Trace Trace
+}
+
+// Complement returns the book of the opposite outcome of a binary market:
+// a bid at p on one outcome is an ask at 1−p on the other, and vice versa.
+// Kalshi updates are YES-centric, so their complement is the NO-centric
+// view whose bids are Kalshi's raw NO bids. Level order is preserved, so a
+// best-first book stays best-first.
+func (u BookUpdate) Complement() BookUpdate {
+	c := u
+	c.Bids = complementLevels(u.Asks)
+	c.Asks = complementLevels(u.Bids)
+	return c
+}
+
+func complementLevels(levels []PriceLevel) []PriceLevel {
+	if levels == nil {
+		return nil
+	}
+	out := make([]PriceLevel, len(levels))
+	for i, l := range levels {
+		// Round off the float noise of 1−p (1−0.7 = 0.30000000000000004).
+		out[i] = PriceLevel{Price: math.Round((1-l.Price)*1e9) / 1e9, Size: l.Size}
+	}
+	return out
+}
+
+// TradeSide is the aggressor side of a trade, in terms of the quoted asset
+// (the YES contract on Kalshi).
+type TradeSide string
+
+const (
+	SideBuy  TradeSide = "buy"  // taker lifted an ask
+	SideSell TradeSide = "sell" // taker hit a bid
+)
+
+// Trade is the unified trade print used across all exchange adapters.
+type Trade struct {
+	Exchange Exchange
+	MarketID string
+	AssetID  string
+	Price    float64
+	Size     float64
+	Side     TradeSide
+
+	// Timestamp is the venue-reported execution time, or the receive time
+	// if the venue did not report one.
+	Timestamp time.Time
 }
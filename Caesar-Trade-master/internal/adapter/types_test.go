@@ -0,0 +1,39 @@
+package adapter
+
+import "testing"
+
+func TestBookUpdate_Complement(t *testing.T) {
+	yes := BookUpdate{
+		Exchange: ExchangeKalshi,
+		MarketID: "m",
+		Bids:     []PriceLevel{{Price: 0.52, Size: 150}, {Price: 0.48, Size: 300}},
+		Asks:     []PriceLevel{{Price: 0.7, Size: 100}, {Price: 0.75, Size: 200}},
+	}
+
+	no := yes.Complement()
+	want := BookUpdate{
+		Bids: []PriceLevel{{Price: 0.3, Size: 100}, {Price: 0.25, Size: 200}},
+		Asks: []PriceLevel{{Price: 0.48, Size: 150}, {Price: 0.52, Size: 300}},
+	}
+	for i, l := range no.Bids {
+		if l != want.Bids[i] {
+			t.Fatalf("bid[%d]: want %+v, got %+v", i, want.Bids[i], l)
+		}
+	}
+	for i, l := range no.Asks {
+		if l != want.Asks[i] {
+			t.Fatalf("ask[%d]: want %+v, got %+v", i, want.Asks[i], l)
+		}
+	}
+	if no.MarketID != "m" || no.Exchange != ExchangeKalshi {
+		t.Fatal("complement should keep identity fields")
+	}
+
+	back := no.Complement()
+	if back.Bids[0] != yes.Bids[0] || back.Asks[1] != yes.Asks[1] {
+		t.Fatalf("double complement should round-trip, got %+v", back)
+	}
+	if yes.Bids[0].Price != 0.52 {
+		t.Fatal("complement must not modify the original")
+	}
+}
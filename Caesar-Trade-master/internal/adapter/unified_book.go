@@ -11,6 +11,10 @@ type MarketPair struct {
 	Name          string // human-readable label, e.g. "BTC > $100k"
 	PolyMarketID  string // Polymarket market / condition ID
 	KalshiMarketID string // Kalshi market ID
+
+	// KalshiNo lines the Polymarket asset up against Kalshi's NO outcome.
+	// Kalshi updates are YES-centric, so they are complemented first.
+	KalshiNo bool
 }
 
 // ArbitrageEvent is emitted when a crossed-book opportunity is detected.
@@ -23,6 +27,10 @@ type ArbitrageEvent struct {
 	Ask       float64   // best ask on the ask exchange
 	Spread    float64   // bid − ask (positive = opportunity)
 	Timestamp time.Time
+
+	// Trace is the trace of the update that triggered the event, with
+	// StageEmitted marked.
+	Trace Trace
 }
 
 // ArbitrageDirection indicates which exchange is cheap vs expensive.
@@ -57,8 +65,12 @@ type UnifiedBook struct {
 
 	mu     sync.RWMutex
 	states map[string]*pairState // keyed by MarketPair.Name
+	runCtx context.Context       // set while Run is active; guarded by mu
+	wg     sync.WaitGroup
 
 	events chan ArbitrageEvent
+
+	latency *LatencyTracker // nil disables recording
 }
 
 // NewUnifiedBook creates a UnifiedBook. The threshold is the minimum
@@ -73,17 +85,38 @@ func NewUnifiedBook(bc *Broadcaster, threshold float64) *UnifiedBook {
 	}
 }
 
+// SetLatencyTracker records apply and emit latencies into lt. Must be
+// called before Run.
+func (ub *UnifiedBook) SetLatencyTracker(lt *LatencyTracker) {
+	ub.latency = lt
+}
+
 // Events returns the channel of detected arbitrage opportunities.
 func (ub *UnifiedBook) Events() <-chan ArbitrageEvent {
 	return ub.events
 }
 
 // AddPair registers a market pair and subscribes to both exchanges on the
-// Broadcaster. Must be called before Run.
+// Broadcaster. A pair added during Run is watched at once and starts from
+// the Broadcaster's cached books.
 func (ub *UnifiedBook) AddPair(pair MarketPair) {
 	ub.mu.Lock()
+	defer ub.mu.Unlock()
 	ub.states[pair.Name] = &pairState{Pair: pair}
-	ub.mu.Unlock()
+	if ub.runCtx != nil {
+		ub.watch(ub.runCtx, pair)
+	}
+}
+
+// Pair returns the registered pair with the given name.
+func (ub *UnifiedBook) Pair(name string) (MarketPair, bool) {
+	ub.mu.RLock()
+	defer ub.mu.RUnlock()
+	ps, ok := ub.states[name]
+	if !ok {
+		return MarketPair{}, false
+	}
+	return ps.Pair, true
 }
 
 // Snapshot returns the current merged state for a pair, or false if not found.
@@ -100,32 +133,35 @@ func (ub *UnifiedBook) Snapshot(pairName string) (pairState, bool) {
 // Run subscribes to both sides of every registered pair and processes
 // updates. It blocks until ctx is cancelled.
 func (ub *UnifiedBook) Run(ctx context.Context) {
-	ub.mu.RLock()
-	pairs := make([]MarketPair, 0, len(ub.states))
+	ub.mu.Lock()
+	ub.runCtx = ctx
 	for _, ps := range ub.states {
-		pairs = append(pairs, ps.Pair)
+		ub.watch(ctx, ps.Pair)
 	}
-	ub.mu.RUnlock()
-
-	var wg sync.WaitGroup
-
-	for _, pair := range pairs {
-		polyCh := ub.bc.Subscribe(ExchangePolymarket, pair.PolyMarketID)
-		kalshiCh := ub.bc.Subscribe(ExchangeKalshi, pair.KalshiMarketID)
+	ub.mu.Unlock()
 
-		wg.Add(2)
-		go func(p MarketPair, ch <-chan BookUpdate) {
-			defer wg.Done()
-			ub.consumeSide(ctx, p, ExchangePolymarket, ch)
-		}(pair, polyCh)
+	<-ctx.Done()
+	ub.mu.Lock()
+	ub.runCtx = nil
+	ub.mu.Unlock()
+	ub.wg.Wait()
+}
 
-		go func(p MarketPair, ch <-chan BookUpdate) {
-			defer wg.Done()
-			ub.consumeSide(ctx, p, ExchangeKalshi, ch)
-		}(pair, kalshiCh)
-	}
+// watch subscribes to both sides of pair and consumes them until ctx is
+// done. Callers hold mu, which keeps it from racing the final Wait in Run.
+func (ub *UnifiedBook) watch(ctx context.Context, pair MarketPair) {
+	polyCh := ub.bc.Subscribe(ctx, ExchangePolymarket, pair.PolyMarketID)
+	kalshiCh := ub.bc.Subscribe(ctx, ExchangeKalshi, pair.KalshiMarketID)
 
-	wg.Wait()
+	ub.wg.Add(2)
+	go func() {
+		defer ub.wg.Done()
+		ub.consumeSide(ctx, pair, ExchangePolymarket, polyCh)
+	}()
+	go func() {
+		defer ub.wg.Done()
+		ub.consumeSide(ctx, pair, ExchangeKalshi, kalshiCh)
+	}()
 }
 
 func (ub *UnifiedBook) consumeSide(ctx context.Context, pair MarketPair, exchange Exchange, ch <-chan BookUpdate) {
@@ -143,8 +179,16 @@ func (ub *UnifiedBook) consumeSide(ctx context.Context, pair MarketPair, exchang
 }
 
 func (ub *UnifiedBook) applyUpdate(pair MarketPair, exchange Exchange, update BookUpdate) {
-	bestBid := bestHigh(update.Bids)
-	bestAsk := bestLow(update.Asks)
+	if exchange == ExchangeKalshi && pair.KalshiNo {
+		update = update.Complement()
+	}
+	var bestBid, bestAsk float64
+	if b, ok := update.BestBid(); ok {
+		bestBid = b.Price
+	}
+	if a, ok := update.BestAsk(); ok {
+		bestAsk = a.Price
+	}
 
 	ub.mu.Lock()
 	ps := ub.states[pair.Name]
@@ -158,10 +202,14 @@ func (ub *UnifiedBook) applyUpdate(pair MarketPair, exchange Exchange, update Bo
 	kalshi := ps.Kalshi
 	ub.mu.Unlock()
 
-	ub.checkArbitrage(pair, poly, kalshi)
+	update.Trace.Mark(StageApplied)
+	ub.latency.ObserveStage(update, StageApplied)
+
+	ub.checkArbitrage(pair, poly, kalshi, update)
 }
 
-func (ub *UnifiedBook) checkArbitrage(pair MarketPair, poly, kalshi side) {
+// checkArbitrage compares both sides of pair after trigger was applied.
+func (ub *UnifiedBook) checkArbitrage(pair MarketPair, poly, kalshi side, trigger BookUpdate) {
 	// Direction 1: Poly bid > Kalshi ask
 	if kalshi.BestAsk > 0 {
 		spread := poly.BestBid - kalshi.BestAsk
@@ -175,7 +223,7 @@ func (ub *UnifiedBook) checkArbitrage(pair MarketPair, poly, kalshi side) {
 				Ask:         kalshi.BestAsk,
 				Spread:      spread,
 				Timestamp:   time.Now(),
-			})
+			}, trigger)
 		}
 	}
 
@@ -192,43 +240,19 @@ func (ub *UnifiedBook) checkArbitrage(pair MarketPair, poly, kalshi side) {
 				Ask:         poly.BestAsk,
 				Spread:      spread,
 				Timestamp:   time.Now(),
-			})
+			}, trigger)
 		}
 	}
 }
 
-func (ub *UnifiedBook) emit(ev ArbitrageEvent) {
+func (ub *UnifiedBook) emit(ev ArbitrageEvent, trigger BookUpdate) {
+	trigger.Trace.Mark(StageEmitted)
+	ev.Trace = trigger.Trace
+	ub.latency.ObserveStage(trigger, StageEmitted)
+
 	select {
 	case ub.events <- ev:
 	default:
 		// Events channel full — drop to avoid blocking the hot path.
 	}
 }
-
-// bestHigh returns the highest price from a set of bids.
-func bestHigh(levels []PriceLevel) float64 {
-	if len(levels) == 0 {
-		return 0
-	}
-	best := levels[0].Price
-	for _, l := range levels[1:] {
-		if l.Price > best {
-			best = l.Price
-		}
-	}
-	return best
-}
-
-// bestLow returns the lowest price from a set of asks.
-func bestLow(levels []PriceLevel) float64 {
-	if len(levels) == 0 {
-		return 0
-	}
-	best := levels[0].Price
-	for _, l := range levels[1:] {
-		if l.Price < best {
-			best = l.Price
-		}
-	}
-	return best
-}
@@ -193,3 +193,81 @@ func TestUnifiedBook_NegativeSpreadNoEvent(t *testing.T) {
 		// Good — no event.
 	}
 }
+
+func TestUnifiedBook_KalshiNoPair(t *testing.T) {
+	pair := testPair
+	pair.KalshiNo = true
+	ub, poly, kalshi, cancel := setupUnifiedBook(t, 0, pair)
+	defer cancel()
+
+	// Poly asset tracks Kalshi NO. Kalshi YES bid 0.30 is a NO ask at 0.70,
+	// below the Poly bid of 0.75.
+	poly.send(BookUpdate{
+		Exchange:  ExchangePolymarket,
+		MarketID:  "0xbtc100k",
+		Bids:      []PriceLevel{{Price: 0.75, Size: 100}},
+		Asks:      []PriceLevel{{Price: 0.78, Size: 50}},
+		Timestamp: time.Now(),
+	})
+	kalshi.send(BookUpdate{
+		Exchange:  ExchangeKalshi,
+		MarketID:  "BTC-100K",
+		Bids:      []PriceLevel{{Price: 0.30, Size: 200}},
+		Asks:      []PriceLevel{{Price: 0.33, Size: 80}},
+		Timestamp: time.Now(),
+	})
+
+	select {
+	case ev := <-ub.Events():
+		if ev.Direction != ArbPolyBidKalshiAsk || ev.Ask != 0.70 {
+			t.Fatalf("expected Poly bid vs Kalshi NO ask 0.70, got %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for arbitrage event")
+	}
+}
+
+func TestUnifiedBook_PairAddedDuringRun(t *testing.T) {
+	ub, poly, kalshi, cancel := setupUnifiedBook(t, 0, testPair)
+	defer cancel()
+
+	// Books for the late pair arrive before anyone watches it.
+	poly.send(BookUpdate{
+		Exchange: ExchangePolymarket,
+		MarketID: "0xeth",
+		Bids:     []PriceLevel{{Price: 0.30, Size: 10}},
+		Asks:     []PriceLevel{{Price: 0.33, Size: 10}},
+	})
+	kalshi.send(BookUpdate{
+		Exchange: ExchangeKalshi,
+		MarketID: "ETH-5K",
+		Bids:     []PriceLevel{{Price: 0.29, Size: 10}},
+		Asks:     []PriceLevel{{Price: 0.31, Size: 10}},
+	})
+	deadline := time.After(time.Second)
+	for {
+		if len(ub.bc.Latest(ExchangePolymarket, "0xeth")) > 0 && len(ub.bc.Latest(ExchangeKalshi, "ETH-5K")) > 0 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for books to be cached")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	ub.AddPair(MarketPair{Name: "ETH > $5k", PolyMarketID: "0xeth", KalshiMarketID: "ETH-5K"})
+
+	deadline = time.After(time.Second)
+	for {
+		snap, ok := ub.Snapshot("ETH > $5k")
+		if ok && snap.Poly.BestBid == 0.30 && snap.Kalshi.BestAsk == 0.31 {
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("late pair never primed, got %+v", snap)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
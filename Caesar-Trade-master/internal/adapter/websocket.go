@@ -2,10 +2,14 @@ package adapter
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"math"
 	"net"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -31,10 +35,30 @@ type WSConfig struct {
 	ReadBufferSize  int
 	WriteBufferSize int
 
-	// HeartbeatTimeout is the maximum duration of silence before the client
-	// considers the connection dead and triggers a reconnect.
+	// HeartbeatTimeout is the maximum duration without any inbound frame
+	// (data, pong, server ping, or application heartbeat) before the client
+	// considers the connection dead and triggers a reconnect. It measures
+	// connection health only; data freshness is tracked separately via
+	// LastDataAt and enforced per market by the CircuitBreaker.
 	HeartbeatTimeout time.Duration
 
+	// PingInterval is how often a WebSocket ping control frame is sent to
+	// solicit a pong. It must be well below HeartbeatTimeout so that quiet
+	// but healthy connections are not torn down. Zero disables pings.
+	PingInterval time.Duration
+
+	// AppPing, when non-empty, is sent as a text message every
+	// AppPingInterval for venues that expect an application-level
+	// heartbeat (e.g. Polymarket's "PING").
+	AppPing         []byte
+	AppPingInterval time.Duration
+
+	// IsHeartbeat reports whether an inbound message is an application-level
+	// heartbeat reply (e.g. Polymarket's "PONG"). Heartbeats refresh
+	// liveness but are not fanned out to subscribers. Nil treats every
+	// message as data.
+	IsHeartbeat func(msg []byte) bool
+
 	// Backoff parameters for reconnection.
 	BackoffInitial time.Duration
 	BackoffMax     time.Duration
@@ -42,15 +66,33 @@ type WSConfig struct {
 
 	// Headers sent during the WebSocket handshake.
 	Headers http.Header
+
+	// HeaderProvider, if set, is called before every dial, including
+	// reconnects, and its headers are merged over Headers. Use it for
+	// credentials that expire, such as timestamped signatures.
+	HeaderProvider HeaderProvider
+
+	// IPPool, if set, supplies the outbound route (source address or proxy)
+	// for every dial. Failed dials are reported back to the pool.
+	IPPool IPPool
+
+	// Recorder, if set, captures every inbound message, heartbeats
+	// included, with its receive time and connection ID for later replay.
+	Recorder *Recorder
 }
 
+// HeaderProvider returns handshake headers for a single dial attempt. An
+// error aborts that attempt; during reconnects it is retried with backoff.
+type HeaderProvider func(ctx context.Context) (http.Header, error)
+
 // DefaultWSConfig returns sensible defaults tuned for low-latency market data.
 func DefaultWSConfig(url string) WSConfig {
 	return WSConfig{
 		URL:              url,
 		ReadBufferSize:   4096,
 		WriteBufferSize:  4096,
-		HeartbeatTimeout: 500 * time.Millisecond,
+		HeartbeatTimeout: 3 * time.Second,
+		PingInterval:     1 * time.Second,
 		BackoffInitial:   50 * time.Millisecond,
 		BackoffMax:       5 * time.Second,
 		BackoffFactor:    2.0,
@@ -66,27 +108,64 @@ type WSClient struct {
 	// circuit exposes connection health for the circuit breaker (Ticket 2.8).
 	circuit atomic.Int32
 
-	mu   sync.RWMutex
-	conn *websocket.Conn
+	// lastData is the receive time (Unix nanoseconds) of the most recent
+	// data message, excluding control frames and heartbeats.
+	lastData atomic.Int64
+
+	mu     sync.RWMutex
+	conn   *websocket.Conn
+	connID uint64
 
 	// subscribers receive copies of every inbound message.
-	subMu sync.RWMutex
-	subs  []chan []byte
+	subs *frameFanOut
 
 	// outbox for sending messages through the connection.
 	outbox chan []byte
 
-	cancel context.CancelFunc
-	done   chan struct{}
+	// registry holds subscription messages in the order they were first
+	// sent, one entry per message, so a batch is replayed as the single
+	// message it was sent as. It is replayed onto every new connection
+	// before the circuit closes. regMu is held across replay and
+	// connection swap so a concurrent SendSubscription lands either in the
+	// replay or on the new connection, never in between.
+	regMu    sync.Mutex
+	registry []subscription
+
+	// subscribed reports whether a subscription has been sent since the
+	// connection opened, so later batches are built as additions. Guarded
+	// by regMu.
+	subscribed bool
+
+	// evSubs receive connection lifecycle events (see Events).
+	evMu   sync.RWMutex
+	evSubs []chan ConnEvent
+
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+}
 
-	// onReconnect is called after each successful reconnection (testing hook).
-	onReconnect func()
+// connIDs hands out process-unique connection IDs, so frames recorded by
+// several clients into one file remain distinguishable.
+var connIDs atomic.Uint64
+
+// subscription is a registered message replayed after every reconnect.
+// An entry sent by SendSubscription carries one key; a batch carries every
+// key it subscribed, and build rebuilds its message once keys are
+// forgotten or it moves to or from the front of the replay.
+type subscription struct {
+	keys    []string
+	data    []byte
+	build   SubscribeFunc // nil for entries sent by SendSubscription
+	initial bool          // data was built as a connection's first message
+	stale   bool          // data still covers forgotten keys
 }
 
 // NewWSClient creates a new WebSocket client. Call Connect to start.
 func NewWSClient(cfg WSConfig) *WSClient {
 	return &WSClient{
 		cfg:    cfg,
+		subs:   &frameFanOut{name: "ws " + cfg.URL},
 		outbox: make(chan []byte, 256),
 		done:   make(chan struct{}),
 	}
@@ -97,14 +176,44 @@ func (ws *WSClient) Circuit() CircuitState {
 	return CircuitState(ws.circuit.Load())
 }
 
+// CircuitFor returns the circuit state of the connection carrying key. A
+// WSClient has a single connection, so this is always Circuit.
+func (ws *WSClient) CircuitFor(key string) CircuitState {
+	return ws.Circuit()
+}
+
+// LastDataAt returns when the most recent data message was received, or the
+// zero time if none has arrived yet. Pongs and heartbeats do not count.
+func (ws *WSClient) LastDataAt() time.Time {
+	ns := ws.lastData.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
 // Subscribe returns a channel that receives copies of every inbound message.
-// The caller must drain the channel to avoid blocking other subscribers.
+// The caller must drain the channel to avoid dropped messages.
 func (ws *WSClient) Subscribe() <-chan []byte {
-	ch := make(chan []byte, 512)
-	ws.subMu.Lock()
-	ws.subs = append(ws.subs, ch)
-	ws.subMu.Unlock()
-	return ch
+	return ws.SubscribeWith(SubscribeOptions{}).C()
+}
+
+// SubscribeWith is like Subscribe but applies the given buffer size and
+// backpressure policy, and returns a handle exposing drop counters.
+func (ws *WSClient) SubscribeWith(opts SubscribeOptions) *Subscription[[]byte] {
+	return ws.subs.subscribe(opts)
+}
+
+// SubscribeFrames returns a channel of inbound messages stamped with their
+// receive time and connection ID.
+func (ws *WSClient) SubscribeFrames() <-chan Frame {
+	return ws.SubscribeFramesWith(SubscribeOptions{}).C()
+}
+
+// SubscribeFramesWith is like SubscribeFrames but applies the given buffer
+// size and backpressure policy.
+func (ws *WSClient) SubscribeFramesWith(opts SubscribeOptions) *Subscription[Frame] {
+	return ws.subs.subscribeFrames(opts)
 }
 
 // Send enqueues a message for delivery over the WebSocket connection.
@@ -116,41 +225,181 @@ func (ws *WSClient) Send(data []byte) {
 	}
 }
 
-// Connect dials the WebSocket endpoint and starts the read/write/heartbeat
+// SendSubscription sends data like Send and records it under key so it is
+// replayed, in registration order, after every successful reconnect. Key
+// identifies the subscription (typically the asset or ticker it covers);
+// sending again with the same key replaces the message but keeps its
+// original position.
+func (ws *WSClient) SendSubscription(key string, data []byte) {
+	ws.regMu.Lock()
+	defer ws.regMu.Unlock()
+
+	ws.register(key, data)
+	ws.subscribed = true
+	ws.Send(data)
+}
+
+// SendSubscriptions sends one message covering keys and records it for
+// replay. The message is built as initial if nothing has been subscribed
+// on the connection yet. Keys registered earlier move to the new entry.
+// Forgetting some of the keys later rebuilds the replayed message with the
+// remaining ones.
+func (ws *WSClient) SendSubscriptions(keys []string, build SubscribeFunc) {
+	if len(keys) > 0 {
+		ws.sendSubscriptions(keys, build)
+	}
+}
+
+// sendSubscriptions registers and sends the message covering keys,
+// holding regMu throughout so a concurrent replay sees all keys or none.
+func (ws *WSClient) sendSubscriptions(keys []string, build SubscribeFunc) {
+	ws.regMu.Lock()
+	defer ws.regMu.Unlock()
+
+	for _, k := range keys {
+		ws.unregister(k)
+	}
+	initial := !ws.subscribed
+	data := build(keys, initial)
+	ws.registry = append(ws.registry, subscription{
+		keys:    append([]string(nil), keys...),
+		data:    data,
+		build:   build,
+		initial: initial,
+	})
+	ws.subscribed = true
+	ws.Send(data)
+}
+
+// register records data under key. An existing single-key entry is
+// replaced in place; a key covered by a batch leaves it for a new entry.
+// Caller holds regMu.
+func (ws *WSClient) register(key string, data []byte) {
+	for i := range ws.registry {
+		if sub := &ws.registry[i]; len(sub.keys) == 1 && sub.keys[0] == key {
+			sub.data, sub.build, sub.stale = data, nil, false
+			return
+		}
+	}
+	ws.unregister(key)
+	ws.registry = append(ws.registry, subscription{keys: []string{key}, data: data})
+}
+
+// unregister removes key from the registry. An entry left without keys is
+// dropped; a batch that still covers other keys is marked stale and
+// rebuilt at the next replay. Caller holds regMu.
+func (ws *WSClient) unregister(key string) {
+	for i := range ws.registry {
+		sub := &ws.registry[i]
+		j := slices.Index(sub.keys, key)
+		if j < 0 {
+			continue
+		}
+		if len(sub.keys) == 1 {
+			ws.registry = slices.Delete(ws.registry, i, i+1)
+			return
+		}
+		sub.keys = slices.Delete(sub.keys, j, j+1)
+		sub.stale = true
+		return
+	}
+}
+
+// SendCommand sends data like Send. Key is ignored: a WSClient has a single
+// connection.
+func (ws *WSClient) SendCommand(key string, data []byte) {
+	ws.Send(data)
+}
+
+// SendCommands sends batch(keys) once. A WSClient carries every key.
+func (ws *WSClient) SendCommands(keys []string, batch func(keys []string) []byte) {
+	if len(keys) > 0 {
+		ws.Send(batch(keys))
+	}
+}
+
+// ForgetSubscription removes key from the replay registry. It does not send
+// anything to the venue; callers unsubscribe explicitly if needed.
+func (ws *WSClient) ForgetSubscription(key string) {
+	ws.regMu.Lock()
+	defer ws.regMu.Unlock()
+	ws.unregister(key)
+}
+
+// subscriptionKeys returns the registered subscription keys in order.
+func (ws *WSClient) subscriptionKeys() []string {
+	ws.regMu.Lock()
+	defer ws.regMu.Unlock()
+	var keys []string
+	for _, sub := range ws.registry {
+		keys = append(keys, sub.keys...)
+	}
+	return keys
+}
+
+// Connect dials the WebSocket endpoint and starts the read/write/keepalive
 // loops. It blocks until the initial connection succeeds or ctx is cancelled.
+// A failed attempt releases its context, so Connect may be retried.
 func (ws *WSClient) Connect(ctx context.Context) error {
-	ctx, ws.cancel = context.WithCancel(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	ws.cancel = cancel
 
-	if err := ws.dial(ctx); err != nil {
+	conn, err := ws.dial(ctx)
+	if err != nil {
+		cancel()
 		return err
 	}
+	ws.setConn(conn)
 	ws.circuit.Store(int32(CircuitClosed))
+	ws.emit(ConnEvent{Type: ConnConnected})
 
 	go ws.readLoop(ctx)
 	go ws.writeLoop(ctx)
+	go ws.keepaliveLoop(ctx)
 
 	return nil
 }
 
+// connectRetry calls Connect with the configured backoff until it succeeds
+// or ctx is cancelled, in which case it returns the last dial error.
+func (ws *WSClient) connectRetry(ctx context.Context) error {
+	delay := ws.cfg.BackoffInitial
+	for {
+		err := ws.Connect(ctx)
+		if err == nil {
+			return nil
+		}
+		log.Printf("ws: connect failed: %v (retry in %v)", err, delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay = time.Duration(math.Min(
+			float64(delay)*ws.cfg.BackoffFactor,
+			float64(ws.cfg.BackoffMax),
+		))
+	}
+}
+
 // Close shuts down the client, closing the underlying connection and all
-// subscriber channels.
+// subscriber channels. It is safe to call more than once.
 func (ws *WSClient) Close() {
-	if ws.cancel != nil {
-		ws.cancel()
-	}
-	ws.mu.Lock()
-	if ws.conn != nil {
-		ws.conn.Close()
-	}
-	ws.mu.Unlock()
+	ws.closeOnce.Do(func() {
+		if ws.cancel != nil {
+			ws.cancel()
+		}
+		ws.mu.Lock()
+		if ws.conn != nil {
+			ws.conn.Close()
+		}
+		ws.mu.Unlock()
 
-	ws.subMu.RLock()
-	for _, ch := range ws.subs {
-		close(ch)
-	}
-	ws.subMu.RUnlock()
+		ws.subs.close()
 
-	close(ws.done)
+		ws.closeEvents()
+		close(ws.done)
+	})
 }
 
 // Done returns a channel that is closed when the client has fully shut down.
@@ -158,32 +407,108 @@ func (ws *WSClient) Done() <-chan struct{} {
 	return ws.done
 }
 
-// dial establishes the WebSocket connection with TCP_NODELAY enabled.
-func (ws *WSClient) dial(ctx context.Context) error {
+// dial establishes a WebSocket connection with TCP_NODELAY enabled, routed
+// through the IPPool if one is configured. The connection is not published
+// to the read/write loops; see setConn.
+func (ws *WSClient) dial(ctx context.Context) (*websocket.Conn, error) {
 	dialer := websocket.Dialer{
 		ReadBufferSize:  ws.cfg.ReadBufferSize,
 		WriteBufferSize: ws.cfg.WriteBufferSize,
 		NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-			d := net.Dialer{}
-			conn, err := d.DialContext(ctx, network, addr)
+			pool := ws.cfg.IPPool
+			if pool == nil {
+				return DialVia(ctx, "", network, addr)
+			}
+			route := pool.Next()
+			conn, err := DialVia(ctx, route, network, addr)
 			if err != nil {
+				pool.ReportFailure(route, err)
 				return nil, err
 			}
-			if tc, ok := conn.(*net.TCPConn); ok {
-				tc.SetNoDelay(true)
-			}
 			return conn, nil
 		},
 	}
 
-	conn, _, err := dialer.DialContext(ctx, ws.cfg.URL, ws.cfg.Headers)
+	ws.mu.RLock()
+	url := ws.cfg.URL
+	ws.mu.RUnlock()
+
+	headers, err := ws.handshakeHeaders(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	conn, _, err := dialer.DialContext(ctx, url, headers)
+	if err != nil {
+		return nil, err
+	}
+	ws.installControlHandlers(conn)
+	return conn, nil
+}
+
+// handshakeHeaders returns the static Headers merged with a fresh set from
+// the HeaderProvider, if any.
+func (ws *WSClient) handshakeHeaders(ctx context.Context) (http.Header, error) {
+	if ws.cfg.HeaderProvider == nil {
+		return ws.cfg.Headers, nil
+	}
+	dynamic, err := ws.cfg.HeaderProvider(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ws: header provider: %w", err)
+	}
+
+	headers := ws.cfg.Headers.Clone()
+	if headers == nil {
+		headers = make(http.Header, len(dynamic))
+	}
+	for k, v := range dynamic {
+		headers[k] = v
+	}
+	return headers, nil
+}
+
+// recycle closes the active connection, forcing readLoop to reconnect and
+// replay the current subscription registry.
+func (ws *WSClient) recycle() {
+	ws.mu.RLock()
+	c := ws.conn
+	ws.mu.RUnlock()
+	if c != nil {
+		c.Close()
+	}
+}
+
+// setConn makes conn the active connection for the read/write loops and
+// assigns it a fresh connection ID.
+func (ws *WSClient) setConn(conn *websocket.Conn) {
 	ws.mu.Lock()
 	ws.conn = conn
+	ws.connID = connIDs.Add(1)
 	ws.mu.Unlock()
+}
+
+// resubscribe replays the subscription registry onto conn and, only if every
+// message was written, publishes conn as the active connection. Batches are
+// rebuilt if they were forgotten in part or now open the replay, or no
+// longer do. The caller keeps the circuit open on error.
+func (ws *WSClient) resubscribe(conn *websocket.Conn) error {
+	ws.regMu.Lock()
+	defer ws.regMu.Unlock()
+
+	conn.SetWriteDeadline(time.Now().Add(ws.cfg.HeartbeatTimeout))
+	for i := range ws.registry {
+		sub := &ws.registry[i]
+		if initial := i == 0; sub.build != nil && (sub.stale || sub.initial != initial) {
+			sub.data, sub.initial, sub.stale = sub.build(sub.keys, initial), initial, false
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, sub.data); err != nil {
+			return fmt.Errorf("resubscribe %q: %w", strings.Join(sub.keys, ","), err)
+		}
+	}
+	conn.SetWriteDeadline(time.Time{})
+
+	ws.subscribed = len(ws.registry) > 0
+	ws.setConn(conn)
 	return nil
 }
 
@@ -193,14 +518,24 @@ func (ws *WSClient) reconnect(ctx context.Context) bool {
 	ws.circuit.Store(int32(CircuitOpen))
 
 	delay := ws.cfg.BackoffInitial
-	for {
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		ws.emit(ConnEvent{Type: ConnReconnectAttempt, Attempt: attempt, Delay: delay, Reason: lastErr})
+
 		select {
 		case <-ctx.Done():
 			return false
 		case <-time.After(delay):
 		}
 
-		if err := ws.dial(ctx); err != nil {
+		conn, err := ws.dial(ctx)
+		if err == nil {
+			if err = ws.resubscribe(conn); err != nil {
+				conn.Close()
+			}
+		}
+		if err != nil {
+			lastErr = err
 			log.Printf("ws: reconnect failed: %v (retry in %v)", err, delay)
 			delay = time.Duration(math.Min(
 				float64(delay)*ws.cfg.BackoffFactor,
@@ -210,20 +545,39 @@ func (ws *WSClient) reconnect(ctx context.Context) bool {
 		}
 
 		ws.circuit.Store(int32(CircuitClosed))
-		if ws.onReconnect != nil {
-			ws.onReconnect()
-		}
+		ws.emit(ConnEvent{Type: ConnReconnected, Attempt: attempt})
 		return true
 	}
 }
 
+// installControlHandlers makes pongs and server pings count towards liveness.
+// Both handlers run inside ReadMessage, so extending the read deadline here
+// keeps a quiet connection alive as long as the peer answers.
+func (ws *WSClient) installControlHandlers(c *websocket.Conn) {
+	c.SetPongHandler(func(string) error {
+		return c.SetReadDeadline(time.Now().Add(ws.cfg.HeartbeatTimeout))
+	})
+	c.SetPingHandler(func(appData string) error {
+		c.SetReadDeadline(time.Now().Add(ws.cfg.HeartbeatTimeout))
+		err := c.WriteControl(websocket.PongMessage, []byte(appData),
+			time.Now().Add(ws.cfg.HeartbeatTimeout))
+		// Same tolerance as gorilla's default handler: a failed pong is
+		// surfaced by the next read, not by aborting this one.
+		var ne net.Error
+		if errors.Is(err, websocket.ErrCloseSent) || errors.As(err, &ne) {
+			return nil
+		}
+		return err
+	})
+}
+
 // readLoop reads messages and fans them out to subscribers. It also acts as the
-// heartbeat monitor: if no message arrives within HeartbeatTimeout, it triggers
-// a reconnect.
+// liveness monitor: if no frame of any kind arrives within HeartbeatTimeout,
+// it triggers a reconnect.
 func (ws *WSClient) readLoop(ctx context.Context) {
 	for {
 		ws.mu.RLock()
-		c := ws.conn
+		c, id := ws.conn, ws.connID
 		ws.mu.RUnlock()
 
 		c.SetReadDeadline(time.Now().Add(ws.cfg.HeartbeatTimeout))
@@ -234,13 +588,26 @@ func (ws *WSClient) readLoop(ctx context.Context) {
 			}
 			log.Printf("ws: read error (triggering reconnect): %v", err)
 			c.Close()
+			ws.circuit.Store(int32(CircuitOpen))
+			ws.emit(ConnEvent{Type: ConnDisconnected, Reason: err})
 			if !ws.reconnect(ctx) {
 				return
 			}
 			continue
 		}
 
-		ws.fanOut(msg)
+		frame := Frame{Received: time.Now(), ConnID: id, Data: msg}
+		if ws.cfg.Recorder != nil {
+			if err := ws.cfg.Recorder.Record(frame); err != nil {
+				log.Printf("ws: record frame: %v", err)
+			}
+		}
+
+		if ws.cfg.IsHeartbeat != nil && ws.cfg.IsHeartbeat(msg) {
+			continue
+		}
+		ws.lastData.Store(frame.Received.UnixNano())
+		ws.subs.deliver(frame)
 	}
 }
 
@@ -261,16 +628,45 @@ func (ws *WSClient) writeLoop(ctx context.Context) {
 	}
 }
 
-// fanOut delivers msg to every subscriber without blocking.
-func (ws *WSClient) fanOut(msg []byte) {
-	ws.subMu.RLock()
-	defer ws.subMu.RUnlock()
+// keepaliveLoop sends protocol pings and, if configured, application-level
+// heartbeats. Pings use WriteControl, which is safe to call concurrently with
+// writeLoop; application heartbeats go through the outbox.
+func (ws *WSClient) keepaliveLoop(ctx context.Context) {
+	var pingC, appC <-chan time.Time
+	if ws.cfg.PingInterval > 0 {
+		t := time.NewTicker(ws.cfg.PingInterval)
+		defer t.Stop()
+		pingC = t.C
+	}
+	if len(ws.cfg.AppPing) > 0 && ws.cfg.AppPingInterval > 0 {
+		t := time.NewTicker(ws.cfg.AppPingInterval)
+		defer t.Stop()
+		appC = t.C
+	}
+	if pingC == nil && appC == nil {
+		return
+	}
 
-	for _, ch := range ws.subs {
+	for {
 		select {
-		case ch <- msg:
-		default:
-			// Slow consumer — drop to avoid head-of-line blocking.
+		case <-ctx.Done():
+			return
+		case <-pingC:
+			if ws.Circuit() == CircuitOpen {
+				continue // readLoop is reconnecting
+			}
+			ws.mu.RLock()
+			c := ws.conn
+			ws.mu.RUnlock()
+			deadline := time.Now().Add(ws.cfg.PingInterval)
+			if err := c.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				log.Printf("ws: ping failed: %v", err)
+			}
+		case <-appC:
+			if ws.Circuit() == CircuitOpen {
+				continue
+			}
+			ws.Send(ws.cfg.AppPing)
 		}
 	}
 }
@@ -5,7 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
-	"sync/atomic"
+	"sync"
 	"testing"
 	"time"
 
@@ -72,6 +72,27 @@ func TestWSClient_Connect(t *testing.T) {
 	}
 }
 
+func TestWSClient_CloseTwice(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	client := NewWSClient(DefaultWSConfig(wsURL(srv)))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	client.Close()
+	client.Close() // must not panic
+
+	select {
+	case <-client.Done():
+	default:
+		t.Fatal("expected Done closed after Close")
+	}
+}
+
 func TestWSClient_Reconnect(t *testing.T) {
 	srv := newTestServer(t)
 
@@ -79,9 +100,8 @@ func TestWSClient_Reconnect(t *testing.T) {
 	cfg.HeartbeatTimeout = 200 * time.Millisecond
 	cfg.BackoffInitial = 50 * time.Millisecond
 
-	var reconnects atomic.Int32
 	client := NewWSClient(cfg)
-	client.onReconnect = func() { reconnects.Add(1) }
+	events := client.Events()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -112,14 +132,12 @@ func TestWSClient_Reconnect(t *testing.T) {
 
 	// Wait for reconnect to succeed.
 	deadline := time.After(3 * time.Second)
-	for {
-		if reconnects.Load() > 0 {
-			break
-		}
+	for reconnected := false; !reconnected; {
 		select {
+		case ev := <-events:
+			reconnected = ev.Type == ConnReconnected
 		case <-deadline:
 			t.Fatal("timed out waiting for reconnect")
-		case <-time.After(50 * time.Millisecond):
 		}
 	}
 
@@ -170,3 +188,339 @@ func TestWSClient_HeartbeatTimeout(t *testing.T) {
 		}
 	}
 }
+
+func TestWSClient_PingKeepsQuietConnectionAlive(t *testing.T) {
+	// Server reads (so gorilla answers pings with pongs) but never sends data.
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	cfg := DefaultWSConfig(wsURL(srv))
+	cfg.HeartbeatTimeout = 200 * time.Millisecond
+	cfg.PingInterval = 50 * time.Millisecond
+
+	client := NewWSClient(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer client.Close()
+
+	// Several heartbeat windows pass without a single data message.
+	deadline := time.After(600 * time.Millisecond)
+	for {
+		if client.Circuit() != CircuitClosed {
+			t.Fatal("quiet but healthy connection was torn down")
+		}
+		select {
+		case <-deadline:
+			if !client.LastDataAt().IsZero() {
+				t.Fatal("pongs must not count as data")
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func TestWSClient_ServerPingKeepsAlive(t *testing.T) {
+	// Kalshi-style: the server pings with a "heartbeat" payload and the
+	// client never pings on its own.
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		go func() {
+			for {
+				if _, _, err := c.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+		for {
+			deadline := time.Now().Add(time.Second)
+			if err := c.WriteControl(websocket.PingMessage, []byte("heartbeat"), deadline); err != nil {
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := DefaultWSConfig(wsURL(srv))
+	cfg.HeartbeatTimeout = 200 * time.Millisecond
+	cfg.PingInterval = 0
+
+	client := NewWSClient(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer client.Close()
+
+	time.Sleep(600 * time.Millisecond)
+	if client.Circuit() != CircuitClosed {
+		t.Fatal("server heartbeats should keep the connection alive")
+	}
+}
+
+func TestWSClient_AppHeartbeatFiltered(t *testing.T) {
+	// Polymarket-style: "PING" text is answered with "PONG" text.
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		for {
+			mt, msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			if string(msg) == "PING" {
+				msg = []byte("PONG")
+			}
+			if err := c.WriteMessage(mt, msg); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	cfg := DefaultWSConfig(wsURL(srv))
+	cfg.HeartbeatTimeout = 200 * time.Millisecond
+	cfg.PingInterval = 0
+	cfg.AppPing = []byte("PING")
+	cfg.AppPingInterval = 50 * time.Millisecond
+	cfg.IsHeartbeat = func(msg []byte) bool { return string(msg) == "PONG" }
+
+	client := NewWSClient(cfg)
+	sub := client.Subscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer client.Close()
+
+	time.Sleep(600 * time.Millisecond)
+	if client.Circuit() != CircuitClosed {
+		t.Fatal("application heartbeats should keep the connection alive")
+	}
+
+	select {
+	case msg := <-sub:
+		t.Fatalf("heartbeat leaked to subscriber: %q", msg)
+	default:
+	}
+
+	// Real data still flows and is timestamped.
+	client.Send([]byte("data"))
+	select {
+	case msg := <-sub:
+		if string(msg) != "data" {
+			t.Fatalf("expected 'data', got %q", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for echoed data")
+	}
+	if client.LastDataAt().IsZero() {
+		t.Fatal("LastDataAt not updated by data message")
+	}
+}
+
+// recordingServer upgrades to WS and forwards every client message to the
+// returned channel. drop closes all connections accepted so far; hijacked
+// WebSocket connections survive httptest.Server.Close on their own.
+func recordingServer(t *testing.T) (srv *httptest.Server, msgs <-chan string, drop func()) {
+	t.Helper()
+	ch := make(chan string, 64)
+	var mu sync.Mutex
+	var conns []*websocket.Conn
+	upgrader := websocket.Upgrader{}
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		conns = append(conns, c)
+		mu.Unlock()
+		defer c.Close()
+		for {
+			_, msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			ch <- string(msg)
+		}
+	}))
+	drop = func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, c := range conns {
+			c.Close()
+		}
+	}
+	return srv, ch, drop
+}
+
+func TestWSClient_ResubscribeAfterReconnect(t *testing.T) {
+	srv, first, dropFirst := recordingServer(t)
+	defer srv.Close()
+
+	cfg := DefaultWSConfig(wsURL(srv))
+	cfg.HeartbeatTimeout = 200 * time.Millisecond
+	cfg.PingInterval = 50 * time.Millisecond
+	cfg.BackoffInitial = 50 * time.Millisecond
+
+	client := NewWSClient(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer client.Close()
+
+	client.SendSubscription("a", []byte("sub-a"))
+	client.SendSubscription("b", []byte("sub-b"))
+	client.SendSubscription("c", []byte("sub-c"))
+	client.ForgetSubscription("b")
+	client.SendSubscription("a", []byte("sub-a2")) // replaces in place
+
+	for _, want := range []string{"sub-a", "sub-b", "sub-c", "sub-a2"} {
+		select {
+		case got := <-first:
+			if got != want {
+				t.Fatalf("initial send: want %q, got %q", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+
+	srv2, second, _ := recordingServer(t)
+	defer srv2.Close()
+	client.mu.Lock()
+	client.cfg.URL = wsURL(srv2)
+	client.mu.Unlock()
+	dropFirst()
+
+	for _, want := range []string{"sub-a2", "sub-c"} {
+		select {
+		case got := <-second:
+			if got != want {
+				t.Fatalf("replay: want %q, got %q", want, got)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timed out waiting for replayed %q", want)
+		}
+	}
+
+	deadline := time.After(time.Second)
+	for client.Circuit() != CircuitClosed {
+		select {
+		case <-deadline:
+			t.Fatal("expected CircuitClosed after resubscribe")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func TestWSClient_ReplaysBatchesAsSent(t *testing.T) {
+	srv, first, dropFirst := recordingServer(t)
+	defer srv.Close()
+
+	cfg := DefaultWSConfig(wsURL(srv))
+	cfg.HeartbeatTimeout = 200 * time.Millisecond
+	cfg.PingInterval = 50 * time.Millisecond
+	cfg.BackoffInitial = 50 * time.Millisecond
+
+	client := NewWSClient(cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer client.Close()
+
+	// "sub:" opens a connection's subscription, "add:" extends it.
+	build := func(keys []string, initial bool) []byte {
+		if initial {
+			return []byte("sub:" + strings.Join(keys, "+"))
+		}
+		return []byte("add:" + strings.Join(keys, "+"))
+	}
+
+	client.SendSubscriptions([]string{"b", "c"}, build)
+	client.SendSubscriptions([]string{"d", "e"}, build)
+	client.SendSubscriptions([]string{"f", "g"}, build)
+	client.SendSubscription("a", []byte("one:a"))
+	client.ForgetSubscription("b")
+	client.ForgetSubscription("c")
+	client.ForgetSubscription("g")
+
+	expectMsgs := func(ch <-chan string, want ...string) {
+		t.Helper()
+		for _, w := range want {
+			select {
+			case got := <-ch:
+				if got != w {
+					t.Fatalf("want %q, got %q", w, got)
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatalf("timed out waiting for %q", w)
+			}
+		}
+	}
+	expectMsgs(first, "sub:b+c", "add:d+e", "add:f+g", "one:a")
+
+	srv2, second, _ := recordingServer(t)
+	defer srv2.Close()
+	client.mu.Lock()
+	client.cfg.URL = wsURL(srv2)
+	client.mu.Unlock()
+	dropFirst()
+
+	// Each batch is replayed as one message: the fully forgotten one is
+	// gone, the next now opens the subscription and the last is rebuilt
+	// without its forgotten key.
+	expectMsgs(second, "sub:d+e", "add:f", "one:a")
+	select {
+	case got := <-second:
+		t.Fatalf("unexpected replayed message %q", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if keys := client.subscriptionKeys(); strings.Join(keys, ",") != "d,e,f,a" {
+		t.Fatalf("unexpected registered keys %v", keys)
+	}
+
+	// Additions after the replay extend the subscription.
+	client.SendSubscriptions([]string{"h"}, build)
+	expectMsgs(second, "add:h")
+}
@@ -14,6 +14,7 @@ type Config struct {
 	Signer             SignerConfig
 	DB                 DBConfig
 	Redis              RedisConfig
+	Gateway            GatewayConfig
 }
 
 // SignerConfig holds signer-specific settings.
@@ -47,6 +48,20 @@ type RedisConfig struct {
 	DB       int    `mapstructure:"db"`
 }
 
+// GatewayConfig holds the market-data gateway settings.
+type GatewayConfig struct {
+	// Enabled starts the gateway. It is off by default: no exchange
+	// adapters feed the Broadcaster yet, so it would serve no books.
+	Enabled bool   `mapstructure:"enabled"`
+	Addr    string `mapstructure:"addr"`
+	// Tokens maps client names to bearer tokens, read from a
+	// comma-separated "name:token" list. An enabled gateway needs at least
+	// one.
+	Tokens map[string]string `mapstructure:"tokens"`
+	// AllowedOrigins lists extra Origin hosts, e.g. the dev frontend.
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+}
+
 // Load reads configuration from environment variables prefixed with CAESAR_.
 func Load() (*Config, error) {
 	v := viper.New()
@@ -75,6 +90,9 @@ func Load() (*Config, error) {
 	v.SetDefault("redis.password", "")
 	v.SetDefault("redis.db", 0)
 
+	// Gateway defaults
+	v.SetDefault("gateway.addr", ":8080")
+
 	cfg := &Config{}
 
 	cfg.Env = v.GetString("env")
@@ -102,5 +120,34 @@ func Load() (*Config, error) {
 		DB:       v.GetInt("redis.db"),
 	}
 
+	cfg.Gateway = GatewayConfig{
+		Enabled:        v.GetBool("gateway.enabled"),
+		Addr:           v.GetString("gateway.addr"),
+		Tokens:         make(map[string]string),
+		AllowedOrigins: splitList(v.GetString("gateway.allowed_origins")),
+	}
+	for i, entry := range splitList(v.GetString("gateway.tokens")) {
+		name, token, ok := strings.Cut(entry, ":")
+		if !ok || name == "" || token == "" {
+			// Never echo the entry; it may be a bare token.
+			return nil, fmt.Errorf("gateway.tokens: entry %d is not name:token", i+1)
+		}
+		cfg.Gateway.Tokens[name] = token
+	}
+	if cfg.Gateway.Enabled && len(cfg.Gateway.Tokens) == 0 {
+		return nil, fmt.Errorf("gateway.enabled: no gateway.tokens configured")
+	}
+
 	return cfg, nil
 }
+
+// splitList splits a comma-separated value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -63,3 +63,39 @@ func TestDBDSN(t *testing.T) {
 		t.Errorf("unexpected DSN:\ngot:  %s\nwant: %s", cfg.DSN(), expected)
 	}
 }
+
+func TestLoadGateway(t *testing.T) {
+	os.Setenv("CAESAR_GATEWAY_TOKENS", "cockpit:abc, ops:def")
+	os.Setenv("CAESAR_GATEWAY_ALLOWED_ORIGINS", "localhost:3000")
+	defer os.Unsetenv("CAESAR_GATEWAY_TOKENS")
+	defer os.Unsetenv("CAESAR_GATEWAY_ALLOWED_ORIGINS")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Gateway.Enabled {
+		t.Error("expected the gateway disabled by default")
+	}
+	if cfg.Gateway.Addr != ":8080" {
+		t.Errorf("expected default addr :8080, got %s", cfg.Gateway.Addr)
+	}
+	if cfg.Gateway.Tokens["cockpit"] != "abc" || cfg.Gateway.Tokens["ops"] != "def" {
+		t.Errorf("unexpected tokens: %v", cfg.Gateway.Tokens)
+	}
+	if len(cfg.Gateway.AllowedOrigins) != 1 || cfg.Gateway.AllowedOrigins[0] != "localhost:3000" {
+		t.Errorf("unexpected origins: %v", cfg.Gateway.AllowedOrigins)
+	}
+
+	os.Setenv("CAESAR_GATEWAY_TOKENS", "no-separator")
+	if _, err := Load(); err == nil {
+		t.Error("expected malformed token entry rejected")
+	}
+
+	os.Setenv("CAESAR_GATEWAY_ENABLED", "true")
+	os.Setenv("CAESAR_GATEWAY_TOKENS", "")
+	defer os.Unsetenv("CAESAR_GATEWAY_ENABLED")
+	if _, err := Load(); err == nil {
+		t.Error("expected an enabled gateway without tokens rejected")
+	}
+}
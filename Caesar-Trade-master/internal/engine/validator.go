@@ -3,6 +3,7 @@ package engine
 import (
 	"errors"
 	"fmt"
+	"math"
 
 	"github.com/caesar-terminal/caesar/internal/adapter"
 )
@@ -12,30 +13,38 @@ var (
 	ErrInvalidSide     = errors.New("invalid order side")
 	ErrInvalidType     = errors.New("invalid order type")
 	ErrPriceOutOfRange = errors.New("price out of valid range")
+	ErrPriceOffTick    = errors.New("price not a multiple of tick size")
 	ErrPriceMissing    = errors.New("limit order requires a price")
 	ErrQuantityTooLow  = errors.New("quantity below minimum lot size")
 	ErrCircuitOpen     = errors.New("circuit breaker: trading disabled for market")
 	ErrSlippageCap     = errors.New("market order exceeds slippage cap")
 )
 
-// ExchangeConstraints defines per-exchange validation limits.
+// ExchangeConstraints defines per-exchange validation limits. TickSize and
+// MinLotSize are venue-wide defaults, overridden per market by a
+// ConstraintsRegistry when one is set.
 type ExchangeConstraints struct {
 	MinPrice   float64
 	MaxPrice   float64
 	MinLotSize float64
+	TickSize   float64
 }
 
-// DefaultConstraints maps each exchange to its validation rules.
+// DefaultConstraints maps each exchange to its validation rules. The
+// Polymarket tick is its finest, 0.001, which markets use near the price
+// extremes; coarser per-market ticks come from a ConstraintsRegistry.
 var DefaultConstraints = map[adapter.Exchange]ExchangeConstraints{
 	adapter.ExchangePolymarket: {
 		MinPrice:   0.0,
 		MaxPrice:   1.0,
 		MinLotSize: 1.0,
+		TickSize:   0.001,
 	},
 	adapter.ExchangeKalshi: {
 		MinPrice:   0.0,
 		MaxPrice:   1.0,
 		MinLotSize: 1.0,
+		TickSize:   0.01,
 	},
 }
 
@@ -55,6 +64,12 @@ const SlippageCapBps = 10
 type Validator struct {
 	gate        TradingGate
 	constraints map[adapter.Exchange]ExchangeConstraints
+
+	// markets supplies per-market tick and lot sizes; nil uses defaults.
+	markets *adapter.ConstraintsRegistry
+
+	// roundToTick rounds off-tick prices instead of rejecting them.
+	roundToTick bool
 }
 
 // NewValidator creates a Validator with the given circuit breaker gate
@@ -66,6 +81,20 @@ func NewValidator(gate TradingGate) *Validator {
 	}
 }
 
+// SetMarketConstraints makes the Validator check tick and lot sizes against
+// the current per-market values in reg, as maintained by the adapters.
+func (v *Validator) SetMarketConstraints(reg *adapter.ConstraintsRegistry) {
+	v.markets = reg
+}
+
+// SetRoundToTick controls how off-tick prices are handled. By default they
+// are rejected with ErrPriceOffTick; with round set, buy prices are rounded
+// down and sell prices up to the nearest tick, so rounding never worsens
+// the order's price.
+func (v *Validator) SetRoundToTick(round bool) {
+	v.roundToTick = round
+}
+
 // Validate runs all pre-flight checks on the order. On success the order
 // status is advanced to StatusValidated. On failure an error is returned
 // and the status is set to StatusRejected.
@@ -92,9 +121,20 @@ func (v *Validator) validate(order *Order) error {
 	if !ok {
 		return fmt.Errorf("unknown exchange: %s", order.Exchange)
 	}
+	if mc, ok := v.markets.Lookup(order.Exchange, order.MarketID); ok {
+		if mc.TickSize > 0 {
+			ec.TickSize = mc.TickSize
+		}
+		if mc.MinOrderSize > 0 {
+			ec.MinLotSize = mc.MinOrderSize
+		}
+	}
 
 	// 3. Price check.
 	if order.Type == Limit || order.Type == StopLoss {
+		if err := v.checkTick(order, ec.TickSize); err != nil {
+			return err
+		}
 		if order.Price <= ec.MinPrice || order.Price >= ec.MaxPrice {
 			return fmt.Errorf("%w: %.4f not in (%.1f, %.1f)",
 				ErrPriceOutOfRange, order.Price, ec.MinPrice, ec.MaxPrice)
@@ -126,3 +166,27 @@ func (v *Validator) validate(order *Order) error {
 
 	return nil
 }
+
+// checkTick rejects or rounds an order price that is not a multiple of tick.
+// Rounding runs before the range check, so a price rounded onto a bound is
+// still rejected.
+func (v *Validator) checkTick(order *Order, tick float64) error {
+	if tick <= 0 {
+		return nil
+	}
+	steps := order.Price / tick
+	nearest := math.Round(steps)
+	if math.Abs(steps-nearest) < 1e-6 {
+		return nil
+	}
+	if !v.roundToTick {
+		return fmt.Errorf("%w: %v not a multiple of %v", ErrPriceOffTick, order.Price, tick)
+	}
+	n := math.Ceil(steps)
+	if order.Side == Buy {
+		n = math.Floor(steps)
+	}
+	// Trim the float noise of n*tick (0.55000000000000004) to 9 decimals.
+	order.Price = math.Round(n*tick*1e9) / 1e9
+	return nil
+}
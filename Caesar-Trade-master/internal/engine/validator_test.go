@@ -179,3 +179,101 @@ func TestValidate_SellSide(t *testing.T) {
 		t.Fatalf("sell order should be valid, got %v", err)
 	}
 }
+
+func TestValidate_PriceOffTick(t *testing.T) {
+	v := NewValidator(&mockGate{canTrade: true})
+	order := validOrder()
+	order.Price = 0.5555
+
+	err := v.Validate(order)
+	if !errors.Is(err, ErrPriceOffTick) {
+		t.Fatalf("expected ErrPriceOffTick, got %v", err)
+	}
+}
+
+func TestValidate_MarketTickFromRegistry(t *testing.T) {
+	reg := adapter.NewConstraintsRegistry()
+	v := NewValidator(&mockGate{canTrade: true})
+	v.SetMarketConstraints(reg)
+
+	order := validOrder()
+	order.Price = 0.555
+	if err := v.Validate(order); err != nil {
+		t.Fatalf("0.555 should be valid at the default tick, got %v", err)
+	}
+
+	// The market trades at a 0.01 tick mid-range.
+	reg.SetTickSize(adapter.ExchangePolymarket, "BTC-100K", 0.01)
+	order = validOrder()
+	order.Price = 0.555
+	if err := v.Validate(order); !errors.Is(err, ErrPriceOffTick) {
+		t.Fatalf("expected ErrPriceOffTick at tick 0.01, got %v", err)
+	}
+
+	// Other markets keep the venue default.
+	order = validOrder()
+	order.MarketID = "OTHER"
+	order.Price = 0.555
+	if err := v.Validate(order); err != nil {
+		t.Fatalf("other market should keep the default tick, got %v", err)
+	}
+}
+
+func TestValidate_PolymarketFineTickWithoutRegistry(t *testing.T) {
+	v := NewValidator(&mockGate{canTrade: true})
+
+	for _, price := range []float64{0.995, 0.001, 0.123} {
+		order := validOrder()
+		order.Price = price
+		if err := v.Validate(order); err != nil {
+			t.Fatalf("%v should be valid at Polymarket's 0.001 tick, got %v", price, err)
+		}
+	}
+}
+
+func TestValidate_MinOrderSizeFromRegistry(t *testing.T) {
+	reg := adapter.NewConstraintsRegistry()
+	reg.SetMinOrderSize(adapter.ExchangePolymarket, "BTC-100K", 15)
+	v := NewValidator(&mockGate{canTrade: true})
+	v.SetMarketConstraints(reg)
+
+	order := validOrder() // quantity 10
+	if err := v.Validate(order); !errors.Is(err, ErrQuantityTooLow) {
+		t.Fatalf("expected ErrQuantityTooLow, got %v", err)
+	}
+}
+
+func TestValidate_RoundToTick(t *testing.T) {
+	v := NewValidator(&mockGate{canTrade: true})
+	v.SetRoundToTick(true)
+
+	// Kalshi's default tick is 0.01.
+	buy := validOrder()
+	buy.Exchange = adapter.ExchangeKalshi
+	buy.Price = 0.557
+	if err := v.Validate(buy); err != nil {
+		t.Fatalf("expected rounding, got %v", err)
+	}
+	if buy.Price != 0.55 {
+		t.Fatalf("buy should round down to 0.55, got %v", buy.Price)
+	}
+
+	sell := validOrder()
+	sell.Exchange = adapter.ExchangeKalshi
+	sell.Side = Sell
+	sell.Price = 0.551
+	if err := v.Validate(sell); err != nil {
+		t.Fatalf("expected rounding, got %v", err)
+	}
+	if sell.Price != 0.56 {
+		t.Fatalf("sell should round up to 0.56, got %v", sell.Price)
+	}
+
+	// Rounding onto a bound is still out of range.
+	low := validOrder()
+	low.Exchange = adapter.ExchangeKalshi
+	low.Price = 0.004
+	if err := v.Validate(low); !errors.Is(err, ErrPriceOutOfRange) {
+		t.Fatalf("expected ErrPriceOutOfRange, got %v", err)
+	}
+}
@@ -0,0 +1,412 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/caesar-terminal/caesar/internal/adapter"
+	"github.com/gorilla/websocket"
+)
+
+// subscription is one client subscription, keyed by its channel string.
+type subscription struct {
+	cancel  context.CancelFunc
+	arb     bool
+	arbPair string // "" for every pair
+}
+
+// pendingBook is the latest unwritten book of one subscription leg: one
+// book of a subscribed market, so a Polymarket market's outcome tokens
+// are separate legs.
+type pendingBook struct {
+	channel  string
+	update   adapter.BookUpdate
+	snapshot bool
+}
+
+// arbFrame is a queued arbitrage event for one arb subscription.
+type arbFrame struct {
+	channel string
+	event   adapter.ArbitrageEvent
+}
+
+// conn is one client connection. A reader goroutine handles requests and a
+// writer goroutine owns the socket's write side; books reach the writer
+// through a per-leg conflation map, arbitrage events through a bounded
+// queue, and acks through ctrl.
+type conn struct {
+	s      *Server
+	ws     *websocket.Conn
+	client string
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	ctrl chan frame
+	arbs chan arbFrame
+	lost atomic.Uint64 // arb frames dropped since the last write
+	seq  uint64        // last data frame sequence; writer only
+
+	// mu guards subscriptions and the pending books.
+	mu      sync.Mutex
+	subs    map[string]*subscription
+	pending map[string]pendingBook // keyed by leg
+	order   []string               // legs in order of their first unwritten change
+	primed  map[string]bool        // legs whose snapshot has been queued
+	ready   chan struct{}
+}
+
+func newConn(s *Server, ws *websocket.Conn, client string, parent context.Context) *conn {
+	ctx, cancel := context.WithCancel(parent)
+	return &conn{
+		s:       s,
+		ws:      ws,
+		client:  client,
+		ctx:     ctx,
+		cancel:  cancel,
+		ctrl:    make(chan frame, 16),
+		arbs:    make(chan arbFrame, s.cfg.ArbBuffer),
+		subs:    make(map[string]*subscription),
+		pending: make(map[string]pendingBook),
+		primed:  make(map[string]bool),
+		ready:   make(chan struct{}, 1),
+	}
+}
+
+// run serves the connection until the client leaves or the server stops.
+func (c *conn) run() {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.write()
+	}()
+	c.read()
+	c.cancel()
+	<-done
+	c.ws.Close()
+}
+
+// read handles client requests until the socket fails.
+func (c *conn) read() {
+	c.ws.SetReadLimit(4096)
+	wait := 2 * c.s.cfg.PingInterval
+	c.ws.SetReadDeadline(time.Now().Add(wait))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(wait))
+	})
+
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.ws.SetReadDeadline(time.Now().Add(wait))
+
+		var req request
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.send(frame{Type: frameError, Error: "malformed request"})
+			continue
+		}
+		start, err := c.handle(req)
+		if err != nil {
+			c.send(frame{Type: frameError, ID: req.ID, Error: err.Error()})
+			continue
+		}
+		c.send(frame{Type: frameAck, ID: req.ID, Channel: channelKey(req)})
+		if start != nil {
+			start()
+		}
+	}
+}
+
+// handle applies a subscribe or unsubscribe request. A subscription's
+// books start flowing when the returned start is called, after its ack has
+// been queued.
+func (c *conn) handle(req request) (start func(), err error) {
+	key := channelKey(req)
+	switch req.Op {
+	case "subscribe":
+	case "unsubscribe":
+		if !c.unsubscribe(key, nil) {
+			return nil, fmt.Errorf("not subscribed to %s", key)
+		}
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown op %q", req.Op)
+	}
+
+	type leg struct {
+		exchange adapter.Exchange
+		market   string
+	}
+	var legs []leg
+	sub := &subscription{}
+	switch req.Channel {
+	case ChannelBook:
+		if req.Exchange != adapter.ExchangePolymarket && req.Exchange != adapter.ExchangeKalshi {
+			return nil, fmt.Errorf("unknown exchange %q", req.Exchange)
+		}
+		if req.Market == "" {
+			return nil, fmt.Errorf("book subscription needs a market")
+		}
+		legs = []leg{{req.Exchange, req.Market}}
+	case ChannelPair:
+		pair, ok := c.pair(req.Pair)
+		if !ok {
+			return nil, fmt.Errorf("unknown pair %q", req.Pair)
+		}
+		legs = []leg{
+			{adapter.ExchangePolymarket, pair.PolyMarketID},
+			{adapter.ExchangeKalshi, pair.KalshiMarketID},
+		}
+	case ChannelArb:
+		if c.s.ub == nil {
+			return nil, fmt.Errorf("arbitrage events are not available")
+		}
+		if req.Pair != "" {
+			if _, ok := c.pair(req.Pair); !ok {
+				return nil, fmt.Errorf("unknown pair %q", req.Pair)
+			}
+		}
+		sub.arb, sub.arbPair = true, req.Pair
+	default:
+		return nil, fmt.Errorf("unknown channel %q", req.Channel)
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	sub.cancel = cancel
+
+	c.mu.Lock()
+	if _, dup := c.subs[key]; dup {
+		c.mu.Unlock()
+		cancel()
+		return nil, fmt.Errorf("already subscribed to %s", key)
+	}
+	if len(c.subs) >= c.s.cfg.MaxSubscriptions {
+		c.mu.Unlock()
+		cancel()
+		return nil, fmt.Errorf("subscription limit of %d reached", c.s.cfg.MaxSubscriptions)
+	}
+	c.subs[key] = sub
+	c.mu.Unlock()
+
+	// Conflated Broadcaster subscriptions start with the cached books, each
+	// of which becomes its leg's snapshot frame.
+	return func() {
+		for _, l := range legs {
+			bs := c.s.bc.SubscribeConflated(ctx, l.exchange, l.market)
+			go c.pump(ctx, key, sub, bs)
+		}
+	}, nil
+}
+
+func (c *conn) pair(name string) (adapter.MarketPair, bool) {
+	if c.s.ub == nil || name == "" {
+		return adapter.MarketPair{}, false
+	}
+	return c.s.ub.Pair(name)
+}
+
+// pump moves one leg's books into the conflation map until its
+// subscription ends. If the Broadcaster ends it, the client is told.
+func (c *conn) pump(ctx context.Context, key string, sub *subscription, bs *adapter.ConflatedSubscription) {
+	for {
+		updates, err := bs.Next(ctx)
+		if err != nil {
+			if ctx.Err() == nil && c.unsubscribe(key, sub) {
+				c.send(frame{Type: frameClosed, Channel: key, Error: err.Error()})
+			}
+			return
+		}
+		for _, u := range updates {
+			c.offer(key, sub, u)
+		}
+	}
+}
+
+// offer stores u as its leg's latest unwritten book, unless sub is no
+// longer the subscription for key.
+func (c *conn) offer(key string, sub *subscription, u adapter.BookUpdate) {
+	leg := key + "|" + string(u.Exchange) + ":" + u.MarketID + ":" + u.AssetID
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.subs[key] != sub {
+		return // unsubscribed while in flight
+	}
+	prev, pending := c.pending[leg]
+	if !pending {
+		c.order = append(c.order, leg)
+	}
+	snapshot := !c.primed[leg] || (pending && prev.snapshot)
+	c.primed[leg] = true
+	c.pending[leg] = pendingBook{channel: key, update: u, snapshot: snapshot}
+
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+// offerArb queues ev for every matching arb subscription, dropping it
+// when the queue is full.
+func (c *conn) offerArb(ev adapter.ArbitrageEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, sub := range c.subs {
+		if !sub.arb || (sub.arbPair != "" && sub.arbPair != ev.Pair.Name) {
+			continue
+		}
+		select {
+		case c.arbs <- arbFrame{channel: key, event: ev}:
+		default:
+			c.lost.Add(1)
+		}
+	}
+}
+
+// unsubscribe ends the subscription for key and discards its unwritten
+// books, reporting whether it existed. If only is set, the subscription is
+// ended only if it is still that one.
+func (c *conn) unsubscribe(key string, only *subscription) bool {
+	c.mu.Lock()
+	sub, ok := c.subs[key]
+	if ok && only != nil && sub != only {
+		ok = false
+	}
+	if ok {
+		delete(c.subs, key)
+		order := c.order[:0]
+		for _, leg := range c.order {
+			if c.pending[leg].channel == key {
+				delete(c.pending, leg)
+				continue
+			}
+			order = append(order, leg)
+		}
+		c.order = order
+		for leg := range c.primed {
+			if strings.HasPrefix(leg, key+"|") {
+				delete(c.primed, leg)
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	if ok {
+		sub.cancel()
+	}
+	return ok
+}
+
+// send queues a control frame for the writer.
+func (c *conn) send(f frame) {
+	select {
+	case c.ctrl <- f:
+	case <-c.ctx.Done():
+	}
+}
+
+// write owns the socket's write side until the connection ends.
+func (c *conn) write() {
+	ping := time.NewTicker(c.s.cfg.PingInterval)
+	defer ping.Stop()
+
+	for {
+		// Control frames go first, so an ack precedes its snapshot.
+		select {
+		case f := <-c.ctrl:
+			if c.writeFrame(f) != nil {
+				c.cancel()
+				return
+			}
+			continue
+		default:
+		}
+
+		var err error
+		select {
+		case <-c.ctx.Done():
+			c.ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""),
+				time.Now().Add(c.s.cfg.WriteTimeout))
+			return
+		case f := <-c.ctrl:
+			err = c.writeFrame(f)
+		case a := <-c.arbs:
+			err = c.writeData(frame{Type: frameArb, Channel: a.channel, Arb: newArbMsg(a.event)})
+		case <-c.ready:
+			err = c.flushBooks()
+		case <-ping.C:
+			err = c.ws.WriteControl(websocket.PingMessage, nil,
+				time.Now().Add(c.s.cfg.WriteTimeout))
+		}
+		if err != nil {
+			c.cancel()
+			return
+		}
+	}
+}
+
+// flushBooks writes every pending book in the order its leg changed.
+func (c *conn) flushBooks() error {
+	c.mu.Lock()
+	books := make([]pendingBook, len(c.order))
+	for i, leg := range c.order {
+		books[i] = c.pending[leg]
+		delete(c.pending, leg)
+	}
+	c.order = c.order[:0]
+	c.mu.Unlock()
+
+	for _, b := range books {
+		typ := frameUpdate
+		if b.snapshot {
+			typ = frameSnapshot
+		}
+		if err := c.writeData(frame{Type: typ, Channel: b.channel, Book: newBookMsg(b.update)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeData numbers and writes a data frame, skipping one sequence number
+// per dropped frame so the client sees the gap.
+func (c *conn) writeData(f frame) error {
+	f.Seq = c.nextSeq()
+	return c.writeFrame(f)
+}
+
+// nextSeq returns the next data frame's sequence number.
+func (c *conn) nextSeq() uint64 {
+	c.seq += 1 + c.lost.Swap(0)
+	return c.seq
+}
+
+func (c *conn) writeFrame(f frame) error {
+	c.ws.SetWriteDeadline(time.Now().Add(c.s.cfg.WriteTimeout))
+	return c.ws.WriteJSON(f)
+}
+
+// channelKey names the channel a request refers to, as echoed in frames:
+// "book:<exchange>:<market>", "pair:<name>", "arb" or "arb:<pair>".
+func channelKey(req request) string {
+	switch req.Channel {
+	case ChannelBook:
+		return ChannelBook + ":" + string(req.Exchange) + ":" + req.Market
+	case ChannelPair:
+		return ChannelPair + ":" + req.Pair
+	case ChannelArb:
+		if req.Pair != "" {
+			return ChannelArb + ":" + req.Pair
+		}
+		return ChannelArb
+	default:
+		return req.Channel
+	}
+}
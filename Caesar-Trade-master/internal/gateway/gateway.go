@@ -0,0 +1,239 @@
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caesar-terminal/caesar/internal/adapter"
+	"github.com/gorilla/websocket"
+)
+
+// ErrUnauthenticated is returned by an Authenticator that rejects a request.
+var ErrUnauthenticated = errors.New("gateway: unauthenticated")
+
+// Authenticator identifies the client behind an upgrade request, returning
+// a client name for logs or an error to refuse the connection.
+type Authenticator interface {
+	Authenticate(r *http.Request) (string, error)
+}
+
+// TokenAuth authenticates clients by bearer token, mapping each token to a
+// client name. The token is read from "Authorization: Bearer <token>" or,
+// since browsers cannot set headers on a WebSocket, the "token" query
+// parameter.
+type TokenAuth map[string]string
+
+// Authenticate implements Authenticator. Every configured token is
+// compared in constant time.
+func (a TokenAuth) Authenticate(r *http.Request) (string, error) {
+	token := r.URL.Query().Get("token")
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		token = strings.TrimPrefix(h, "Bearer ")
+	}
+	if token == "" {
+		return "", ErrUnauthenticated
+	}
+
+	client := ""
+	for t, name := range a {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			client = name
+		}
+	}
+	if client == "" {
+		return "", ErrUnauthenticated
+	}
+	return client, nil
+}
+
+// Config holds the gateway settings.
+type Config struct {
+	// Auth authenticates every upgrade request. Required.
+	Auth Authenticator
+	// AllowedOrigins lists the Origin hosts accepted besides the gateway's
+	// own host, e.g. "localhost:3000" for the dev frontend.
+	AllowedOrigins []string
+	// PingInterval is how often clients are pinged. A client silent for
+	// two intervals is disconnected.
+	PingInterval time.Duration
+	// WriteTimeout bounds every frame write.
+	WriteTimeout time.Duration
+	// MaxSubscriptions caps the subscriptions per connection.
+	MaxSubscriptions int
+	// ArbBuffer is the per-connection queue of arbitrage events. Events
+	// overflowing it are dropped and show up as a sequence gap.
+	ArbBuffer int
+}
+
+// DefaultConfig returns a Config with sensible defaults for auth.
+func DefaultConfig(auth Authenticator) Config {
+	return Config{
+		Auth:             auth,
+		PingInterval:     15 * time.Second,
+		WriteTimeout:     5 * time.Second,
+		MaxSubscriptions: 256,
+		ArbBuffer:        64,
+	}
+}
+
+// Server is the market-data gateway for the cockpit frontend. Clients
+// connect over WebSocket, subscribe to market books, UnifiedBook pairs and
+// arbitrage events with JSON requests, and receive a snapshot followed by
+// conflated updates; see the request and frame types.
+type Server struct {
+	cfg      Config
+	bc       *adapter.Broadcaster
+	ub       *adapter.UnifiedBook // nil disables pair and arb channels
+	upgrader websocket.Upgrader
+
+	mu    sync.Mutex
+	ctx   context.Context // set by Run
+	conns map[*conn]struct{}
+}
+
+// New creates a Server streaming from bc. ub supplies pairs and arbitrage
+// events and may be nil. Zero fields of cfg take DefaultConfig's values.
+func New(cfg Config, bc *adapter.Broadcaster, ub *adapter.UnifiedBook) *Server {
+	def := DefaultConfig(cfg.Auth)
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = def.PingInterval
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = def.WriteTimeout
+	}
+	if cfg.MaxSubscriptions <= 0 {
+		cfg.MaxSubscriptions = def.MaxSubscriptions
+	}
+	if cfg.ArbBuffer <= 0 {
+		cfg.ArbBuffer = def.ArbBuffer
+	}
+
+	s := &Server{
+		cfg:   cfg,
+		bc:    bc,
+		ub:    ub,
+		ctx:   context.Background(),
+		conns: make(map[*conn]struct{}),
+	}
+	s.upgrader = websocket.Upgrader{CheckOrigin: s.checkOrigin}
+	return s
+}
+
+// Handler returns the gateway's HTTP handler, serving WebSocket upgrades
+// on /ws.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", s.serveWS)
+	return mux
+}
+
+// Run fans arbitrage events out to subscribed connections. It takes over
+// the UnifiedBook's Events channel. It blocks until ctx is cancelled, then
+// closes every connection.
+func (s *Server) Run(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+
+	var events <-chan adapter.ArbitrageEvent
+	if s.ub != nil {
+		events = s.ub.Events()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			for c := range s.conns {
+				c.cancel()
+			}
+			s.mu.Unlock()
+			return
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			s.mu.Lock()
+			for c := range s.conns {
+				c.offerArb(ev)
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// ListenAndServe serves Handler on addr until ctx is cancelled.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{Addr: addr, Handler: s.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdown)
+	}
+}
+
+func (s *Server) serveWS(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.Auth == nil {
+		http.Error(w, "gateway has no authenticator", http.StatusServiceUnavailable)
+		return
+	}
+	client, err := s.cfg.Auth.Authenticate(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade has replied
+	}
+
+	s.mu.Lock()
+	c := newConn(s, ws, client, s.ctx)
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+
+	log.Printf("gateway: %s connected from %s", client, r.RemoteAddr)
+	start := time.Now()
+	c.run()
+
+	s.mu.Lock()
+	delete(s.conns, c)
+	s.mu.Unlock()
+	log.Printf("gateway: %s disconnected after %dms", client, time.Since(start).Milliseconds())
+}
+
+// checkOrigin accepts requests without an Origin, from the gateway's own
+// host, or from AllowedOrigins.
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range s.cfg.AllowedOrigins {
+		if strings.EqualFold(u.Host, allowed) {
+			return true
+		}
+	}
+	return false
+}
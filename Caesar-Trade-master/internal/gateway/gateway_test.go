@@ -0,0 +1,338 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caesar-terminal/caesar/internal/adapter"
+	"github.com/gorilla/websocket"
+)
+
+// mockProvider feeds BookUpdates into the Broadcaster.
+type mockProvider struct {
+	ch chan adapter.BookUpdate
+}
+
+func (m *mockProvider) Updates() <-chan adapter.BookUpdate { return m.ch }
+
+func (m *mockProvider) send(u adapter.BookUpdate) { m.ch <- u }
+
+const testToken = "s3cret"
+
+// testGateway runs a Broadcaster, a UnifiedBook with testPair and a
+// gateway behind httptest.
+type testGateway struct {
+	src *mockProvider
+	bc  *adapter.Broadcaster
+	srv *httptest.Server
+}
+
+var testPair = adapter.MarketPair{
+	Name:           "BTC > $100k",
+	PolyMarketID:   "0xbtc",
+	KalshiMarketID: "KXBTC-100K",
+}
+
+func newTestGateway(t *testing.T) *testGateway {
+	t.Helper()
+	src := &mockProvider{ch: make(chan adapter.BookUpdate, 64)}
+	bc := adapter.NewBroadcaster()
+	bc.Register(src)
+	ub := adapter.NewUnifiedBook(bc, 0)
+	ub.AddPair(testPair)
+
+	gw := New(Config{Auth: TokenAuth{testToken: "cockpit"}}, bc, ub)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go bc.Run(ctx)
+	go ub.Run(ctx)
+	go gw.Run(ctx)
+
+	srv := httptest.NewServer(gw.Handler())
+	t.Cleanup(func() {
+		cancel()
+		srv.Close()
+	})
+	return &testGateway{src: src, bc: bc, srv: srv}
+}
+
+func (g *testGateway) url() string {
+	return "ws" + strings.TrimPrefix(g.srv.URL, "http") + "/ws"
+}
+
+// publish sends u and waits until the Broadcaster has cached it.
+func (g *testGateway) publish(t *testing.T, u adapter.BookUpdate) {
+	t.Helper()
+	g.src.send(u)
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _, got := range g.bc.Latest(u.Exchange, u.MarketID) {
+			if got.AssetID == u.AssetID && got.Hash == u.Hash {
+				return
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	t.Fatalf("update %s/%s never distributed", u.Exchange, u.MarketID)
+}
+
+func (g *testGateway) dial(t *testing.T) *websocket.Conn {
+	t.Helper()
+	h := http.Header{"Authorization": {"Bearer " + testToken}}
+	c, _, err := websocket.DefaultDialer.Dial(g.url(), h)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func send(t *testing.T, c *websocket.Conn, req request) {
+	t.Helper()
+	if err := c.WriteJSON(req); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func recv(t *testing.T, c *websocket.Conn) frame {
+	t.Helper()
+	c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var f frame
+	if err := c.ReadJSON(&f); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return f
+}
+
+func expect(t *testing.T, c *websocket.Conn, typ string) frame {
+	t.Helper()
+	f := recv(t, c)
+	if f.Type != typ {
+		t.Fatalf("expected %s frame, got %+v", typ, f)
+	}
+	return f
+}
+
+func book(ex adapter.Exchange, market, hash string, bid, ask float64) adapter.BookUpdate {
+	return adapter.BookUpdate{
+		Exchange:  ex,
+		MarketID:  market,
+		Bids:      []adapter.PriceLevel{{Price: bid, Size: 10}},
+		Asks:      []adapter.PriceLevel{{Price: ask, Size: 10}},
+		Timestamp: time.UnixMilli(1700000000000),
+		Hash:      hash,
+	}
+}
+
+func TestGateway_Auth(t *testing.T) {
+	g := newTestGateway(t)
+
+	for name, h := range map[string]http.Header{
+		"none":  nil,
+		"wrong": {"Authorization": {"Bearer nope"}},
+	} {
+		_, resp, err := websocket.DefaultDialer.Dial(g.url(), h)
+		if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("%s: expected 401, got %v", name, err)
+		}
+	}
+
+	c, _, err := websocket.DefaultDialer.Dial(g.url()+"?token="+testToken, nil)
+	if err != nil {
+		t.Fatalf("query token should be accepted: %v", err)
+	}
+	c.Close()
+
+	h := http.Header{
+		"Authorization": {"Bearer " + testToken},
+		"Origin":        {"https://evil.example"},
+	}
+	if _, _, err := websocket.DefaultDialer.Dial(g.url(), h); err == nil {
+		t.Fatal("expected foreign origin rejected")
+	}
+}
+
+func TestGateway_BookSnapshotAndUpdates(t *testing.T) {
+	g := newTestGateway(t)
+	g.publish(t, book(adapter.ExchangeKalshi, "KXBTC-100K", "h1", 0.40, 0.45))
+
+	c := g.dial(t)
+	send(t, c, request{Op: "subscribe", ID: 7, Channel: ChannelBook, Exchange: adapter.ExchangeKalshi, Market: "KXBTC-100K"})
+
+	ack := expect(t, c, frameAck)
+	if ack.ID != 7 || ack.Channel != "book:kalshi:KXBTC-100K" {
+		t.Fatalf("unexpected ack %+v", ack)
+	}
+	snap := expect(t, c, frameSnapshot)
+	if snap.Seq != 1 || snap.Channel != ack.Channel {
+		t.Fatalf("unexpected snapshot %+v", snap)
+	}
+	if snap.Book.Market != "KXBTC-100K" || snap.Book.Bids[0] != (level{0.40, 10}) || snap.Book.TS != 1700000000000 {
+		t.Fatalf("unexpected snapshot book %+v", snap.Book)
+	}
+
+	g.publish(t, book(adapter.ExchangeKalshi, "KXBTC-100K", "h2", 0.41, 0.45))
+	upd := expect(t, c, frameUpdate)
+	if upd.Seq != 2 || upd.Book.Bids[0][0] != 0.41 {
+		t.Fatalf("unexpected update %+v", upd)
+	}
+
+	// Errors are reported against the request and leave the stream intact.
+	send(t, c, request{Op: "subscribe", ID: 8, Channel: ChannelBook, Exchange: adapter.ExchangeKalshi, Market: "KXBTC-100K"})
+	if f := expect(t, c, frameError); f.ID != 8 || !strings.Contains(f.Error, "already subscribed") {
+		t.Fatalf("unexpected error frame %+v", f)
+	}
+	send(t, c, request{Op: "subscribe", ID: 9, Channel: "news"})
+	if f := expect(t, c, frameError); f.ID != 9 {
+		t.Fatalf("unexpected error frame %+v", f)
+	}
+
+	send(t, c, request{Op: "unsubscribe", ID: 10, Channel: ChannelBook, Exchange: adapter.ExchangeKalshi, Market: "KXBTC-100K"})
+	expect(t, c, frameAck)
+	g.publish(t, book(adapter.ExchangeKalshi, "KXBTC-100K", "h3", 0.42, 0.45))
+	c.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	var f frame
+	if err := c.ReadJSON(&f); err == nil {
+		t.Fatalf("expected no frames after unsubscribe, got %+v", f)
+	}
+}
+
+func TestGateway_BookSnapshotPerOutcome(t *testing.T) {
+	g := newTestGateway(t)
+	yes := book(adapter.ExchangePolymarket, "0xm", "y1", 0.60, 0.62)
+	yes.AssetID = "yes"
+	no := book(adapter.ExchangePolymarket, "0xm", "n1", 0.38, 0.40)
+	no.AssetID = "no"
+	g.publish(t, yes)
+	g.publish(t, no)
+
+	c := g.dial(t)
+	send(t, c, request{Op: "subscribe", ID: 1, Channel: ChannelBook, Exchange: adapter.ExchangePolymarket, Market: "0xm"})
+	expect(t, c, frameAck)
+
+	// Both outcome books get a snapshot; neither overwrites the other.
+	bids := map[string]float64{}
+	for range 2 {
+		snap := expect(t, c, frameSnapshot)
+		bids[snap.Book.Asset] = snap.Book.Bids[0][0]
+	}
+	if bids["yes"] != 0.60 || bids["no"] != 0.38 {
+		t.Fatalf("expected YES and NO snapshots, got %v", bids)
+	}
+
+	no = book(adapter.ExchangePolymarket, "0xm", "n2", 0.39, 0.40)
+	no.AssetID = "no"
+	g.publish(t, no)
+	if upd := expect(t, c, frameUpdate); upd.Book.Asset != "no" || upd.Book.Bids[0][0] != 0.39 {
+		t.Fatalf("unexpected update %+v", upd.Book)
+	}
+}
+
+func TestGateway_PairAndArbitrage(t *testing.T) {
+	g := newTestGateway(t)
+	c := g.dial(t)
+
+	send(t, c, request{Op: "subscribe", ID: 1, Channel: ChannelArb, Pair: testPair.Name})
+	expect(t, c, frameAck)
+	send(t, c, request{Op: "subscribe", ID: 2, Channel: ChannelPair, Pair: testPair.Name})
+	expect(t, c, frameAck)
+
+	// Crossed books: Polymarket bid above Kalshi ask.
+	g.publish(t, book(adapter.ExchangePolymarket, "0xbtc", "p1", 0.60, 0.62))
+	g.publish(t, book(adapter.ExchangeKalshi, "KXBTC-100K", "k1", 0.50, 0.55))
+
+	snapshots := map[adapter.Exchange]bool{}
+	var arb *arbMsg
+	var lastSeq uint64
+	for arb == nil || len(snapshots) < 2 {
+		f := recv(t, c)
+		if f.Seq != lastSeq+1 {
+			t.Fatalf("sequence gap: %d after %d", f.Seq, lastSeq)
+		}
+		lastSeq = f.Seq
+		switch f.Type {
+		case frameSnapshot:
+			if f.Channel != "pair:"+testPair.Name {
+				t.Fatalf("unexpected channel %q", f.Channel)
+			}
+			snapshots[f.Book.Exchange] = true
+		case frameArb:
+			if f.Channel != "arb:"+testPair.Name {
+				t.Fatalf("unexpected channel %q", f.Channel)
+			}
+			arb = f.Arb
+		default:
+			t.Fatalf("unexpected frame %+v", f)
+		}
+	}
+	if arb.Pair != testPair.Name || arb.BidExchange != adapter.ExchangePolymarket || arb.Bid != 0.60 || arb.Ask != 0.55 {
+		t.Fatalf("unexpected arb %+v", arb)
+	}
+
+	send(t, c, request{Op: "subscribe", ID: 3, Channel: ChannelPair, Pair: "unknown"})
+	if f := expect(t, c, frameError); !strings.Contains(f.Error, "unknown pair") {
+		t.Fatalf("unexpected error %+v", f)
+	}
+}
+
+func TestGateway_MarketRemovedClosesSubscription(t *testing.T) {
+	g := newTestGateway(t)
+	c := g.dial(t)
+
+	send(t, c, request{Op: "subscribe", Channel: ChannelBook, Exchange: adapter.ExchangePolymarket, Market: "0xgone"})
+	expect(t, c, frameAck)
+
+	g.bc.RemoveMarket(adapter.ExchangePolymarket, "0xgone")
+	f := expect(t, c, frameClosed)
+	if f.Channel != "book:polymarket:0xgone" || !strings.Contains(f.Error, "market removed") {
+		t.Fatalf("unexpected closed frame %+v", f)
+	}
+
+	// The channel can be subscribed again.
+	send(t, c, request{Op: "subscribe", Channel: ChannelBook, Exchange: adapter.ExchangePolymarket, Market: "0xgone"})
+	expect(t, c, frameAck)
+}
+
+func TestConn_ConflationAndGaps(t *testing.T) {
+	s := New(Config{ArbBuffer: 1}, adapter.NewBroadcaster(), nil)
+	c := newConn(s, nil, "test", context.Background())
+
+	sub := &subscription{arb: true}
+	c.subs["book:kalshi:A"] = &subscription{}
+	c.subs["arb"] = sub
+	books := c.subs["book:kalshi:A"]
+
+	// Three books before the writer runs collapse into one snapshot.
+	for _, bid := range []float64{0.40, 0.41, 0.42} {
+		c.offer("book:kalshi:A", books, book(adapter.ExchangeKalshi, "A", "", bid, 0.5))
+	}
+	if len(c.order) != 1 {
+		t.Fatalf("expected one pending leg, got %d", len(c.order))
+	}
+	p := c.pending[c.order[0]]
+	if !p.snapshot || p.update.Bids[0].Price != 0.42 {
+		t.Fatalf("expected the latest book as snapshot, got %+v", p)
+	}
+
+	// A stale pump from an earlier subscription is ignored.
+	c.offer("book:kalshi:A", &subscription{}, book(adapter.ExchangeKalshi, "A", "", 0.10, 0.5))
+	if c.pending[c.order[0]].update.Bids[0].Price != 0.42 {
+		t.Fatal("stale subscription overwrote the pending book")
+	}
+
+	// Arb events beyond the queue are dropped and skip sequence numbers.
+	ev := adapter.ArbitrageEvent{Pair: testPair}
+	c.offerArb(ev)
+	c.offerArb(ev)
+	c.offerArb(ev)
+	if got := c.nextSeq(); got != 3 {
+		t.Fatalf("expected seq 3 after two drops, got %d", got)
+	}
+	if got := c.nextSeq(); got != 4 {
+		t.Fatalf("expected seq 4, got %d", got)
+	}
+}
@@ -0,0 +1,107 @@
+package gateway
+
+import "github.com/caesar-terminal/caesar/internal/adapter"
+
+// Channels a client can subscribe to.
+const (
+	ChannelBook = "book" // one market's book: exchange + market
+	ChannelPair = "pair" // both legs of a UnifiedBook pair: pair
+	ChannelArb  = "arb"  // arbitrage events, optionally for one pair
+)
+
+// request is a client message.
+//
+//	{"op":"subscribe","id":1,"channel":"book","exchange":"kalshi","market":"KXBTC-25"}
+//	{"op":"subscribe","id":2,"channel":"pair","pair":"BTC > $100k"}
+//	{"op":"subscribe","id":3,"channel":"arb"}
+//	{"op":"unsubscribe","id":4,"channel":"book","exchange":"kalshi","market":"KXBTC-25"}
+type request struct {
+	Op       string           `json:"op"`
+	ID       int64            `json:"id,omitempty"`
+	Channel  string           `json:"channel"`
+	Exchange adapter.Exchange `json:"exchange,omitempty"`
+	Market   string           `json:"market,omitempty"`
+	Pair     string           `json:"pair,omitempty"`
+}
+
+// Frame types sent to clients.
+const (
+	frameAck      = "ack"      // request accepted
+	frameError    = "error"    // request rejected
+	frameSnapshot = "snapshot" // first book of a subscription leg
+	frameUpdate   = "update"   // later books
+	frameArb      = "arb"      // arbitrage event
+	frameClosed   = "closed"   // subscription ended by the server
+)
+
+// frame is a server message. Data frames (snapshot, update, arb) carry Seq,
+// which increases by exactly one per data frame on a connection; a jump
+// means frames were dropped and the client should resubscribe. Books are
+// conflated per connection rather than dropped, so only arb frames can be
+// lost.
+type frame struct {
+	Type    string   `json:"type"`
+	Seq     uint64   `json:"seq,omitempty"`
+	ID      int64    `json:"id,omitempty"`
+	Channel string   `json:"channel,omitempty"`
+	Error   string   `json:"error,omitempty"`
+	Book    *bookMsg `json:"book,omitempty"`
+	Arb     *arbMsg  `json:"arb,omitempty"`
+}
+
+// level is a price level as [price, size].
+type level [2]float64
+
+type bookMsg struct {
+	Exchange adapter.Exchange `json:"exchange"`
+	Market   string           `json:"market"`
+	Asset    string           `json:"asset,omitempty"`
+	Bids     []level          `json:"bids"`
+	Asks     []level          `json:"asks"`
+	TS       int64            `json:"ts"` // exchange time, ms; receive time if unknown
+}
+
+func newBookMsg(u adapter.BookUpdate) *bookMsg {
+	ts := u.ExchangeTime
+	if ts.IsZero() {
+		ts = u.Timestamp
+	}
+	return &bookMsg{
+		Exchange: u.Exchange,
+		Market:   u.MarketID,
+		Asset:    u.AssetID,
+		Bids:     levels(u.Bids),
+		Asks:     levels(u.Asks),
+		TS:       ts.UnixMilli(),
+	}
+}
+
+func levels(ls []adapter.PriceLevel) []level {
+	out := make([]level, len(ls))
+	for i, l := range ls {
+		out[i] = level{l.Price, l.Size}
+	}
+	return out
+}
+
+type arbMsg struct {
+	Pair        string           `json:"pair"`
+	BidExchange adapter.Exchange `json:"bid_exchange"`
+	AskExchange adapter.Exchange `json:"ask_exchange"`
+	Bid         float64          `json:"bid"`
+	Ask         float64          `json:"ask"`
+	Spread      float64          `json:"spread"`
+	TS          int64            `json:"ts"`
+}
+
+func newArbMsg(ev adapter.ArbitrageEvent) *arbMsg {
+	return &arbMsg{
+		Pair:        ev.Pair.Name,
+		BidExchange: ev.BidExchange,
+		AskExchange: ev.AskExchange,
+		Bid:         ev.Bid,
+		Ask:         ev.Ask,
+		Spread:      ev.Spread,
+		TS:          ev.Timestamp.UnixMilli(),
+	}
+}